@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/mungkiice/-loan-service/internal/usecase"
+)
+
+// runExpiryWorker expires under-subscribed loans past their funding deadline
+// every interval until ctx is cancelled
+func runExpiryWorker(ctx context.Context, loanUseCase *usecase.LoanUseCase, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			expired, err := loanUseCase.ExpireOverdueLoans(ctx, now)
+			if err != nil {
+				log.Printf("expiry worker: %v", err)
+			}
+			if expired > 0 {
+				log.Printf("expiry worker: expired %d loan(s) past their funding deadline", expired)
+			}
+		}
+	}
+}
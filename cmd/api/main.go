@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"expvar"
 	"fmt"
 	"log"
 	"os"
@@ -10,12 +11,18 @@ import (
 
 	"github.com/mungkiice/-loan-service/internal/config"
 	"github.com/mungkiice/-loan-service/internal/delivery/http"
+	"github.com/mungkiice/-loan-service/internal/idempotency"
+	"github.com/mungkiice/-loan-service/internal/infrastructure/agreement"
 	"github.com/mungkiice/-loan-service/internal/infrastructure/email"
 	"github.com/mungkiice/-loan-service/internal/infrastructure/jwt"
 	"github.com/mungkiice/-loan-service/internal/infrastructure/redis"
 	"github.com/mungkiice/-loan-service/internal/infrastructure/storage"
+	"github.com/mungkiice/-loan-service/internal/ledger"
+	"github.com/mungkiice/-loan-service/internal/notification"
+	"github.com/mungkiice/-loan-service/internal/outbox"
 	"github.com/mungkiice/-loan-service/internal/repository/postgres"
 	"github.com/mungkiice/-loan-service/internal/usecase"
+	"github.com/mungkiice/-loan-service/internal/webhook"
 )
 
 func main() {
@@ -47,42 +54,146 @@ func main() {
 		log.Fatalf("failed to init file storage: %v", err)
 	}
 
-	emailService := email.NewMockEmailService()
+	agreementGenerator, err := agreement.NewPDFGenerator()
+	if err != nil {
+		log.Fatalf("failed to init agreement generator: %v", err)
+	}
+
+	var emailSender email.Sender = email.NewMockSender()
 	if cfg.Email.Provider == "smtp" {
-		// TODO: implement SMTP
-		emailService = email.NewMockEmailService()
+		smtp := cfg.Email.SMTP
+		emailSender, err = email.NewSMTPSender(smtp.Host, smtp.Port, smtp.Username, smtp.Password, smtp.From)
+		if err != nil {
+			log.Fatalf("failed to init smtp email sender: %v", err)
+		}
+	}
+
+	notificationRenderer, err := notification.NewRenderer()
+	if err != nil {
+		log.Fatalf("failed to init notification templates: %v", err)
 	}
 
 	loanRepo := postgres.NewLoanRepository(db)
 	approvalRepo := postgres.NewApprovalRepository(db)
 	investmentRepo := postgres.NewInvestmentRepository(db)
 	disbursementRepo := postgres.NewDisbursementRepository(db)
+	installmentRepo := postgres.NewInstallmentRepository(db)
+	repaymentRepo := postgres.NewRepaymentRepository(db)
+	payoutRepo := postgres.NewPayoutRepository(db)
+	closureRepo := postgres.NewClosureRepository(db)
 	userRepo := postgres.NewUserRepository(db)
 	employeeRepo := postgres.NewEmployeeRepository(db)
 	investorRepo := postgres.NewInvestorRepository(db)
+	borrowerRepo := postgres.NewBorrowerRepository(db)
+	txManager := postgres.NewTxManager(db)
+	ledgerRepo := postgres.NewLedgerRepository(db)
+	loanLedger := ledger.New(ledgerRepo)
+	outboxRepo := postgres.NewOutboxRepository(db)
+	loanOutbox := outbox.New(outboxRepo)
+	notificationPrefsRepo := postgres.NewNotificationPreferenceRepository(db)
+	inAppNotificationRepo := postgres.NewInAppNotificationRepository(db)
+	notifier := notification.NewPublisher(notificationPrefsRepo, loanOutbox)
+	webhookSubscriptionRepo := postgres.NewWebhookSubscriptionRepository(db)
+	webhookAttemptRepo := postgres.NewWebhookAttemptRepository(db)
+	webhooks := webhook.NewPublisher(webhookSubscriptionRepo, loanOutbox)
+	var idempotencyStore idempotency.Store = redis.NewIdempotencyStore(redisClient)
+	if cfg.App.IdempotencyStore == "postgres" {
+		idempotencyStore = postgres.NewIdempotencyRepository(db)
+	}
+	idempotencyKeeper := idempotency.NewKeeper(idempotencyStore, cfg.App.IdempotencyTTL, cfg.App.LockTTL)
+	locker := redis.NewLocker(redisClient, redis.LockOptions{
+		TTL:           cfg.App.LockTTL,
+		Wait:          cfg.App.LockWait,
+		RetryInterval: cfg.App.LockRetryInterval,
+		Renew:         cfg.App.LockRenewal,
+	})
 
 	jwtService := jwt.NewJWTService(cfg.App.JWTSecret, cfg.App.JWTExpiration)
 
 	loanUseCase := usecase.NewLoanUseCase(
 		loanRepo,
+		borrowerRepo,
 		approvalRepo,
 		investmentRepo,
 		disbursementRepo,
+		installmentRepo,
+		closureRepo,
 		userRepo,
+		investorRepo,
+		employeeRepo,
+		txManager,
+		loanLedger,
+		loanOutbox,
+		notifier,
+		webhooks,
 		redisClient,
+		idempotencyKeeper,
+		locker,
 		fileStorage,
-		emailService,
+		agreementGenerator,
+		cfg.App.FundingWindow,
+		cfg.App.CacheTTL,
+	)
+	expvar.Publish("loan_cache", expvar.Func(func() any { return loanUseCase.CacheStats() }))
+
+	repaymentUseCase := usecase.NewRepaymentUseCase(
+		loanRepo,
+		investmentRepo,
+		installmentRepo,
+		repaymentRepo,
+		payoutRepo,
+		borrowerRepo,
+		investorRepo,
+		employeeRepo,
+		txManager,
+		loanLedger,
+		redisClient,
+		idempotencyKeeper,
+		locker,
 	)
 
-	authUseCase := usecase.NewAuthUseCase(userRepo, employeeRepo, investorRepo, jwtService)
+	ledgerUseCase := usecase.NewLedgerUseCase(loanRepo, investmentRepo, loanLedger)
+
+	borrowerUseCase := usecase.NewBorrowerUseCase(borrowerRepo, userRepo, txManager, idempotencyKeeper)
+
+	notificationUseCase := usecase.NewNotificationUseCase(notificationPrefsRepo, inAppNotificationRepo)
+
+	webhookUseCase := usecase.NewWebhookUseCase(webhookSubscriptionRepo, webhookAttemptRepo)
+
+	authUseCase := usecase.NewAuthUseCase(userRepo, employeeRepo, investorRepo, borrowerRepo, jwtService)
 
 	handler := http.NewHandler(loanUseCase)
 	authHandler := http.NewAuthHandler(authUseCase)
-	router := http.SetupRouter(handler, authHandler, authUseCase)
+	repaymentHandler := http.NewRepaymentHandler(repaymentUseCase)
+	ledgerHandler := http.NewLedgerHandler(ledgerUseCase)
+	borrowerHandler := http.NewBorrowerHandler(borrowerUseCase)
+	notificationHandler := http.NewNotificationHandler(notificationUseCase)
+	webhookHandler := http.NewWebhookHandler(webhookUseCase)
+	router := http.SetupRouter(handler, authHandler, repaymentHandler, ledgerHandler, borrowerHandler, notificationHandler, webhookHandler, authUseCase)
 
 	addr := fmt.Sprintf("%s:%s", cfg.Server.Host, cfg.Server.Port)
 	go router.Run(addr)
 
+	workerCtx, stopWorkers := context.WithCancel(ctx)
+	defer stopWorkers()
+	go runExpiryWorker(workerCtx, loanUseCase, cfg.App.ExpiryInterval)
+
+	dispatcher := outbox.NewDispatcher(outboxRepo, outbox.Config{
+		BatchSize:   cfg.Outbox.BatchSize,
+		MaxAttempts: cfg.Outbox.MaxAttempts,
+		BaseBackoff: cfg.Outbox.BaseBackoff,
+		MaxBackoff:  cfg.Outbox.MaxBackoff,
+		Lease:       cfg.Outbox.Lease,
+	})
+	usecase.RegisterOutboxHandlers(dispatcher)
+	notification.RegisterHandlers(dispatcher, notificationRenderer, map[notification.Channel]notification.Sender{
+		notification.ChannelEmail: notification.NewEmailChannel(emailSender, fileStorage),
+		notification.ChannelSMS:   notification.NewSMSChannel(),
+		notification.ChannelInApp: notification.NewInAppChannel(inAppNotificationRepo),
+	})
+	webhook.NewSender(webhookSubscriptionRepo, webhookAttemptRepo, cfg.Webhook.Timeout).Register(dispatcher)
+	go dispatcher.Run(workerCtx, cfg.Outbox.PollInterval)
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
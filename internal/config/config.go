@@ -14,6 +14,8 @@ type Config struct {
 	Redis    RedisConfig    `yaml:"redis"`
 	Storage  StorageConfig  `yaml:"storage"`
 	Email    EmailConfig    `yaml:"email"`
+	Outbox   OutboxConfig   `yaml:"outbox"`
+	Webhook  WebhookConfig  `yaml:"webhook"`
 	App      AppConfig      `yaml:"app"`
 }
 
@@ -81,14 +83,37 @@ type SMTPConfig struct {
 	From     string `yaml:"from"`
 }
 
+type OutboxConfig struct {
+	PollInterval time.Duration `yaml:"poll_interval"`
+	BatchSize    int           `yaml:"batch_size"`
+	MaxAttempts  int           `yaml:"max_attempts"`
+	BaseBackoff  time.Duration `yaml:"base_backoff"`
+	MaxBackoff   time.Duration `yaml:"max_backoff"`
+	Lease        time.Duration `yaml:"lease"`
+}
+
+type WebhookConfig struct {
+	Timeout time.Duration `yaml:"timeout"`
+}
+
 type AppConfig struct {
 	Environment    string        `yaml:"environment"`
 	LogLevel       string        `yaml:"log_level"`
 	IdempotencyTTL time.Duration `yaml:"idempotency_ttl"`
-	CacheTTL       time.Duration `yaml:"cache_ttl"`
-	LockTTL        time.Duration `yaml:"lock_ttl"`
+	// IdempotencyStore is "redis" or "postgres". It chooses where keys are
+	// kept; requests do not fall back to the other store when it is down.
+	IdempotencyStore string        `yaml:"idempotency_store"`
+	CacheTTL         time.Duration `yaml:"cache_ttl"`
+	LockTTL          time.Duration `yaml:"lock_ttl"`
+	// LockWait is how long a request waits for a lock held by another one
+	LockWait          time.Duration `yaml:"lock_wait"`
+	LockRetryInterval time.Duration `yaml:"lock_retry_interval"`
+	// LockRenewal keeps locks alive while their holder is still running
+	LockRenewal    bool          `yaml:"lock_renewal"`
 	JWTSecret      string        `yaml:"jwt_secret"`
 	JWTExpiration  time.Duration `yaml:"jwt_expiration"`
+	FundingWindow  time.Duration `yaml:"funding_window"`
+	ExpiryInterval time.Duration `yaml:"expiry_interval"`
 }
 
 func Load(configPath string) (*Config, error) {
@@ -238,6 +263,29 @@ func setDefaults(cfg *Config) {
 		cfg.Email.Provider = "mock"
 	}
 
+	if cfg.Outbox.PollInterval == 0 {
+		cfg.Outbox.PollInterval = 5 * time.Second
+	}
+	if cfg.Outbox.BatchSize == 0 {
+		cfg.Outbox.BatchSize = 50
+	}
+	if cfg.Outbox.MaxAttempts == 0 {
+		cfg.Outbox.MaxAttempts = 8
+	}
+	if cfg.Outbox.BaseBackoff == 0 {
+		cfg.Outbox.BaseBackoff = 30 * time.Second
+	}
+	if cfg.Outbox.MaxBackoff == 0 {
+		cfg.Outbox.MaxBackoff = time.Hour
+	}
+	if cfg.Outbox.Lease == 0 {
+		cfg.Outbox.Lease = time.Minute
+	}
+
+	if cfg.Webhook.Timeout == 0 {
+		cfg.Webhook.Timeout = 10 * time.Second
+	}
+
 	if cfg.App.Environment == "" {
 		cfg.App.Environment = "development"
 	}
@@ -247,16 +295,31 @@ func setDefaults(cfg *Config) {
 	if cfg.App.IdempotencyTTL == 0 {
 		cfg.App.IdempotencyTTL = 24 * time.Hour
 	}
+	if cfg.App.IdempotencyStore == "" {
+		cfg.App.IdempotencyStore = "redis"
+	}
 	if cfg.App.CacheTTL == 0 {
 		cfg.App.CacheTTL = 5 * time.Minute
 	}
 	if cfg.App.LockTTL == 0 {
 		cfg.App.LockTTL = 30 * time.Second
 	}
+	if cfg.App.LockWait == 0 {
+		cfg.App.LockWait = 5 * time.Second
+	}
+	if cfg.App.LockRetryInterval == 0 {
+		cfg.App.LockRetryInterval = 50 * time.Millisecond
+	}
 	if cfg.App.JWTSecret == "" {
 		cfg.App.JWTSecret = "your-secret-key-change-in-production"
 	}
 	if cfg.App.JWTExpiration == 0 {
 		cfg.App.JWTExpiration = 24 * time.Hour
 	}
+	if cfg.App.FundingWindow == 0 {
+		cfg.App.FundingWindow = 30 * 24 * time.Hour
+	}
+	if cfg.App.ExpiryInterval == 0 {
+		cfg.App.ExpiryInterval = time.Minute
+	}
 }
@@ -0,0 +1,171 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mungkiice/-loan-service/internal/domain"
+	"github.com/mungkiice/-loan-service/internal/usecase"
+)
+
+type BorrowerHandler struct {
+	borrowerUseCase *usecase.BorrowerUseCase
+}
+
+func NewBorrowerHandler(borrowerUseCase *usecase.BorrowerUseCase) *BorrowerHandler {
+	return &BorrowerHandler{borrowerUseCase: borrowerUseCase}
+}
+
+type RegisterBorrowerRequest struct {
+	Name        string  `json:"name" binding:"required"`
+	NationalID  *string `json:"national_id"`
+	Phone       *string `json:"phone"`
+	Address     *string `json:"address"`
+	DateOfBirth string  `json:"date_of_birth"`
+	Email       string  `json:"email" binding:"omitempty,email"`
+	Password    string  `json:"password" binding:"required_with=Email,omitempty,min=6"`
+	// IdempotencyKey is optional
+	IdempotencyKey string `json:"idempotency_key"`
+}
+
+type BorrowerResponse struct {
+	ID            string     `json:"id"`
+	UserID        *string    `json:"user_id,omitempty"`
+	Name          string     `json:"name"`
+	NationalID    *string    `json:"national_id,omitempty"`
+	Phone         *string    `json:"phone,omitempty"`
+	Address       *string    `json:"address,omitempty"`
+	DateOfBirth   *string    `json:"date_of_birth,omitempty"`
+	KYCStatus     string     `json:"kyc_status"`
+	KYCReviewedAt *time.Time `json:"kyc_reviewed_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func (h *BorrowerHandler) RegisterBorrower(c *gin.Context) {
+	var req RegisterBorrowerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var dateOfBirth *time.Time
+	if req.DateOfBirth != "" {
+		dob, err := time.Parse(time.DateOnly, req.DateOfBirth)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad date_of_birth, expected YYYY-MM-DD"})
+			return
+		}
+		dateOfBirth = &dob
+	}
+
+	borrower, err := h.borrowerUseCase.RegisterBorrower(c.Request.Context(), usecase.RegisterBorrowerRequest{
+		Name:           req.Name,
+		NationalID:     req.NationalID,
+		Phone:          req.Phone,
+		Address:        req.Address,
+		DateOfBirth:    dateOfBirth,
+		Email:          req.Email,
+		Password:       req.Password,
+		IdempotencyKey: req.IdempotencyKey,
+	})
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, toBorrowerResponse(borrower))
+}
+
+func (h *BorrowerHandler) GetBorrower(c *gin.Context) {
+	borrowerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid borrower id"})
+		return
+	}
+
+	borrower, err := h.borrowerUseCase.GetBorrower(c.Request.Context(), borrowerID)
+	if errors.Is(err, domain.ErrBorrowerNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toBorrowerResponse(borrower))
+}
+
+type ReviewKYCRequest struct {
+	Status string `json:"status" binding:"required,oneof=verified rejected"`
+	// IdempotencyKey is optional
+	IdempotencyKey string `json:"idempotency_key"`
+}
+
+func (h *BorrowerHandler) ReviewKYC(c *gin.Context) {
+	borrowerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid borrower id"})
+		return
+	}
+
+	var req ReviewKYCRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	eidStr, ok := userID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	eid, err := uuid.Parse(eidStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid employee id"})
+		return
+	}
+
+	borrower, err := h.borrowerUseCase.ReviewKYC(c.Request.Context(), usecase.ReviewKYCRequest{
+		BorrowerID:     borrowerID,
+		EmployeeID:     eid,
+		Status:         domain.KYCStatus(req.Status),
+		IdempotencyKey: req.IdempotencyKey,
+	})
+	if errors.Is(err, domain.ErrBorrowerNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toBorrowerResponse(borrower))
+}
+
+func toBorrowerResponse(b *domain.Borrower) BorrowerResponse {
+	res := BorrowerResponse{
+		ID:            b.ID.String(),
+		Name:          b.Name,
+		NationalID:    b.NationalID,
+		Phone:         b.Phone,
+		Address:       b.Address,
+		KYCStatus:     string(b.KYCStatus),
+		KYCReviewedAt: b.KYCReviewedAt,
+		CreatedAt:     b.CreatedAt,
+	}
+	if b.UserID != nil {
+		uid := b.UserID.String()
+		res.UserID = &uid
+	}
+	if b.DateOfBirth != nil {
+		dob := b.DateOfBirth.Format(time.DateOnly)
+		res.DateOfBirth = &dob
+	}
+	return res
+}
@@ -1,12 +1,18 @@
 package http
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/mungkiice/-loan-service/internal/domain"
+	"github.com/mungkiice/-loan-service/internal/idempotency"
+	"github.com/mungkiice/-loan-service/internal/infrastructure/redis"
 	"github.com/mungkiice/-loan-service/internal/usecase"
 )
 
@@ -18,39 +24,66 @@ func NewHandler(loanUseCase *usecase.LoanUseCase) *Handler {
 	return &Handler{loanUseCase: loanUseCase}
 }
 
+// CreateLoanRequest creates a loan. Borrowers may leave out borrower_id to
+// create a loan for themselves; employees must name the borrower.
 type CreateLoanRequest struct {
-	BorrowerID      string  `json:"borrower_id" binding:"required"`
-	PrincipalAmount float64 `json:"principal_amount" binding:"required,gt=0"`
-	Rate            float64 `json:"rate" binding:"required,gte=0"`
-	ROI             float64 `json:"roi" binding:"required,gte=0"`
+	BorrowerID      string         `json:"borrower_id"`
+	PrincipalAmount domain.Money   `json:"principal_amount"`
+	Rate            domain.Percent `json:"rate" binding:"required,gte=0"`
+	ROI             domain.Percent `json:"roi" binding:"required,gte=0"`
+	TenorMonths     int            `json:"tenor_months" binding:"omitempty,gt=0,max=360"`
+	RepaymentType   string         `json:"repayment_type" binding:"omitempty,oneof=flat annuity interest_only"`
+	IdempotencyKey  string         `json:"idempotency_key"`
 }
 
 func (h *Handler) CreateLoan(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
 	var req CreateLoanRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	borrowerID, err := uuid.Parse(req.BorrowerID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid borrower_id"})
+	var borrowerID uuid.UUID
+	if req.BorrowerID != "" || actor.UserType != domain.UserTypeBorrower {
+		var err error
+		borrowerID, err = uuid.Parse(req.BorrowerID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid borrower_id"})
+			return
+		}
+	}
+
+	if err := validateAmount(req.PrincipalAmount); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid principal_amount: " + err.Error()})
 		return
 	}
 
 	loan, err := h.loanUseCase.CreateLoan(c.Request.Context(), usecase.CreateLoanRequest{
+		Actor:           actor,
 		BorrowerID:      borrowerID,
 		PrincipalAmount: req.PrincipalAmount,
 		Rate:            req.Rate,
 		ROI:             req.ROI,
+		TenorMonths:     req.TenorMonths,
+		RepaymentType:   domain.RepaymentType(req.RepaymentType),
+		IdempotencyKey:  req.IdempotencyKey,
 	})
 
+	if errors.Is(err, domain.ErrBorrowerNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown borrower_id"})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, loan)
+	c.JSON(http.StatusCreated, toLoanResponse(loan))
 }
 
 type ApproveLoanRequest struct {
@@ -59,27 +92,21 @@ type ApproveLoanRequest struct {
 }
 
 func (h *Handler) ApproveLoan(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
 	loanID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid loan id"})
 		return
 	}
 
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadRequestSize)
 	var req ApproveLoanRequest
 	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	eidStr, ok := userID(c)
-	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-		return
-	}
-
-	eid, err := uuid.Parse(eidStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid employee id"})
+		c.JSON(bindStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -102,28 +129,36 @@ func (h *Handler) ApproveLoan(c *gin.Context) {
 	}
 	defer f.Close()
 
-	if err := h.loanUseCase.ApproveLoan(c.Request.Context(), usecase.ApproveLoanRequest{
+	if _, err := h.loanUseCase.ApproveLoan(c.Request.Context(), usecase.ApproveLoanRequest{
+		Actor:                actor,
 		LoanID:               loanID,
-		EmployeeID:           eid,
 		PictureProof:         f,
 		PictureProofFilename: file.Filename,
 		ApprovalDate:         approvalDate,
 		IdempotencyKey:       req.IdempotencyKey,
 	}); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"ok": true})
 }
 
+// InvestRequest invests in a loan. Investors invest as the investor in their
+// token and may leave out investor_id; admins investing on an investor's
+// behalf must name them.
 type InvestRequest struct {
-	InvestorID     string  `json:"investor_id" binding:"required"`
-	Amount         float64 `json:"amount" binding:"required,gt=0"`
-	IdempotencyKey string  `json:"idempotency_key" binding:"required"`
+	InvestorID     string       `json:"investor_id"`
+	Amount         domain.Money `json:"amount"`
+	IdempotencyKey string       `json:"idempotency_key" binding:"required"`
 }
 
 func (h *Handler) Invest(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
 	loanID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid loan id"})
@@ -136,19 +171,28 @@ func (h *Handler) Invest(c *gin.Context) {
 		return
 	}
 
-	investorID, err := uuid.Parse(req.InvestorID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid investor_id"})
+	var investorID uuid.UUID
+	if req.InvestorID != "" || actor.UserType != domain.UserTypeInvestor {
+		investorID, err = uuid.Parse(req.InvestorID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid investor_id"})
+			return
+		}
+	}
+
+	if err := validateAmount(req.Amount); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid amount: " + err.Error()})
 		return
 	}
 
-	if err := h.loanUseCase.Invest(c.Request.Context(), usecase.InvestRequest{
+	if _, err := h.loanUseCase.Invest(c.Request.Context(), usecase.InvestRequest{
+		Actor:          actor,
 		LoanID:         loanID,
 		InvestorID:     investorID,
 		Amount:         req.Amount,
 		IdempotencyKey: req.IdempotencyKey,
 	}); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -161,27 +205,21 @@ type DisburseLoanRequest struct {
 }
 
 func (h *Handler) DisburseLoan(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
 	loanID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid loan id"})
 		return
 	}
 
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadRequestSize)
 	var req DisburseLoanRequest
 	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	eidStr, ok := userID(c)
-	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-		return
-	}
-
-	eid, err := uuid.Parse(eidStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid employee id"})
+		c.JSON(bindStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -204,66 +242,463 @@ func (h *Handler) DisburseLoan(c *gin.Context) {
 	}
 	defer f.Close()
 
-	if err := h.loanUseCase.DisburseLoan(c.Request.Context(), usecase.DisburseLoanRequest{
+	if _, err := h.loanUseCase.DisburseLoan(c.Request.Context(), usecase.DisburseLoanRequest{
+		Actor:                   actor,
 		LoanID:                  loanID,
-		EmployeeID:              eid,
 		SignedAgreement:         f,
 		SignedAgreementFilename: file.Filename,
 		DisbursementDate:        disbursementDate,
 		IdempotencyKey:          req.IdempotencyKey,
 	}); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"ok": true})
 }
 
+type CloseLoanRequest struct {
+	Reason         string `json:"reason" binding:"required"`
+	Note           string `json:"note"`
+	IdempotencyKey string `json:"idempotency_key" binding:"required"`
+}
+
+func (h *Handler) RejectLoan(c *gin.Context) {
+	h.closeLoan(c, h.loanUseCase.RejectLoan)
+}
+
+func (h *Handler) CancelLoan(c *gin.Context) {
+	h.closeLoan(c, h.loanUseCase.CancelLoan)
+}
+
+func (h *Handler) closeLoan(c *gin.Context, closeFn func(context.Context, usecase.CloseLoanRequest) (*domain.LoanClosure, error)) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	loanID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid loan id"})
+		return
+	}
+
+	var req CloseLoanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	closure, err := closeFn(c.Request.Context(), usecase.CloseLoanRequest{
+		Actor:          actor,
+		LoanID:         loanID,
+		Reason:         domain.ClosureReason(req.Reason),
+		Note:           req.Note,
+		IdempotencyKey: req.IdempotencyKey,
+	})
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"loan_id":   closure.LoanID.String(),
+		"state":     closure.State,
+		"reason":    closure.Reason,
+		"note":      closure.Note,
+		"closed_at": closure.ClosedAt,
+	})
+}
+
+type LoanResponse struct {
+	ID                 string         `json:"id"`
+	BorrowerID         string         `json:"borrower_id"`
+	PrincipalAmount    domain.Money   `json:"principal_amount"`
+	Rate               domain.Percent `json:"rate"`
+	ROI                domain.Percent `json:"roi"`
+	TenorMonths        int            `json:"tenor_months"`
+	RepaymentType      string         `json:"repayment_type"`
+	State              string         `json:"state"`
+	AgreementLetterURL *string        `json:"agreement_letter_url,omitempty"`
+	FundingDeadline    *time.Time     `json:"funding_deadline,omitempty"`
+	Version            int            `json:"version"`
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+}
+
+type ApprovalResponse struct {
+	EmployeeID   string    `json:"employee_id"`
+	PictureProof string    `json:"picture_proof"`
+	ApprovalDate time.Time `json:"approval_date"`
+}
+
+type LoanInvestmentResponse struct {
+	ID                 string         `json:"id"`
+	InvestorID         string         `json:"investor_id"`
+	Amount             domain.Money   `json:"amount"`
+	Share              domain.Percent `json:"share"`
+	AgreementLetterURL *string        `json:"agreement_letter_url,omitempty"`
+	// PlacedBy is the admin who invested on the investor's behalf
+	PlacedBy  *string   `json:"placed_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type DisbursementResponse struct {
+	EmployeeID         string    `json:"employee_id"`
+	SignedAgreementURL string    `json:"signed_agreement_url"`
+	DisbursementDate   time.Time `json:"disbursement_date"`
+}
+
+// LoanDetailResponse is a loan with how far it is funded and the records of
+// each step it has been through; approval and disbursement are omitted until
+// the loan gets there
+type LoanDetailResponse struct {
+	LoanResponse
+	FundedAmount    domain.Money             `json:"funded_amount"`
+	FundedPercent   domain.Percent           `json:"funded_percent"`
+	RemainingAmount domain.Money             `json:"remaining_amount"`
+	Approval        *ApprovalResponse        `json:"approval,omitempty"`
+	Investments     []LoanInvestmentResponse `json:"investments"`
+	Disbursement    *DisbursementResponse    `json:"disbursement,omitempty"`
+}
+
+func toLoanResponse(l *domain.Loan) LoanResponse {
+	return LoanResponse{
+		ID:                 l.ID.String(),
+		BorrowerID:         l.BorrowerID.String(),
+		PrincipalAmount:    l.PrincipalAmount,
+		Rate:               l.Rate,
+		ROI:                l.ROI,
+		TenorMonths:        l.TenorMonths,
+		RepaymentType:      string(l.RepaymentType),
+		State:              string(l.State),
+		AgreementLetterURL: l.AgreementLetterURL,
+		FundingDeadline:    l.FundingDeadline,
+		Version:            l.Version,
+		CreatedAt:          l.CreatedAt,
+		UpdatedAt:          l.UpdatedAt,
+	}
+}
+
+func toLoanResponses(loans []*domain.Loan) []LoanResponse {
+	res := make([]LoanResponse, 0, len(loans))
+	for _, l := range loans {
+		res = append(res, toLoanResponse(l))
+	}
+	return res
+}
+
+func toLoanDetailResponse(d *domain.LoanDetails) LoanDetailResponse {
+	funded := d.FundedAmount()
+	res := LoanDetailResponse{
+		LoanResponse:    toLoanResponse(d.Loan),
+		FundedAmount:    funded,
+		FundedPercent:   d.Loan.FundingShare(funded),
+		RemainingAmount: d.RemainingAmount(),
+		Investments:     make([]LoanInvestmentResponse, 0, len(d.Investments)),
+	}
+	if d.Approval != nil {
+		res.Approval = &ApprovalResponse{
+			EmployeeID:   d.Approval.EmployeeID.String(),
+			PictureProof: d.Approval.PictureProof,
+			ApprovalDate: d.Approval.ApprovalDate,
+		}
+	}
+	for _, inv := range d.Investments {
+		investment := LoanInvestmentResponse{
+			ID:                 inv.ID.String(),
+			InvestorID:         inv.InvestorID.String(),
+			Amount:             inv.Amount,
+			Share:              d.Loan.FundingShare(inv.Amount),
+			AgreementLetterURL: inv.AgreementLetterURL,
+			CreatedAt:          inv.CreatedAt,
+		}
+		if inv.PlacedBy != nil {
+			placedBy := inv.PlacedBy.String()
+			investment.PlacedBy = &placedBy
+		}
+		res.Investments = append(res.Investments, investment)
+	}
+	if d.Disbursement != nil {
+		res.Disbursement = &DisbursementResponse{
+			EmployeeID:         d.Disbursement.EmployeeID.String(),
+			SignedAgreementURL: d.Disbursement.SignedAgreementURL,
+			DisbursementDate:   d.Disbursement.DisbursementDate,
+		}
+	}
+	return res
+}
+
 func (h *Handler) GetLoan(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
 	loanID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid loan id"})
 		return
 	}
 
-	loan, err := h.loanUseCase.GetLoan(c.Request.Context(), loanID)
+	details, err := h.loanUseCase.GetLoan(c.Request.Context(), actor, loanID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, loan)
+	c.JSON(http.StatusOK, toLoanDetailResponse(details))
+}
+
+// SearchLoansRequest holds the query parameters of GET /loans. States may be
+// repeated or comma-separated, amounts and percentages are decimal strings and
+// times are RFC 3339.
+type SearchLoansRequest struct {
+	States           []string `form:"state"`
+	BorrowerID       string   `form:"borrower_id"`
+	Currency         string   `form:"currency"`
+	MinPrincipal     string   `form:"min_principal"`
+	MaxPrincipal     string   `form:"max_principal"`
+	MinRate          string   `form:"min_rate"`
+	MaxRate          string   `form:"max_rate"`
+	CreatedFrom      string   `form:"created_from"`
+	CreatedTo        string   `form:"created_to"`
+	UpdatedFrom      string   `form:"updated_from"`
+	UpdatedTo        string   `form:"updated_to"`
+	MinFundedPercent string   `form:"min_funded_percent"`
+	MaxFundedPercent string   `form:"max_funded_percent"`
+	Sort             string   `form:"sort"`
+	Order            string   `form:"order"`
+	Limit            int      `form:"limit"`
+	Cursor           string   `form:"cursor"`
+}
+
+type LoanPageResponse struct {
+	Loans      []LoanResponse `json:"loans"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+func (r SearchLoansRequest) toQuery() (domain.LoanQuery, error) {
+	q := domain.LoanQuery{
+		Currency: domain.Currency(strings.ToUpper(r.Currency)),
+		Sort:     domain.LoanSort(r.Sort),
+		Order:    domain.SortOrder(r.Order),
+		Limit:    r.Limit,
+	}
+
+	for _, states := range r.States {
+		for _, state := range strings.Split(states, ",") {
+			if state != "" {
+				q.States = append(q.States, domain.LoanState(state))
+			}
+		}
+	}
+
+	if r.BorrowerID != "" {
+		borrowerID, err := uuid.Parse(r.BorrowerID)
+		if err != nil {
+			return q, errors.New("invalid borrower_id")
+		}
+		q.BorrowerID = &borrowerID
+	}
+
+	currency := q.Currency
+	if currency == "" {
+		currency = domain.DefaultCurrency
+	}
+	var err error
+	if q.MinPrincipal, err = parseOptionalMoney(r.MinPrincipal, currency, "min_principal"); err != nil {
+		return q, err
+	}
+	if q.MaxPrincipal, err = parseOptionalMoney(r.MaxPrincipal, currency, "max_principal"); err != nil {
+		return q, err
+	}
+	if q.MinRate, err = parseOptionalPercent(r.MinRate, "min_rate"); err != nil {
+		return q, err
+	}
+	if q.MaxRate, err = parseOptionalPercent(r.MaxRate, "max_rate"); err != nil {
+		return q, err
+	}
+	if q.MinFundedPercent, err = parseOptionalPercent(r.MinFundedPercent, "min_funded_percent"); err != nil {
+		return q, err
+	}
+	if q.MaxFundedPercent, err = parseOptionalPercent(r.MaxFundedPercent, "max_funded_percent"); err != nil {
+		return q, err
+	}
+	if q.CreatedFrom, err = parseOptionalTime(r.CreatedFrom, "created_from"); err != nil {
+		return q, err
+	}
+	if q.CreatedTo, err = parseOptionalTime(r.CreatedTo, "created_to"); err != nil {
+		return q, err
+	}
+	if q.UpdatedFrom, err = parseOptionalTime(r.UpdatedFrom, "updated_from"); err != nil {
+		return q, err
+	}
+	if q.UpdatedTo, err = parseOptionalTime(r.UpdatedTo, "updated_to"); err != nil {
+		return q, err
+	}
+
+	if r.Cursor != "" {
+		if q.After, err = domain.DecodeLoanCursor(r.Cursor); err != nil {
+			return q, err
+		}
+	}
+
+	return q, nil
+}
+
+func parseOptionalMoney(s string, currency domain.Currency, name string) (*domain.Money, error) {
+	if s == "" {
+		return nil, nil
+	}
+	m, err := domain.ParseMoney(s, currency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return &m, nil
+}
+
+func parseOptionalPercent(s, name string) (*domain.Percent, error) {
+	if s == "" {
+		return nil, nil
+	}
+	p, err := domain.ParsePercent(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return &p, nil
+}
+
+func parseOptionalTime(s, name string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: expected RFC 3339", name)
+	}
+	return &t, nil
 }
 
 func (h *Handler) GetLoans(c *gin.Context) {
-	stateStr := c.Query("state")
-	if stateStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "state query parameter is required"})
+	actor, ok := currentActor(c)
+	if !ok {
 		return
 	}
 
-	state := domain.LoanState(stateStr)
-	if !isValidState(state) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid state"})
+	var req SearchLoansRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	loans, err := h.loanUseCase.GetLoansByState(c.Request.Context(), state)
+	query, err := req.toQuery()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.loanUseCase.SearchLoans(c.Request.Context(), actor, query)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, LoanPageResponse{
+		Loans:      toLoanResponses(page.Loans),
+		NextCursor: page.NextCursor,
+	})
+}
+
+func (h *Handler) GetMyLoans(c *gin.Context) {
+	uidStr, ok := userID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	uid, err := uuid.Parse(uidStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	loans, err := h.loanUseCase.GetBorrowerLoans(c.Request.Context(), uid)
+	if errors.Is(err, domain.ErrBorrowerNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, loans)
+	c.JSON(http.StatusOK, toLoanResponses(loans))
+}
+
+// errorStatus is the status for a failed request. A retry while the
+// original request is still running is a conflict, as is a loan that stayed
+// busy with, or was changed by, another request. Reusing an idempotency key
+// for a different request is unprocessable. Acting for someone the user may
+// not act for, or disbursing a loan one approved, is forbidden. Invalid
+// values or searches, unknown borrowers or investors and changes the loan's
+// state does not allow are bad requests, a missing loan is not found, and anything else
+// is an internal error.
+func errorStatus(err error) int {
+	var transitionErr *domain.StateTransitionError
+	switch {
+	case errors.Is(err, domain.ErrForbidden),
+		errors.Is(err, domain.ErrApproverCannotDisburse):
+		return http.StatusForbidden
+	case errors.Is(err, idempotency.ErrInProgress),
+		errors.Is(err, redis.ErrLockNotObtained),
+		errors.Is(err, domain.ErrFundingChanged),
+		errors.Is(err, domain.ErrLoanVersionConflict):
+		return http.StatusConflict
+	case errors.Is(err, idempotency.ErrKeyReused):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, domain.ErrLoanNotFound):
+		return http.StatusNotFound
+	case errors.As(err, &transitionErr),
+		errors.Is(err, domain.ErrInvalidInput),
+		errors.Is(err, domain.ErrInvalidLoanQuery),
+		errors.Is(err, domain.ErrCurrencyMismatch),
+		errors.Is(err, domain.ErrOverInvestment),
+		errors.Is(err, domain.ErrLoanNotOpen),
+		errors.Is(err, domain.ErrLoanNotDisbursed),
+		errors.Is(err, domain.ErrRepaymentExceedsOutstanding),
+		errors.Is(err, domain.ErrBorrowerNotFound),
+		errors.Is(err, domain.ErrInvestorNotFound):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// maxUploadRequestSize bounds the body of a request carrying a file upload,
+// leaving room for the form fields around the file
+const maxUploadRequestSize = usecase.MaxUploadSize + 1<<20
+
+// bindStatus is the status for a request body that could not be bound. A
+// body over its size limit is too large, anything else is a bad request.
+func bindStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
 }
 
 func parseTime(timeStr string) (time.Time, error) {
 	return time.Parse(time.RFC3339, timeStr)
 }
 
-func isValidState(state domain.LoanState) bool {
-	return state == domain.StateProposed ||
-		state == domain.StateApproved ||
-		state == domain.StateInvested ||
-		state == domain.StateDisbursed
+func validateAmount(m domain.Money) error {
+	if !m.IsPositive() {
+		return errors.New("must be greater than 0")
+	}
+	if !m.Currency.IsValid() {
+		return errors.New("unsupported currency")
+	}
+	return nil
 }
@@ -0,0 +1,91 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mungkiice/-loan-service/internal/domain"
+	"github.com/mungkiice/-loan-service/internal/ledger"
+	"github.com/mungkiice/-loan-service/internal/usecase"
+)
+
+type LedgerHandler struct {
+	ledgerUseCase *usecase.LedgerUseCase
+}
+
+func NewLedgerHandler(ledgerUseCase *usecase.LedgerUseCase) *LedgerHandler {
+	return &LedgerHandler{ledgerUseCase: ledgerUseCase}
+}
+
+type AccountBalanceResponse struct {
+	AccountType string       `json:"account_type"`
+	OwnerID     *string      `json:"owner_id,omitempty"`
+	Debits      domain.Money `json:"debits"`
+	Credits     domain.Money `json:"credits"`
+	Balance     domain.Money `json:"balance"`
+}
+
+type TrialBalanceResponse struct {
+	Accounts     []AccountBalanceResponse `json:"accounts"`
+	TotalDebits  []domain.Money           `json:"total_debits"`
+	TotalCredits []domain.Money           `json:"total_credits"`
+	Balanced     bool                     `json:"balanced"`
+}
+
+func (h *LedgerHandler) GetTrialBalance(c *gin.Context) {
+	tb, err := h.ledgerUseCase.GetTrialBalance(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	res := TrialBalanceResponse{
+		Accounts:     make([]AccountBalanceResponse, 0, len(tb.Balances)),
+		TotalDebits:  make([]domain.Money, 0, len(tb.TotalDebits)),
+		TotalCredits: make([]domain.Money, 0, len(tb.TotalCredits)),
+		Balanced:     tb.IsBalanced(),
+	}
+	for _, b := range tb.Balances {
+		account := AccountBalanceResponse{
+			AccountType: string(b.Account.Type),
+			Debits:      b.Debits,
+			Credits:     b.Credits,
+			Balance:     b.Net(),
+		}
+		if b.Account.OwnerID != uuid.Nil {
+			owner := b.Account.OwnerID.String()
+			account.OwnerID = &owner
+		}
+		res.Accounts = append(res.Accounts, account)
+	}
+	for _, total := range tb.TotalDebits {
+		res.TotalDebits = append(res.TotalDebits, total)
+	}
+	for _, total := range tb.TotalCredits {
+		res.TotalCredits = append(res.TotalCredits, total)
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *LedgerHandler) CheckEscrow(c *gin.Context) {
+	loanID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid loan id"})
+		return
+	}
+
+	err = h.ledgerUseCase.CheckEscrow(c.Request.Context(), loanID)
+	if errors.Is(err, ledger.ErrEscrowMismatch) {
+		c.JSON(http.StatusConflict, gin.H{"consistent": false, "error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"consistent": true})
+}
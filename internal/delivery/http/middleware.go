@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mungkiice/-loan-service/internal/domain"
 	"github.com/mungkiice/-loan-service/internal/usecase"
 )
 
@@ -42,15 +43,18 @@ func AuthMiddleware(authUseCase *usecase.AuthUseCase) gin.HandlerFunc {
 	}
 }
 
-func RequireUserType(required string) gin.HandlerFunc {
+// RequireUserType lets through users of any of the allowed types
+func RequireUserType(allowed ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		utype, ok := c.Get("utype")
-		if !ok || utype != required {
-			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
-			c.Abort()
-			return
+		utype, _ := c.Get("utype")
+		for _, t := range allowed {
+			if utype == t {
+				c.Next()
+				return
+			}
 		}
-		c.Next()
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		c.Abort()
 	}
 }
 
@@ -77,3 +81,17 @@ func userID(c *gin.Context) (string, bool) {
 	s, ok := id.(string)
 	return s, ok
 }
+
+// currentActor returns the signed-in user, responding 401 when there is none
+func currentActor(c *gin.Context) (domain.Actor, bool) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		return domain.Actor{}, false
+	}
+
+	return domain.Actor{
+		UserID:   uid,
+		UserType: domain.UserType(c.GetString("utype")),
+		Role:     domain.EmployeeRole(c.GetString("role")),
+	}, true
+}
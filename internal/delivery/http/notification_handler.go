@@ -0,0 +1,137 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mungkiice/-loan-service/internal/notification"
+	"github.com/mungkiice/-loan-service/internal/usecase"
+)
+
+type NotificationHandler struct {
+	notificationUseCase *usecase.NotificationUseCase
+}
+
+func NewNotificationHandler(notificationUseCase *usecase.NotificationUseCase) *NotificationHandler {
+	return &NotificationHandler{notificationUseCase: notificationUseCase}
+}
+
+type NotificationResponse struct {
+	ID        string     `json:"id"`
+	Kind      string     `json:"kind"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	CreatedAt time.Time  `json:"created_at"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+}
+
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	notifications, err := h.notificationUseCase.GetNotifications(c.Request.Context(), uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	res := make([]NotificationResponse, 0, len(notifications))
+	for _, n := range notifications {
+		res = append(res, NotificationResponse{
+			ID:        n.ID.String(),
+			Kind:      string(n.Kind),
+			Title:     n.Title,
+			Body:      n.Body,
+			CreatedAt: n.CreatedAt,
+			ReadAt:    n.ReadAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	notificationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid notification id"})
+		return
+	}
+
+	uid, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	err = h.notificationUseCase.MarkRead(c.Request.Context(), uid, notificationID)
+	if errors.Is(err, notification.ErrNotificationNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "notification marked as read"})
+}
+
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	prefs, err := h.notificationUseCase.GetPreferences(c.Request.Context(), uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+// UpdatePreferences takes a map of channel to enabled, e.g.
+// {"sms": true, "email": false}; channels left out keep their setting
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	var req notification.Preferences
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	uid, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	prefs, err := h.notificationUseCase.UpdatePreferences(c.Request.Context(), uid, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+// currentUserID returns the signed-in user's ID, writing a 401 when there is
+// none
+func currentUserID(c *gin.Context) (uuid.UUID, bool) {
+	uidStr, ok := userID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return uuid.Nil, false
+	}
+
+	uid, err := uuid.Parse(uidStr)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return uuid.Nil, false
+	}
+
+	return uid, true
+}
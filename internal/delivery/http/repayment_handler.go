@@ -0,0 +1,176 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mungkiice/-loan-service/internal/domain"
+	"github.com/mungkiice/-loan-service/internal/usecase"
+)
+
+type RepaymentHandler struct {
+	repaymentUseCase *usecase.RepaymentUseCase
+}
+
+func NewRepaymentHandler(repaymentUseCase *usecase.RepaymentUseCase) *RepaymentHandler {
+	return &RepaymentHandler{repaymentUseCase: repaymentUseCase}
+}
+
+type InstallmentResponse struct {
+	ID            string       `json:"id"`
+	Sequence      int          `json:"sequence"`
+	DueDate       time.Time    `json:"due_date"`
+	PrincipalDue  domain.Money `json:"principal_due"`
+	InterestDue   domain.Money `json:"interest_due"`
+	PrincipalPaid domain.Money `json:"principal_paid"`
+	InterestPaid  domain.Money `json:"interest_paid"`
+	Status        string       `json:"status"`
+	PaidAt        *time.Time   `json:"paid_at,omitempty"`
+}
+
+type RepaymentResponse struct {
+	ID        string       `json:"id"`
+	LoanID    string       `json:"loan_id"`
+	Amount    domain.Money `json:"amount"`
+	PaidAt    time.Time    `json:"paid_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+func toInstallmentResponse(inst *domain.Installment) InstallmentResponse {
+	return InstallmentResponse{
+		ID:            inst.ID.String(),
+		Sequence:      inst.Sequence,
+		DueDate:       inst.DueDate,
+		PrincipalDue:  inst.PrincipalDue,
+		InterestDue:   inst.InterestDue,
+		PrincipalPaid: inst.PrincipalPaid,
+		InterestPaid:  inst.InterestPaid,
+		Status:        string(inst.Status),
+		PaidAt:        inst.PaidAt,
+	}
+}
+
+func (h *RepaymentHandler) GetSchedule(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	loanID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid loan id"})
+		return
+	}
+
+	installments, err := h.repaymentUseCase.GetSchedule(c.Request.Context(), actor, loanID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	res := make([]InstallmentResponse, 0, len(installments))
+	for _, inst := range installments {
+		res = append(res, toInstallmentResponse(inst))
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+type RecordRepaymentRequest struct {
+	Amount         domain.Money `json:"amount"`
+	PaidAt         string       `json:"paid_at" binding:"required"`
+	IdempotencyKey string       `json:"idempotency_key" binding:"required"`
+}
+
+func (h *RepaymentHandler) RecordRepayment(c *gin.Context) {
+	loanID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid loan id"})
+		return
+	}
+
+	var req RecordRepaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := validateAmount(req.Amount); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid amount: " + err.Error()})
+		return
+	}
+
+	paidAt, err := parseTime(req.PaidAt)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad paid_at"})
+		return
+	}
+
+	repayment, err := h.repaymentUseCase.RecordRepayment(c.Request.Context(), usecase.RecordRepaymentRequest{
+		LoanID:         loanID,
+		Amount:         req.Amount,
+		PaidAt:         paidAt,
+		IdempotencyKey: req.IdempotencyKey,
+	})
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, RepaymentResponse{
+		ID:        repayment.ID.String(),
+		LoanID:    repayment.LoanID.String(),
+		Amount:    repayment.Amount,
+		PaidAt:    repayment.PaidAt,
+		CreatedAt: repayment.CreatedAt,
+	})
+}
+
+type InvestorReturnResponse struct {
+	LoanID            string         `json:"loan_id"`
+	LoanState         string         `json:"loan_state"`
+	ROI               domain.Percent `json:"roi"`
+	Invested          domain.Money   `json:"invested"`
+	ExpectedInterest  domain.Money   `json:"expected_interest"`
+	ExpectedTotal     domain.Money   `json:"expected_total"`
+	ReceivedPrincipal domain.Money   `json:"received_principal"`
+	ReceivedInterest  domain.Money   `json:"received_interest"`
+	ReceivedTotal     domain.Money   `json:"received_total"`
+}
+
+func (h *RepaymentHandler) GetInvestorReturns(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	returns, err := h.repaymentUseCase.GetInvestorReturns(c.Request.Context(), actor)
+	if errors.Is(err, domain.ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	res := make([]InvestorReturnResponse, 0, len(returns))
+	for _, r := range returns {
+		res = append(res, InvestorReturnResponse{
+			LoanID:            r.LoanID.String(),
+			LoanState:         string(r.LoanState),
+			ROI:               r.ROI,
+			Invested:          r.Invested,
+			ExpectedInterest:  r.ExpectedInterest,
+			ExpectedTotal:     r.ExpectedTotal(),
+			ReceivedPrincipal: r.ReceivedPrincipal,
+			ReceivedInterest:  r.ReceivedInterest,
+			ReceivedTotal:     r.ReceivedTotal(),
+		})
+	}
+
+	c.JSON(http.StatusOK, res)
+}
@@ -1,36 +1,67 @@
 package http
 
 import (
+	"expvar"
+
 	"github.com/gin-gonic/gin"
 	"github.com/mungkiice/-loan-service/internal/usecase"
 )
 
-func SetupRouter(handler *Handler, authHandler *AuthHandler, authUseCase *usecase.AuthUseCase) *gin.Engine {
+func SetupRouter(handler *Handler, authHandler *AuthHandler, repaymentHandler *RepaymentHandler, ledgerHandler *LedgerHandler, borrowerHandler *BorrowerHandler, notificationHandler *NotificationHandler, webhookHandler *WebhookHandler, authUseCase *usecase.AuthUseCase) *gin.Engine {
 	router := gin.Default()
 
 	api := router.Group("/api/v1")
 	{
 		api.POST("/auth/signin", authHandler.SignIn)
-
-		api.POST("/loans", handler.CreateLoan)
-		api.GET("/loans", handler.GetLoans)
-		api.GET("/loans/:id", handler.GetLoan)
 	}
 
 	protected := api.Group("")
 	protected.Use(AuthMiddleware(authUseCase))
 	{
+		// which loans a user sees is narrowed further by the use case
+		protected.POST("/loans", RequireUserType("borrower", "employee"), handler.CreateLoan)
+		protected.GET("/loans", RequireUserType("borrower", "employee", "investor"), handler.GetLoans)
+		protected.GET("/loans/:id", RequireUserType("borrower", "employee", "investor"), handler.GetLoan)
+		protected.GET("/loans/:id/schedule", RequireUserType("borrower", "employee", "investor"), repaymentHandler.GetSchedule)
+		protected.GET("/notifications", notificationHandler.GetNotifications)
+		protected.POST("/notifications/:id/read", notificationHandler.MarkRead)
+		protected.GET("/notifications/preferences", notificationHandler.GetPreferences)
+		protected.PUT("/notifications/preferences", notificationHandler.UpdatePreferences)
+
 		employeeRoutes := protected.Group("")
 		employeeRoutes.Use(RequireUserType("employee"))
 		{
 			employeeRoutes.POST("/loans/:id/approve", RequireRole("field_validator"), handler.ApproveLoan)
+			employeeRoutes.POST("/loans/:id/reject", RequireRole("field_validator"), handler.RejectLoan)
+			employeeRoutes.POST("/loans/:id/cancel", RequireRole("admin"), handler.CancelLoan)
 			employeeRoutes.POST("/loans/:id/disburse", RequireRole("field_officer"), handler.DisburseLoan)
+			employeeRoutes.POST("/loans/:id/invest/on-behalf", RequireRole("admin"), handler.Invest)
+			employeeRoutes.POST("/loans/:id/repayments", RequireRole("field_officer"), repaymentHandler.RecordRepayment)
+			employeeRoutes.POST("/borrowers", RequireRole("field_validator"), borrowerHandler.RegisterBorrower)
+			employeeRoutes.GET("/borrowers/:id", borrowerHandler.GetBorrower)
+			employeeRoutes.POST("/borrowers/:id/kyc", RequireRole("field_validator"), borrowerHandler.ReviewKYC)
+			employeeRoutes.GET("/ledger/trial-balance", RequireRole("admin"), ledgerHandler.GetTrialBalance)
+			employeeRoutes.GET("/loans/:id/escrow-check", RequireRole("admin"), ledgerHandler.CheckEscrow)
+			employeeRoutes.POST("/webhooks", RequireRole("admin"), webhookHandler.CreateWebhook)
+			employeeRoutes.GET("/webhooks", RequireRole("admin"), webhookHandler.GetWebhooks)
+			employeeRoutes.GET("/webhooks/:id", RequireRole("admin"), webhookHandler.GetWebhook)
+			employeeRoutes.PUT("/webhooks/:id", RequireRole("admin"), webhookHandler.UpdateWebhook)
+			employeeRoutes.DELETE("/webhooks/:id", RequireRole("admin"), webhookHandler.DeleteWebhook)
+			employeeRoutes.GET("/webhooks/:id/attempts", RequireRole("admin"), webhookHandler.GetAttempts)
+			employeeRoutes.GET("/metrics", RequireRole("admin"), gin.WrapH(expvar.Handler()))
 		}
 
 		investorRoutes := protected.Group("")
 		investorRoutes.Use(RequireUserType("investor"))
 		{
 			investorRoutes.POST("/loans/:id/invest", handler.Invest)
+			investorRoutes.GET("/investors/me/returns", repaymentHandler.GetInvestorReturns)
+		}
+
+		borrowerRoutes := protected.Group("")
+		borrowerRoutes.Use(RequireUserType("borrower"))
+		{
+			borrowerRoutes.GET("/borrowers/me/loans", handler.GetMyLoans)
 		}
 	}
 
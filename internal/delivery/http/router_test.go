@@ -0,0 +1,157 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mungkiice/-loan-service/internal/domain"
+	"github.com/mungkiice/-loan-service/internal/infrastructure/jwt"
+	"github.com/mungkiice/-loan-service/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// knownUsers accepts every user ID; its other methods are not implemented
+type knownUsers struct {
+	domain.UserRepository
+}
+
+func (knownUsers) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	return &domain.User{ID: id}, nil
+}
+
+// newTestRouter routes to handlers without use cases, so only requests the
+// middleware rejects may be sent to it
+func newTestRouter(t *testing.T) (*gin.Engine, func(userType, role string) string) {
+	gin.SetMode(gin.TestMode)
+	jwtService := jwt.NewJWTService("test-secret", time.Hour)
+	authUseCase := usecase.NewAuthUseCase(knownUsers{}, nil, nil, nil, jwtService)
+	router := SetupRouter(&Handler{}, &AuthHandler{}, &RepaymentHandler{}, &LedgerHandler{}, &BorrowerHandler{}, &NotificationHandler{}, &WebhookHandler{}, authUseCase)
+
+	token := func(userType, role string) string {
+		token, err := jwtService.GenerateToken(uuid.New(), "user@example.com", userType, role)
+		require.NoError(t, err)
+		return token
+	}
+	return router, token
+}
+
+func TestLoanRoutes_RequireAuthentication(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	for _, route := range []struct{ method, path string }{
+		{http.MethodPost, "/api/v1/loans"},
+		{http.MethodGet, "/api/v1/loans"},
+		{http.MethodGet, "/api/v1/loans/" + uuid.NewString()},
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(route.method, route.path, strings.NewReader("{}")))
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code, "%s %s", route.method, route.path)
+	}
+}
+
+func TestCreateLoan_ForbiddenForInvestors(t *testing.T) {
+	router, token := newTestRouter(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/loans", strings.NewReader("{}"))
+	req.Header.Set("Authorization", BearerPrefix+token("investor", ""))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestCreateLoan_RejectsTenorBeyondMaximum(t *testing.T) {
+	router, token := newTestRouter(t)
+
+	body := `{"principal_amount": 5000000, "rate": 10, "roi": 8, "tenor_months": 400}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/loans", strings.NewReader(body))
+	req.Header.Set("Authorization", BearerPrefix+token("borrower", ""))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "TenorMonths")
+}
+
+func TestApproveLoan_RejectsOversizedUpload(t *testing.T) {
+	router, token := newTestRouter(t)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	require.NoError(t, form.WriteField("approval_date", "2024-01-01T00:00:00Z"))
+	require.NoError(t, form.WriteField("idempotency_key", "approve-key"))
+	file, err := form.CreateFormFile("picture_proof", "proof.jpg")
+	require.NoError(t, err)
+	_, err = file.Write(make([]byte, maxUploadRequestSize))
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/loans/"+uuid.NewString()+"/approve", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", BearerPrefix+token("employee", "field_validator"))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestRequireUserType_AllowsAnyListedType(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for utype, want := range map[string]int{
+		"borrower": http.StatusOK,
+		"employee": http.StatusOK,
+		"investor": http.StatusForbidden,
+		"":         http.StatusForbidden,
+	} {
+		router := gin.New()
+		router.GET("/", func(c *gin.Context) {
+			if utype != "" {
+				c.Set("utype", utype)
+			}
+		}, RequireUserType("borrower", "employee"), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, want, w.Code, "user type %q", utype)
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"state transition", fmt.Errorf("failed to approve: %w", &domain.StateTransitionError{From: domain.StateDisbursed, To: domain.StateApproved}), http.StatusBadRequest},
+		{"currency mismatch", fmt.Errorf("%w: investment in USD, loan in IDR", domain.ErrCurrencyMismatch), http.StatusBadRequest},
+		{"invalid input", fmt.Errorf("%w: unknown repayment type", domain.ErrInvalidInput), http.StatusBadRequest},
+		{"invalid loan query", fmt.Errorf("%w: invalid sort %q", domain.ErrInvalidLoanQuery, "name"), http.StatusBadRequest},
+		{"loan not found", fmt.Errorf("failed to get loan: %w", domain.ErrLoanNotFound), http.StatusNotFound},
+		{"loan lookup failure", fmt.Errorf("failed to get loan: %w", errors.New("connection refused")), http.StatusInternalServerError},
+		{"version conflict", domain.ErrLoanVersionConflict, http.StatusConflict},
+		{"forbidden", domain.ErrForbidden, http.StatusForbidden},
+		{"repository failure", fmt.Errorf("failed to create loan: %w", errors.New("connection refused")), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, errorStatus(tt.err))
+		})
+	}
+}
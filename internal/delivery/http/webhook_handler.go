@@ -0,0 +1,236 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mungkiice/-loan-service/internal/domain"
+	"github.com/mungkiice/-loan-service/internal/usecase"
+	"github.com/mungkiice/-loan-service/internal/webhook"
+)
+
+type WebhookHandler struct {
+	webhookUseCase *usecase.WebhookUseCase
+}
+
+func NewWebhookHandler(webhookUseCase *usecase.WebhookUseCase) *WebhookHandler {
+	return &WebhookHandler{webhookUseCase: webhookUseCase}
+}
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events" binding:"required"`
+}
+
+type UpdateWebhookRequest struct {
+	URL    *string  `json:"url"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active"`
+}
+
+// WebhookResponse never includes the signing secret; it is only returned once,
+// when the subscription is created
+type WebhookResponse struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type WebhookAttemptResponse struct {
+	ID          string    `json:"id"`
+	DeliveryID  string    `json:"delivery_id"`
+	Event       string    `json:"event"`
+	Attempt     int       `json:"attempt"`
+	StatusCode  *int      `json:"status_code,omitempty"`
+	Error       *string   `json:"error,omitempty"`
+	DurationMS  int64     `json:"duration_ms"`
+	Succeeded   bool      `json:"succeeded"`
+	AttemptedAt time.Time `json:"attempted_at"`
+}
+
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, err := h.webhookUseCase.CreateSubscription(c.Request.Context(), usecase.CreateWebhookRequest{
+		URL:    req.URL,
+		Secret: req.Secret,
+		Events: toEventTypes(req.Events),
+	})
+	if errors.Is(err, webhook.ErrInvalidSubscription) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	res := toWebhookResponse(subscription)
+	res.Secret = subscription.Secret
+	c.JSON(http.StatusCreated, res)
+}
+
+func (h *WebhookHandler) GetWebhooks(c *gin.Context) {
+	subscriptions, err := h.webhookUseCase.GetSubscriptions(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	res := make([]WebhookResponse, 0, len(subscriptions))
+	for _, s := range subscriptions {
+		res = append(res, toWebhookResponse(s))
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	subscription, err := h.webhookUseCase.GetSubscription(c.Request.Context(), id)
+	if errors.Is(err, webhook.ErrSubscriptionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toWebhookResponse(subscription))
+}
+
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	update := usecase.UpdateWebhookRequest{ID: id, URL: req.URL, Active: req.Active}
+	if req.Events != nil {
+		update.Events = toEventTypes(req.Events)
+	}
+
+	subscription, err := h.webhookUseCase.UpdateSubscription(c.Request.Context(), update)
+	if errors.Is(err, webhook.ErrSubscriptionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, webhook.ErrInvalidSubscription) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toWebhookResponse(subscription))
+}
+
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	err := h.webhookUseCase.DeleteSubscription(c.Request.Context(), id)
+	if errors.Is(err, webhook.ErrSubscriptionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "webhook deleted"})
+}
+
+func (h *WebhookHandler) GetAttempts(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	attempts, err := h.webhookUseCase.GetAttempts(c.Request.Context(), id)
+	if errors.Is(err, webhook.ErrSubscriptionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	res := make([]WebhookAttemptResponse, 0, len(attempts))
+	for _, a := range attempts {
+		res = append(res, WebhookAttemptResponse{
+			ID:          a.ID.String(),
+			DeliveryID:  a.DeliveryID.String(),
+			Event:       string(a.Event),
+			Attempt:     a.Attempt,
+			StatusCode:  a.StatusCode,
+			Error:       a.Error,
+			DurationMS:  a.Duration.Milliseconds(),
+			Succeeded:   a.Succeeded(),
+			AttemptedAt: a.AttemptedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func webhookID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook id"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func toEventTypes(events []string) []domain.EventType {
+	types := make([]domain.EventType, 0, len(events))
+	for _, event := range events {
+		types = append(types, domain.EventType(event))
+	}
+	return types
+}
+
+func toWebhookResponse(s *webhook.Subscription) WebhookResponse {
+	events := make([]string, 0, len(s.Events))
+	for _, event := range s.Events {
+		events = append(events, string(event))
+	}
+	return WebhookResponse{
+		ID:        s.ID.String(),
+		URL:       s.URL,
+		Events:    events,
+		Active:    s.Active,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AgreementParty is one investor's position in a loan agreement
+type AgreementParty struct {
+	Investment       *Investment
+	Investor         *Investor
+	Share            Percent
+	ExpectedInterest Money
+}
+
+func (p *AgreementParty) ExpectedReturn() Money {
+	return p.Investment.Amount.Add(p.ExpectedInterest)
+}
+
+// Agreement holds everything printed on a loan agreement letter. The
+// borrower's copy lists every party; an investor's copy has Recipient set and
+// only carries that investor's terms.
+type Agreement struct {
+	Loan         *Loan
+	Borrower     *Borrower
+	Parties      []*AgreementParty
+	Recipient    *AgreementParty
+	Installments []*Installment
+	IssuedAt     time.Time
+}
+
+// For returns the investor's copy of the agreement addressed to party
+func (a *Agreement) For(party *AgreementParty) *Agreement {
+	letter := *a
+	letter.Recipient = party
+	return &letter
+}
+
+// NewAgreement builds the agreement for a fully invested loan. The repayment
+// schedule is projected from issuedAt since the loan is not disbursed yet.
+// investors is keyed by investor ID.
+func NewAgreement(loan *Loan, borrower *Borrower, investments []*Investment, investors map[uuid.UUID]*Investor, issuedAt time.Time) (*Agreement, error) {
+	if len(investments) == 0 {
+		return nil, errors.New("agreement needs at least one investment")
+	}
+
+	installments, err := GenerateSchedule(loan, issuedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	expected, err := loan.ExpectedInvestorInterest(investments, installments)
+	if err != nil {
+		return nil, err
+	}
+
+	parties := make([]*AgreementParty, len(investments))
+	for i, inv := range investments {
+		investor, ok := investors[inv.InvestorID]
+		if !ok {
+			return nil, fmt.Errorf("no investor found for investment %s", inv.ID)
+		}
+		parties[i] = &AgreementParty{
+			Investment:       inv,
+			Investor:         investor,
+			Share:            loan.FundingShare(inv.Amount),
+			ExpectedInterest: expected[inv.ID],
+		}
+	}
+
+	return &Agreement{
+		Loan:         loan,
+		Borrower:     borrower,
+		Parties:      parties,
+		Installments: installments,
+		IssuedAt:     issuedAt,
+	}, nil
+}
+
+// FundingShare returns amount as a percentage of the loan principal
+func (l *Loan) FundingShare(amount Money) Percent {
+	if l.PrincipalAmount.Amount == 0 {
+		return 0
+	}
+	share := new(big.Rat).Mul(big.NewRat(amount.Amount, l.PrincipalAmount.Amount), big.NewRat(10000, 1))
+	return Percent(roundRat(share))
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFundingShare(t *testing.T) {
+	loan := NewLoan(uuid.New(), NewMoney(3000000, CurrencyIDR), 1200, 800)
+
+	assert.Equal(t, Percent(10000), loan.FundingShare(NewMoney(3000000, CurrencyIDR)))
+	assert.Equal(t, Percent(3333), loan.FundingShare(NewMoney(1000000, CurrencyIDR)))
+	assert.Equal(t, Percent(6667), loan.FundingShare(NewMoney(2000000, CurrencyIDR)))
+}
+
+func TestNewAgreement(t *testing.T) {
+	loan := NewLoan(uuid.New(), NewMoney(1000000, CurrencyIDR), 1200, 800)
+	loan.TenorMonths = 3
+	borrower := &Borrower{ID: loan.BorrowerID, Name: "Borrower"}
+	first := &Investor{ID: uuid.New(), Name: "First"}
+	second := &Investor{ID: uuid.New(), Name: "Second"}
+	investments := []*Investment{
+		{ID: uuid.New(), LoanID: loan.ID, InvestorID: first.ID, Amount: NewMoney(750000, CurrencyIDR)},
+		{ID: uuid.New(), LoanID: loan.ID, InvestorID: second.ID, Amount: NewMoney(250000, CurrencyIDR)},
+	}
+	investors := map[uuid.UUID]*Investor{first.ID: first, second.ID: second}
+
+	agreement, err := NewAgreement(loan, borrower, investments, investors, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	require.NoError(t, err)
+	assert.Len(t, agreement.Installments, 3)
+	require.Len(t, agreement.Parties, 2)
+	assert.Equal(t, first, agreement.Parties[0].Investor)
+	assert.Equal(t, Percent(7500), agreement.Parties[0].Share)
+	assert.Equal(t, Percent(2500), agreement.Parties[1].Share)
+	assert.True(t, agreement.Parties[0].ExpectedInterest.IsPositive())
+	assert.InDelta(t, 3*agreement.Parties[1].ExpectedInterest.Amount, agreement.Parties[0].ExpectedInterest.Amount, 3)
+	assert.Equal(t, investments[0].Amount.Add(agreement.Parties[0].ExpectedInterest), agreement.Parties[0].ExpectedReturn())
+}
+
+func TestNewAgreement_RequiresEveryInvestor(t *testing.T) {
+	loan := NewLoan(uuid.New(), NewMoney(1000000, CurrencyIDR), 1200, 800)
+	investments := []*Investment{
+		{ID: uuid.New(), LoanID: loan.ID, InvestorID: uuid.New(), Amount: NewMoney(1000000, CurrencyIDR)},
+	}
+
+	_, err := NewAgreement(loan, &Borrower{ID: loan.BorrowerID}, investments, map[uuid.UUID]*Investor{}, time.Now())
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type KYCStatus string
+
+const (
+	KYCPending  KYCStatus = "pending"
+	KYCVerified KYCStatus = "verified"
+	KYCRejected KYCStatus = "rejected"
+)
+
+func (s KYCStatus) IsValid() bool {
+	return s == KYCPending || s == KYCVerified || s == KYCRejected
+}
+
+var ErrBorrowerNotFound = errors.New("borrower not found")
+
+// Borrower is the person a loan is made to, together with their KYC
+// profile. UserID is nil until the borrower has a login.
+type Borrower struct {
+	ID            uuid.UUID
+	UserID        *uuid.UUID
+	Name          string
+	NationalID    *string
+	Phone         *string
+	Address       *string
+	DateOfBirth   *time.Time
+	KYCStatus     KYCStatus
+	KYCReviewedBy *uuid.UUID
+	KYCReviewedAt *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func NewBorrower(userID *uuid.UUID, name string, nationalID, phone, address *string, dateOfBirth *time.Time) *Borrower {
+	now := time.Now()
+	return &Borrower{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Name:        name,
+		NationalID:  nationalID,
+		Phone:       phone,
+		Address:     address,
+		DateOfBirth: dateOfBirth,
+		KYCStatus:   KYCPending,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// ReviewKYC records an employee's verification decision. A borrower without
+// a national ID cannot be verified.
+func (b *Borrower) ReviewKYC(status KYCStatus, employeeID uuid.UUID) error {
+	if status != KYCVerified && status != KYCRejected {
+		return fmt.Errorf("%w: kyc review status %q", ErrInvalidInput, status)
+	}
+	if status == KYCVerified && (b.NationalID == nil || *b.NationalID == "") {
+		return fmt.Errorf("%w: national id is required for kyc verification", ErrInvalidInput)
+	}
+
+	now := time.Now()
+	b.KYCStatus = status
+	b.KYCReviewedBy = &employeeID
+	b.KYCReviewedAt = &now
+	b.UpdatedAt = now
+	return nil
+}
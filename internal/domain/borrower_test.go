@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBorrowerReviewKYC(t *testing.T) {
+	nationalID := "3171000000000001"
+	employeeID := uuid.New()
+
+	b := NewBorrower(nil, "Borrower", &nationalID, nil, nil, nil)
+	assert.Equal(t, KYCPending, b.KYCStatus)
+
+	require.NoError(t, b.ReviewKYC(KYCVerified, employeeID))
+	assert.Equal(t, KYCVerified, b.KYCStatus)
+	assert.Equal(t, employeeID, *b.KYCReviewedBy)
+	assert.NotNil(t, b.KYCReviewedAt)
+
+	assert.Error(t, b.ReviewKYC(KYCPending, employeeID))
+}
+
+func TestBorrowerReviewKYC_RequiresNationalIDToVerify(t *testing.T) {
+	b := NewBorrower(nil, "Borrower", nil, nil, nil, nil)
+
+	assert.Error(t, b.ReviewKYC(KYCVerified, uuid.New()))
+	require.NoError(t, b.ReviewKYC(KYCRejected, uuid.New()))
+	assert.Equal(t, KYCRejected, b.KYCStatus)
+}
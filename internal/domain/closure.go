@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ClosureReason explains why a loan was rejected or cancelled
+type ClosureReason string
+
+const (
+	ReasonIncompleteDocuments ClosureReason = "incomplete_documents"
+	ReasonFailedVerification  ClosureReason = "failed_verification"
+	ReasonCreditRisk          ClosureReason = "credit_risk"
+	ReasonDuplicate           ClosureReason = "duplicate"
+	ReasonBorrowerWithdrew    ClosureReason = "borrower_withdrew"
+	ReasonFundingShortfall    ClosureReason = "funding_shortfall"
+	ReasonFraudSuspected      ClosureReason = "fraud_suspected"
+	ReasonOther               ClosureReason = "other"
+)
+
+// closureReasons lists the reason codes accepted for each terminal state
+var closureReasons = map[LoanState][]ClosureReason{
+	StateRejected: {
+		ReasonIncompleteDocuments,
+		ReasonFailedVerification,
+		ReasonCreditRisk,
+		ReasonDuplicate,
+		ReasonOther,
+	},
+	StateCancelled: {
+		ReasonBorrowerWithdrew,
+		ReasonFundingShortfall,
+		ReasonFraudSuspected,
+		ReasonDuplicate,
+		ReasonOther,
+	},
+}
+
+// LoanClosure records who moved a loan into a terminal state and why
+type LoanClosure struct {
+	LoanID     uuid.UUID
+	State      LoanState
+	Reason     ClosureReason
+	Note       string
+	EmployeeID uuid.UUID
+	ClosedAt   time.Time
+	CreatedAt  time.Time
+}
+
+// ValidateClosureReason checks that reason is allowed for the terminal state.
+// ReasonOther needs a note.
+func ValidateClosureReason(state LoanState, reason ClosureReason, note string) error {
+	reasons, ok := closureReasons[state]
+	if !ok {
+		return fmt.Errorf("%w: %s is not a closing state", ErrInvalidInput, state)
+	}
+
+	for _, r := range reasons {
+		if r != reason {
+			continue
+		}
+		if reason == ReasonOther && note == "" {
+			return fmt.Errorf("%w: a note is required when the reason is %q", ErrInvalidInput, ReasonOther)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("%w: reason %q is not valid for a %s loan", ErrInvalidInput, reason, state)
+}
+
+// Close moves the loan into a terminal state and returns the closure record
+func (l *Loan) Close(state LoanState, reason ClosureReason, note string, employeeID uuid.UUID) (*LoanClosure, error) {
+	if err := ValidateClosureReason(state, reason, note); err != nil {
+		return nil, err
+	}
+
+	if err := l.TransitionTo(state); err != nil {
+		return nil, err
+	}
+
+	return &LoanClosure{
+		LoanID:     l.ID,
+		State:      state,
+		Reason:     reason,
+		Note:       note,
+		EmployeeID: employeeID,
+		ClosedAt:   l.UpdatedAt,
+		CreatedAt:  l.UpdatedAt,
+	}, nil
+}
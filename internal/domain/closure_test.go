@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateClosureReason(t *testing.T) {
+	tests := []struct {
+		name      string
+		state     LoanState
+		reason    ClosureReason
+		note      string
+		shouldErr bool
+	}{
+		{"rejected for credit risk", StateRejected, ReasonCreditRisk, "", false},
+		{"cancelled for funding shortfall", StateCancelled, ReasonFundingShortfall, "", false},
+		{"rejection reason on cancellation", StateCancelled, ReasonCreditRisk, "", true},
+		{"cancellation reason on rejection", StateRejected, ReasonBorrowerWithdrew, "", true},
+		{"other with note", StateRejected, ReasonOther, "collateral missing", false},
+		{"other without note", StateCancelled, ReasonOther, "", true},
+		{"unknown reason", StateRejected, ClosureReason("bad_vibes"), "", true},
+		{"not a closing state", StateApproved, ReasonOther, "note", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateClosureReason(tt.state, tt.reason, tt.note)
+			if tt.shouldErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLoanClose(t *testing.T) {
+	loan := NewLoan(uuid.New(), idr(1000000), 500, 300)
+	employeeID := uuid.New()
+
+	closure, err := loan.Close(StateRejected, ReasonFailedVerification, "", employeeID)
+
+	require.NoError(t, err)
+	assert.Equal(t, StateRejected, loan.State)
+	assert.Equal(t, loan.ID, closure.LoanID)
+	assert.Equal(t, employeeID, closure.EmployeeID)
+
+	_, err = loan.Close(StateCancelled, ReasonDuplicate, "", employeeID)
+	assert.Error(t, err)
+}
@@ -1,11 +1,14 @@
 package domain
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+var ErrEmployeeNotFound = errors.New("employee not found")
+
 type Employee struct {
 	ID        uuid.UUID
 	UserID    uuid.UUID
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventType names a loan domain event. It doubles as the outbox topic the
+// event is published on.
+type EventType string
+
+const (
+	EventLoanApproved  EventType = "loan.approved"
+	EventLoanRejected  EventType = "loan.rejected"
+	EventLoanInvested  EventType = "loan.invested"
+	EventLoanDisbursed EventType = "loan.disbursed"
+	EventLoanCancelled EventType = "loan.cancelled"
+	EventLoanExpired   EventType = "loan.expired"
+)
+
+// EventTypes lists every loan event type
+var EventTypes = []EventType{
+	EventLoanApproved,
+	EventLoanRejected,
+	EventLoanInvested,
+	EventLoanDisbursed,
+	EventLoanCancelled,
+	EventLoanExpired,
+}
+
+// LoanEvent records that a loan reached a new state
+type LoanEvent struct {
+	Type       EventType `json:"type"`
+	LoanID     uuid.UUID `json:"loan_id"`
+	State      LoanState `json:"state"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+func NewLoanEvent(eventType EventType, loan *Loan, at time.Time) LoanEvent {
+	return LoanEvent{
+		Type:       eventType,
+		LoanID:     loan.ID,
+		State:      loan.State,
+		OccurredAt: at,
+	}
+}
+
+const (
+	EventInvestmentCreated  EventType = "investment.created"
+	EventInvestmentRefunded EventType = "investment.refunded"
+)
+
+// InvestmentEventTypes lists every investment event type
+var InvestmentEventTypes = []EventType{
+	EventInvestmentCreated,
+	EventInvestmentRefunded,
+}
+
+// InvestmentEvent records that an investment was made or refunded
+type InvestmentEvent struct {
+	Type         EventType  `json:"type"`
+	InvestmentID uuid.UUID  `json:"investment_id"`
+	LoanID       uuid.UUID  `json:"loan_id"`
+	InvestorID   uuid.UUID  `json:"investor_id"`
+	PlacedBy     *uuid.UUID `json:"placed_by,omitempty"`
+	Amount       Money      `json:"amount"`
+	OccurredAt   time.Time  `json:"occurred_at"`
+}
+
+func NewInvestmentEvent(eventType EventType, investment *Investment, at time.Time) InvestmentEvent {
+	return InvestmentEvent{
+		Type:         eventType,
+		InvestmentID: investment.ID,
+		LoanID:       investment.LoanID,
+		InvestorID:   investment.InvestorID,
+		PlacedBy:     investment.PlacedBy,
+		Amount:       investment.Amount,
+		OccurredAt:   at,
+	}
+}
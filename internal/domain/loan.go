@@ -15,18 +15,53 @@ const (
 	StateApproved  LoanState = "approved"
 	StateInvested  LoanState = "invested"
 	StateDisbursed LoanState = "disbursed"
+	StateRejected  LoanState = "rejected"
+	StateCancelled LoanState = "cancelled"
+	StateExpired   LoanState = "expired"
+)
+
+var (
+	// ErrOverInvestment is returned when an investment would take a loan's
+	// total investment past its principal
+	ErrOverInvestment = errors.New("total investment would exceed principal")
+	// ErrFundingChanged is returned when a loan's total investment moved
+	// while an investment in it was being made
+	ErrFundingChanged = errors.New("loan funding changed while investing, please try again")
+	// ErrLoanVersionConflict is returned when a loan is updated from a copy
+	// that another request has changed since it was read
+	ErrLoanVersionConflict = errors.New("loan was changed by another request")
+	// ErrApproverCannotDisburse is returned when the employee who approved a
+	// loan tries to disburse it too
+	ErrApproverCannotDisburse = errors.New("loan must be disbursed by someone other than its approver")
+
+	// ErrInvalidInput is returned when a request carries a value the domain
+	// rejects, such as a non-positive amount or an unknown option
+	ErrInvalidInput = errors.New("invalid input")
+	// ErrLoanNotOpen is returned when a loan cannot take investments because
+	// it is not approved or its funding deadline has passed
+	ErrLoanNotOpen = errors.New("loan is not open for investment")
+
+	ErrLoanNotFound         = errors.New("loan not found")
+	ErrApprovalNotFound     = errors.New("approval not found")
+	ErrDisbursementNotFound = errors.New("disbursement not found")
 )
 
 type Loan struct {
 	ID                 uuid.UUID
 	BorrowerID         uuid.UUID
-	PrincipalAmount    float64
-	Rate               float64
-	ROI                float64
+	PrincipalAmount    Money
+	Rate               Percent
+	ROI                Percent
+	TenorMonths        int
+	RepaymentType      RepaymentType
 	AgreementLetterURL *string
+	FundingDeadline    *time.Time
 	State              LoanState
-	CreatedAt          time.Time
-	UpdatedAt          time.Time
+	// Version counts the updates to the loan; an update only applies to the
+	// version it was read at
+	Version   int
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
 
 type LoanApproval struct {
@@ -37,12 +72,19 @@ type LoanApproval struct {
 	CreatedAt    time.Time
 }
 
+// Investment is an investor's commitment to a loan. VoidedAt is set when the
+// loan is cancelled and the amount refunded.
 type Investment struct {
-	ID         uuid.UUID
-	LoanID     uuid.UUID
-	InvestorID uuid.UUID
-	Amount     float64
-	CreatedAt  time.Time
+	ID                 uuid.UUID
+	LoanID             uuid.UUID
+	InvestorID         uuid.UUID
+	Amount             Money
+	AgreementLetterURL *string
+	// PlacedBy is the admin employee who invested on the investor's behalf;
+	// it is nil when the investor invested themselves
+	PlacedBy  *uuid.UUID
+	CreatedAt time.Time
+	VoidedAt  *time.Time
 }
 
 type Disbursement struct {
@@ -53,6 +95,31 @@ type Disbursement struct {
 	CreatedAt          time.Time
 }
 
+// LoanDetails is a loan with its approval, active investments and
+// disbursement; Approval and Disbursement are nil until the loan gets there
+type LoanDetails struct {
+	Loan         *Loan         `json:"loan"`
+	Approval     *LoanApproval `json:"approval,omitempty"`
+	Investments  []*Investment `json:"investments"`
+	Disbursement *Disbursement `json:"disbursement,omitempty"`
+}
+
+// FundedAmount is the sum of the loan's active investments
+func (d *LoanDetails) FundedAmount() Money {
+	funded := NewMoney(0, d.Loan.PrincipalAmount.Currency)
+	for _, inv := range d.Investments {
+		if inv.VoidedAt == nil {
+			funded = funded.Add(inv.Amount)
+		}
+	}
+	return funded
+}
+
+// RemainingAmount is how much of the principal is still open for investment
+func (d *LoanDetails) RemainingAmount() Money {
+	return d.Loan.PrincipalAmount.Sub(d.FundedAmount())
+}
+
 type StateTransitionError struct {
 	From  LoanState
 	To    LoanState
@@ -60,10 +127,13 @@ type StateTransitionError struct {
 }
 
 var validTransitions = map[LoanState][]LoanState{
-	StateProposed:  {StateApproved},
-	StateApproved:  {StateInvested},
+	StateProposed:  {StateApproved, StateRejected, StateCancelled},
+	StateApproved:  {StateInvested, StateCancelled, StateExpired},
 	StateInvested:  {StateDisbursed},
 	StateDisbursed: {},
+	StateRejected:  {},
+	StateCancelled: {},
+	StateExpired:   {},
 }
 
 func (e *StateTransitionError) Error() string {
@@ -103,7 +173,7 @@ func (l *Loan) TransitionTo(newState LoanState) error {
 	return nil
 }
 
-func NewLoan(borrowerID uuid.UUID, principalAmount, rate, roi float64) *Loan {
+func NewLoan(borrowerID uuid.UUID, principalAmount Money, rate, roi Percent) *Loan {
 	now := time.Now()
 	return &Loan{
 		ID:              uuid.New(),
@@ -111,25 +181,44 @@ func NewLoan(borrowerID uuid.UUID, principalAmount, rate, roi float64) *Loan {
 		PrincipalAmount: principalAmount,
 		Rate:            rate,
 		ROI:             roi,
+		TenorMonths:     DefaultTenorMonths,
+		RepaymentType:   DefaultRepaymentType,
 		State:           StateProposed,
+		Version:         1,
 		CreatedAt:       now,
 		UpdatedAt:       now,
 	}
 }
 
-func (l *Loan) ValidateInvestmentAmount(amount float64, currentTotal float64) error {
-	if amount <= 0 {
-		return errors.New("investment amount must be positive")
+func (l *Loan) ValidateInvestmentAmount(amount Money, currentTotal Money) error {
+	if !amount.IsPositive() {
+		return fmt.Errorf("%w: investment amount must be positive", ErrInvalidInput)
+	}
+
+	if !amount.SameCurrency(l.PrincipalAmount) {
+		return fmt.Errorf("%w: investment in %s, loan in %s", ErrCurrencyMismatch, amount.Currency, l.PrincipalAmount.Currency)
 	}
 
-	if currentTotal+amount > l.PrincipalAmount {
-		return fmt.Errorf("total investment (%.2f) would exceed principal (%.2f)", currentTotal+amount, l.PrincipalAmount)
+	newTotal := currentTotal.Add(amount)
+	if newTotal.Cmp(l.PrincipalAmount) > 0 {
+		return fmt.Errorf("%w: %s of %s", ErrOverInvestment, newTotal, l.PrincipalAmount)
 	}
 
 	return nil
 }
 
-func (l *Loan) IsFullyInvested(totalInvested float64) bool {
-	const epsilon = 0.01
-	return totalInvested >= l.PrincipalAmount-epsilon
+func (l *Loan) IsFullyInvested(totalInvested Money) bool {
+	return totalInvested.Cmp(l.PrincipalAmount) >= 0
+}
+
+// OpenForFunding sets the investment deadline to window after approval
+func (l *Loan) OpenForFunding(approvedAt time.Time, window time.Duration) {
+	deadline := approvedAt.Add(window)
+	l.FundingDeadline = &deadline
+}
+
+// IsFundingExpired reports whether the loan is still collecting investments
+// after its funding deadline
+func (l *Loan) IsFundingExpired(now time.Time) bool {
+	return l.State == StateApproved && l.FundingDeadline != nil && now.After(*l.FundingDeadline)
 }
@@ -0,0 +1,205 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultLoanPageSize = 20
+	MaxLoanPageSize     = 100
+)
+
+var (
+	// ErrInvalidLoanQuery is returned for a loan search that cannot be run
+	ErrInvalidLoanQuery = errors.New("invalid loan query")
+	ErrInvalidCursor    = errors.New("invalid cursor")
+)
+
+// LoanSort is the field loans are ordered by. Ties are broken by loan ID.
+type LoanSort string
+
+const (
+	SortByCreatedAt     LoanSort = "created_at"
+	SortByUpdatedAt     LoanSort = "updated_at"
+	SortByPrincipal     LoanSort = "principal_amount"
+	SortByRate          LoanSort = "rate"
+	SortByFundedPercent LoanSort = "funded_percent"
+)
+
+func (s LoanSort) IsValid() bool {
+	switch s {
+	case SortByCreatedAt, SortByUpdatedAt, SortByPrincipal, SortByRate, SortByFundedPercent:
+		return true
+	}
+	return false
+}
+
+type SortOrder string
+
+const (
+	SortAsc  SortOrder = "asc"
+	SortDesc SortOrder = "desc"
+)
+
+func (s LoanState) IsValid() bool {
+	switch s {
+	case StateProposed, StateApproved, StateInvested, StateDisbursed, StateRejected, StateCancelled, StateExpired:
+		return true
+	}
+	return false
+}
+
+// LoanQuery selects a page of loans. Nil and empty filters match every loan;
+// ranges include both bounds.
+type LoanQuery struct {
+	States     []LoanState
+	BorrowerID *uuid.UUID
+	// OpenOrInvestedBy limits the loans to those open for investment and
+	// those the investor holds an active investment in
+	OpenOrInvestedBy *uuid.UUID
+
+	// Currency restricts loans to one currency; the principal bounds are
+	// compared in it
+	Currency     Currency
+	MinPrincipal *Money
+	MaxPrincipal *Money
+
+	MinRate *Percent
+	MaxRate *Percent
+
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	UpdatedFrom *time.Time
+	UpdatedTo   *time.Time
+
+	// MinFundedPercent and MaxFundedPercent bound the share of the principal
+	// invested so far, from 0 to 100
+	MinFundedPercent *Percent
+	MaxFundedPercent *Percent
+
+	Sort  LoanSort
+	Order SortOrder
+	Limit int
+	// After continues from the last loan of a previous page
+	After *LoanCursor
+}
+
+// Normalize fills in the default sort and page size and checks the query
+func (q *LoanQuery) Normalize() error {
+	if q.Sort == "" {
+		q.Sort = SortByCreatedAt
+	}
+	if q.Order == "" {
+		q.Order = SortDesc
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultLoanPageSize
+	}
+
+	if !q.Sort.IsValid() {
+		return fmt.Errorf("%w: invalid sort %q", ErrInvalidLoanQuery, q.Sort)
+	}
+	if q.Order != SortAsc && q.Order != SortDesc {
+		return fmt.Errorf("%w: invalid sort order %q", ErrInvalidLoanQuery, q.Order)
+	}
+	if q.Limit < 1 || q.Limit > MaxLoanPageSize {
+		return fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidLoanQuery, MaxLoanPageSize)
+	}
+	for _, state := range q.States {
+		if !state.IsValid() {
+			return fmt.Errorf("%w: invalid state %q", ErrInvalidLoanQuery, state)
+		}
+	}
+	if q.Currency != "" && !q.Currency.IsValid() {
+		return fmt.Errorf("%w: invalid currency %q", ErrInvalidLoanQuery, q.Currency)
+	}
+	if q.After != nil && (q.After.Sort != q.Sort || q.After.Order != q.Order) {
+		return fmt.Errorf("%w: %w: it was issued for a different sort", ErrInvalidLoanQuery, ErrInvalidCursor)
+	}
+
+	if q.MinPrincipal != nil && q.MaxPrincipal != nil && q.MinPrincipal.Amount > q.MaxPrincipal.Amount {
+		return fmt.Errorf("%w: min_principal must not exceed max_principal", ErrInvalidLoanQuery)
+	}
+	if q.MinRate != nil && q.MaxRate != nil && *q.MinRate > *q.MaxRate {
+		return fmt.Errorf("%w: min_rate must not exceed max_rate", ErrInvalidLoanQuery)
+	}
+	if q.CreatedFrom != nil && q.CreatedTo != nil && q.CreatedFrom.After(*q.CreatedTo) {
+		return fmt.Errorf("%w: created_from must not be after created_to", ErrInvalidLoanQuery)
+	}
+	if q.UpdatedFrom != nil && q.UpdatedTo != nil && q.UpdatedFrom.After(*q.UpdatedTo) {
+		return fmt.Errorf("%w: updated_from must not be after updated_to", ErrInvalidLoanQuery)
+	}
+	if q.MinFundedPercent != nil && q.MaxFundedPercent != nil && *q.MinFundedPercent > *q.MaxFundedPercent {
+		return fmt.Errorf("%w: min_funded_percent must not exceed max_funded_percent", ErrInvalidLoanQuery)
+	}
+
+	return nil
+}
+
+// LoanCursor marks the last loan of a page by its sort value and ID. It is
+// only valid for the sort it was issued for.
+type LoanCursor struct {
+	Sort  LoanSort  `json:"s"`
+	Order SortOrder `json:"o"`
+	// Value is the loan's sort value as the database renders it
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+// Encode renders the cursor as an opaque URL-safe token
+func (c LoanCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// sortValueLayout is how the database renders a timestamp sort value
+const sortValueLayout = "2006-01-02 15:04:05.999999999"
+
+var numericSortValue = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// DecodeLoanCursor parses a cursor token. Cursors come from clients, so the
+// sort value is checked against its sort's type here rather than left for the
+// database to reject.
+func DecodeLoanCursor(token string) (*LoanCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, invalidCursor("not a cursor")
+	}
+
+	var cursor LoanCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == uuid.Nil {
+		return nil, invalidCursor("not a cursor")
+	}
+
+	switch cursor.Sort {
+	case SortByCreatedAt, SortByUpdatedAt:
+		if _, err := time.Parse(sortValueLayout, cursor.Value); err != nil {
+			return nil, invalidCursor("sort value is not a timestamp")
+		}
+	case SortByPrincipal, SortByRate, SortByFundedPercent:
+		if !numericSortValue.MatchString(cursor.Value) {
+			return nil, invalidCursor("sort value is not a number")
+		}
+	default:
+		return nil, invalidCursor(fmt.Sprintf("unknown sort %q", cursor.Sort))
+	}
+
+	return &cursor, nil
+}
+
+func invalidCursor(reason string) error {
+	return fmt.Errorf("%w: %w: %s", ErrInvalidInput, ErrInvalidCursor, reason)
+}
+
+// LoanPage is one page of a loan search. NextCursor is empty on the last page.
+type LoanPage struct {
+	Loans      []*Loan
+	NextCursor string
+}
@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoanQueryNormalize_AppliesDefaults(t *testing.T) {
+	var q LoanQuery
+
+	require.NoError(t, q.Normalize())
+
+	assert.Equal(t, SortByCreatedAt, q.Sort)
+	assert.Equal(t, SortDesc, q.Order)
+	assert.Equal(t, DefaultLoanPageSize, q.Limit)
+}
+
+func TestLoanQueryNormalize(t *testing.T) {
+	low, high := Percent(1000), Percent(2000)
+	small, large := idr(100000), idr(500000)
+	earlier := time.Now().Add(-time.Hour)
+	later := time.Now()
+
+	tests := []struct {
+		name      string
+		query     LoanQuery
+		shouldErr bool
+	}{
+		{"valid filters", LoanQuery{States: []LoanState{StateApproved, StateInvested}, MinRate: &low, MaxRate: &high, MinPrincipal: &small, MaxPrincipal: &large}, false},
+		{"unknown state", LoanQuery{States: []LoanState{"pending"}}, true},
+		{"unknown sort", LoanQuery{Sort: "borrower_id"}, true},
+		{"unknown order", LoanQuery{Order: "up"}, true},
+		{"limit too large", LoanQuery{Limit: MaxLoanPageSize + 1}, true},
+		{"negative limit", LoanQuery{Limit: -1}, true},
+		{"invalid currency", LoanQuery{Currency: "rupiah"}, true},
+		{"rate range reversed", LoanQuery{MinRate: &high, MaxRate: &low}, true},
+		{"principal range reversed", LoanQuery{MinPrincipal: &large, MaxPrincipal: &small}, true},
+		{"created range reversed", LoanQuery{CreatedFrom: &later, CreatedTo: &earlier}, true},
+		{"funded range reversed", LoanQuery{MinFundedPercent: &high, MaxFundedPercent: &low}, true},
+		{"cursor for the same sort", LoanQuery{Sort: SortByRate, Order: SortAsc, After: &LoanCursor{Sort: SortByRate, Order: SortAsc, ID: uuid.New()}}, false},
+		{"cursor for another sort", LoanQuery{Sort: SortByRate, After: &LoanCursor{Sort: SortByCreatedAt, Order: SortDesc, ID: uuid.New()}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.query.Normalize()
+			if tt.shouldErr {
+				assert.ErrorIs(t, err, ErrInvalidLoanQuery)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLoanCursor_RoundTrip(t *testing.T) {
+	cursor := LoanCursor{Sort: SortByPrincipal, Order: SortAsc, Value: "1000000.00", ID: uuid.New()}
+
+	decoded, err := DecodeLoanCursor(cursor.Encode())
+
+	require.NoError(t, err)
+	assert.Equal(t, cursor, *decoded)
+}
+
+func TestDecodeLoanCursor_RejectsGarbage(t *testing.T) {
+	for _, token := range []string{"not a cursor", "bm90IGpzb24", "e30"} {
+		_, err := DecodeLoanCursor(token)
+		assert.ErrorIs(t, err, ErrInvalidCursor, token)
+		assert.ErrorIs(t, err, ErrInvalidInput, token)
+	}
+}
+
+func TestDecodeLoanCursor_ChecksSortValue(t *testing.T) {
+	tests := []struct {
+		sort  LoanSort
+		value string
+		valid bool
+	}{
+		{SortByCreatedAt, "2024-01-15 10:20:30.123456", true},
+		{SortByUpdatedAt, "2024-01-15 10:20:30", true},
+		{SortByCreatedAt, "yesterday", false},
+		{SortByCreatedAt, "2024-13-45 10:20:30", false},
+		{SortByPrincipal, "1000000.00", true},
+		{SortByFundedPercent, "33.3333333333333333", true},
+		{SortByRate, "12", true},
+		{SortByRate, "1e9", false},
+		{SortByPrincipal, "1000'); DROP TABLE loans;--", false},
+		{SortByPrincipal, "", false},
+		{"balance", "10", false},
+	}
+
+	for _, tt := range tests {
+		token := LoanCursor{Sort: tt.sort, Order: SortDesc, Value: tt.value, ID: uuid.New()}.Encode()
+
+		_, err := DecodeLoanCursor(token)
+
+		if tt.valid {
+			assert.NoError(t, err, "%s %q", tt.sort, tt.value)
+		} else {
+			assert.ErrorIs(t, err, ErrInvalidInput, "%s %q", tt.sort, tt.value)
+		}
+	}
+}
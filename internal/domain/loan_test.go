@@ -2,6 +2,7 @@ package domain
 
 import (
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -9,13 +10,13 @@ import (
 
 func TestNewLoan(t *testing.T) {
 	borrowerID := uuid.New()
-	loan := NewLoan(borrowerID, 10000.0, 5.0, 3.0)
+	loan := NewLoan(borrowerID, idr(1000000), 500, 300)
 
 	assert.NotNil(t, loan)
 	assert.Equal(t, borrowerID, loan.BorrowerID)
-	assert.Equal(t, 10000.0, loan.PrincipalAmount)
-	assert.Equal(t, 5.0, loan.Rate)
-	assert.Equal(t, 3.0, loan.ROI)
+	assert.Equal(t, idr(1000000), loan.PrincipalAmount)
+	assert.Equal(t, Percent(500), loan.Rate)
+	assert.Equal(t, Percent(300), loan.ROI)
 	assert.Equal(t, StateProposed, loan.State)
 	assert.NotEqual(t, uuid.Nil, loan.ID)
 }
@@ -33,6 +34,16 @@ func TestCanTransitionTo(t *testing.T) {
 		{"proposed to invested", StateProposed, StateInvested, true},
 		{"approved to proposed", StateApproved, StateProposed, true},
 		{"disbursed to any", StateDisbursed, StateApproved, true},
+		{"proposed to rejected", StateProposed, StateRejected, false},
+		{"proposed to cancelled", StateProposed, StateCancelled, false},
+		{"approved to cancelled", StateApproved, StateCancelled, false},
+		{"approved to rejected", StateApproved, StateRejected, true},
+		{"invested to cancelled", StateInvested, StateCancelled, true},
+		{"rejected to approved", StateRejected, StateApproved, true},
+		{"cancelled to approved", StateCancelled, StateApproved, true},
+		{"approved to expired", StateApproved, StateExpired, false},
+		{"proposed to expired", StateProposed, StateExpired, true},
+		{"expired to invested", StateExpired, StateInvested, true},
 	}
 
 	for _, tt := range tests {
@@ -65,19 +76,21 @@ func TestTransitionTo(t *testing.T) {
 }
 
 func TestValidateInvestmentAmount(t *testing.T) {
-	loan := &Loan{PrincipalAmount: 10000.0}
+	loan := &Loan{PrincipalAmount: idr(1000000)}
 
 	tests := []struct {
-		name        string
-		amount      float64
-		currentTotal float64
-		shouldErr   bool
+		name         string
+		amount       Money
+		currentTotal Money
+		shouldErr    bool
 	}{
-		{"valid amount", 5000.0, 0.0, false},
-		{"exact principal", 10000.0, 0.0, false},
-		{"exceeds principal", 5001.0, 5000.0, true},
-		{"zero amount", 0.0, 0.0, true},
-		{"negative amount", -100.0, 0.0, true},
+		{"valid amount", idr(500000), idr(0), false},
+		{"exact principal", idr(1000000), idr(0), false},
+		{"exceeds principal", idr(500100), idr(500000), true},
+		{"exceeds principal by one cent", idr(500001), idr(500000), true},
+		{"zero amount", idr(0), idr(0), true},
+		{"negative amount", idr(-10000), idr(0), true},
+		{"different currency", NewMoney(500000, CurrencyUSD), idr(0), true},
 	}
 
 	for _, tt := range tests {
@@ -93,10 +106,46 @@ func TestValidateInvestmentAmount(t *testing.T) {
 }
 
 func TestIsFullyInvested(t *testing.T) {
-	loan := &Loan{PrincipalAmount: 10000.0}
+	loan := &Loan{PrincipalAmount: idr(1000000)}
 
-	assert.True(t, loan.IsFullyInvested(10000.0))
-	assert.True(t, loan.IsFullyInvested(10000.01))
-	assert.False(t, loan.IsFullyInvested(9999.0))
-	assert.False(t, loan.IsFullyInvested(5000.0))
+	assert.True(t, loan.IsFullyInvested(idr(1000000)))
+	assert.True(t, loan.IsFullyInvested(idr(1000001)))
+	assert.False(t, loan.IsFullyInvested(idr(999999)))
+	assert.False(t, loan.IsFullyInvested(idr(500000)))
+}
+
+func idr(minorUnits int64) Money {
+	return NewMoney(minorUnits, CurrencyIDR)
+}
+
+func TestIsFundingExpired(t *testing.T) {
+	approvedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	loan := NewLoan(uuid.New(), idr(1000000), 500, 300)
+	loan.State = StateApproved
+
+	assert.False(t, loan.IsFundingExpired(approvedAt.AddDate(1, 0, 0)), "no deadline set")
+
+	loan.OpenForFunding(approvedAt, 30*24*time.Hour)
+	assert.Equal(t, approvedAt.AddDate(0, 0, 30), *loan.FundingDeadline)
+	assert.False(t, loan.IsFundingExpired(approvedAt.AddDate(0, 0, 30)))
+	assert.True(t, loan.IsFundingExpired(approvedAt.AddDate(0, 0, 31)))
+
+	loan.State = StateInvested
+	assert.False(t, loan.IsFundingExpired(approvedAt.AddDate(0, 0, 31)), "only approved loans expire")
+}
+
+func TestLoanDetails_FundedAndRemainingAmount(t *testing.T) {
+	voidedAt := time.Now()
+	details := &LoanDetails{
+		Loan: NewLoan(uuid.New(), idr(1000000), 500, 300),
+		Investments: []*Investment{
+			{Amount: idr(250000)},
+			{Amount: idr(125050)},
+			{Amount: idr(300000), VoidedAt: &voidedAt},
+		},
+	}
+
+	assert.Equal(t, idr(375050), details.FundedAmount())
+	assert.Equal(t, idr(624950), details.RemainingAmount())
+	assert.Equal(t, Percent(3751), details.Loan.FundingShare(details.FundedAmount()))
 }
@@ -0,0 +1,353 @@
+package domain
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency code
+type Currency string
+
+const (
+	CurrencyIDR Currency = "IDR"
+	CurrencyUSD Currency = "USD"
+)
+
+// DefaultCurrency is applied to amounts submitted without a currency
+const DefaultCurrency = CurrencyIDR
+
+// IsValid reports whether c looks like an ISO 4217 code
+func (c Currency) IsValid() bool {
+	if len(c) != 3 {
+		return false
+	}
+	for _, r := range c {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// moneyScale is the number of decimal places kept for every amount and
+// percentage, matching the DECIMAL(_, 2) columns in the schema
+const moneyScale = 2
+
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// Money is an exact amount held in minor units (cents) together with its
+// currency. It never passes through float64.
+type Money struct {
+	Amount   int64
+	Currency Currency
+}
+
+func NewMoney(minorUnits int64, currency Currency) Money {
+	return Money{Amount: minorUnits, Currency: currency}
+}
+
+// ParseMoney parses a decimal string such as "10000.50" into Money
+func ParseMoney(s string, currency Currency) (Money, error) {
+	amount, err := parseFixed(s, moneyScale)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount %q: %w", s, err)
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+func (m Money) String() string {
+	return formatFixed(m.Amount, moneyScale)
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+func (m Money) SameCurrency(other Money) bool {
+	return m.Currency == other.Currency
+}
+
+// Add returns m + other. A zero-value currency adopts the other operand's.
+func (m Money) Add(other Money) Money {
+	return Money{Amount: m.Amount + other.Amount, Currency: m.currencyWith(other)}
+}
+
+// Sub returns m - other. A zero-value currency adopts the other operand's.
+func (m Money) Sub(other Money) Money {
+	return Money{Amount: m.Amount - other.Amount, Currency: m.currencyWith(other)}
+}
+
+// Cmp returns -1, 0 or +1 depending on whether m is less than, equal to or
+// greater than other
+func (m Money) Cmp(other Money) int {
+	switch {
+	case m.Amount < other.Amount:
+		return -1
+	case m.Amount > other.Amount:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Allocate splits m in proportion to weights using the largest remainder
+// method, so the parts always add back up to m exactly. Ties go to the
+// earlier weight.
+func (m Money) Allocate(weights []int64) ([]Money, error) {
+	if m.Amount < 0 {
+		return nil, errors.New("cannot allocate a negative amount")
+	}
+
+	total := new(big.Int)
+	for _, w := range weights {
+		if w < 0 {
+			return nil, errors.New("allocation weights must not be negative")
+		}
+		total.Add(total, big.NewInt(w))
+	}
+	if total.Sign() == 0 {
+		return nil, errors.New("allocation weights must not all be zero")
+	}
+
+	parts := make([]Money, len(weights))
+	remainders := make([]*big.Int, len(weights))
+	allocated := int64(0)
+	for i, w := range weights {
+		share, rem := new(big.Int).QuoRem(new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(w)), total, new(big.Int))
+		parts[i] = Money{Amount: share.Int64(), Currency: m.Currency}
+		remainders[i] = rem
+		allocated += share.Int64()
+	}
+
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]].Cmp(remainders[order[b]]) > 0
+	})
+	for i := 0; allocated < m.Amount; i++ {
+		parts[order[i%len(order)]].Amount++
+		allocated++
+	}
+
+	return parts, nil
+}
+
+func (m Money) currencyWith(other Money) Currency {
+	if m.Currency == "" {
+		return other.Currency
+	}
+	return m.Currency
+}
+
+// Scan implements sql.Scanner for NUMERIC columns. Only the amount is read;
+// repositories set the currency from its own column.
+func (m *Money) Scan(src any) error {
+	amount, err := scanFixed(src, moneyScale)
+	if err != nil {
+		return err
+	}
+	m.Amount = amount
+	return nil
+}
+
+// Value implements driver.Valuer, writing the amount as an exact decimal
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+type moneyJSON struct {
+	Amount   json.Number `json:"amount"`
+	Currency Currency    `json:"currency"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: json.Number(m.String()), Currency: m.Currency})
+}
+
+// UnmarshalJSON accepts {"amount": "10.00", "currency": "IDR"}, a bare JSON
+// number or a decimal string. Bare amounts use DefaultCurrency.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		var raw moneyJSON
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		parsed, err := ParseMoney(raw.Amount.String(), raw.Currency)
+		if err != nil {
+			return err
+		}
+		if parsed.Currency == "" {
+			parsed.Currency = DefaultCurrency
+		}
+		*m = parsed
+		return nil
+	}
+
+	text, err := jsonDecimalText(data)
+	if err != nil {
+		return err
+	}
+	parsed, err := ParseMoney(text, DefaultCurrency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Percent is a percentage with two decimal places, held in hundredths of a
+// percent (5.25% is 525) to match DECIMAL(5, 2)
+type Percent int64
+
+func ParsePercent(s string) (Percent, error) {
+	v, err := parseFixed(s, moneyScale)
+	if err != nil {
+		return 0, fmt.Errorf("invalid percentage %q: %w", s, err)
+	}
+	return Percent(v), nil
+}
+
+func (p Percent) String() string {
+	return formatFixed(int64(p), moneyScale)
+}
+
+func (p *Percent) Scan(src any) error {
+	v, err := scanFixed(src, moneyScale)
+	if err != nil {
+		return err
+	}
+	*p = Percent(v)
+	return nil
+}
+
+func (p Percent) Value() (driver.Value, error) {
+	return p.String(), nil
+}
+
+func (p Percent) MarshalJSON() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *Percent) UnmarshalJSON(data []byte) error {
+	text, err := jsonDecimalText(data)
+	if err != nil {
+		return err
+	}
+	parsed, err := ParsePercent(text)
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
+// jsonDecimalText returns the literal text of a JSON number or string
+// without going through float64
+func jsonDecimalText(data []byte) (string, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return "", err
+		}
+		return s, nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return "", err
+	}
+	return n.String(), nil
+}
+
+// parseFixed converts a decimal string into an integer scaled by 10^scale.
+// Inputs with more than scale fractional digits are rejected rather than
+// rounded.
+func parseFixed(s string, scale int) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, errors.New("empty value")
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" {
+		whole = "0"
+	}
+	frac = strings.TrimRight(frac, "0")
+	if len(frac) > scale {
+		return 0, fmt.Errorf("more than %d decimal places", scale)
+	}
+	frac += strings.Repeat("0", scale-len(frac))
+
+	for _, part := range []string{whole, frac} {
+		for _, r := range part {
+			if r < '0' || r > '9' {
+				return 0, errors.New("not a decimal number")
+			}
+		}
+	}
+
+	v, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if negative {
+		v = -v
+	}
+	return v, nil
+}
+
+func formatFixed(v int64, scale int) string {
+	sign := ""
+	u := uint64(v)
+	if v < 0 {
+		sign = "-"
+		u = uint64(-v)
+	}
+
+	digits := strconv.FormatUint(u, 10)
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	cut := len(digits) - scale
+	return sign + digits[:cut] + "." + digits[cut:]
+}
+
+func scanFixed(src any, scale int) (int64, error) {
+	switch v := src.(type) {
+	case string:
+		return parseFixed(v, scale)
+	case []byte:
+		return parseFixed(string(v), scale)
+	case int64:
+		return parseFixed(strconv.FormatInt(v, 10), scale)
+	case nil:
+		return 0, errors.New("cannot scan NULL into a decimal")
+	default:
+		return 0, fmt.Errorf("cannot scan %T into a decimal", src)
+	}
+}
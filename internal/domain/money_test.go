@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		input     string
+		want      int64
+		shouldErr bool
+	}{
+		{"10000", 1000000, false},
+		{"10000.5", 1000050, false},
+		{"10000.50", 1000050, false},
+		{"0.01", 1, false},
+		{".25", 25, false},
+		{"-3.10", -310, false},
+		{"1.230", 123, false},
+		{"1.234", 0, true},
+		{"abc", 0, true},
+		{"", 0, true},
+		{"1e3", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			m, err := ParseMoney(tt.input, CurrencyIDR)
+			if tt.shouldErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, m.Amount)
+			assert.Equal(t, CurrencyIDR, m.Currency)
+		})
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	assert.Equal(t, "10000.00", idr(1000000).String())
+	assert.Equal(t, "0.05", idr(5).String())
+	assert.Equal(t, "-1.50", idr(-150).String())
+	assert.Equal(t, "0.00", idr(0).String())
+}
+
+func TestMoneyArithmeticIsExact(t *testing.T) {
+	total := idr(0)
+	for i := 0; i < 10; i++ {
+		total = total.Add(idr(10))
+	}
+
+	assert.Equal(t, idr(100), total)
+	assert.Equal(t, 0, total.Cmp(idr(100)))
+	assert.Equal(t, idr(90), total.Sub(idr(10)))
+}
+
+func TestMoneyJSON(t *testing.T) {
+	var fromNumber Money
+	require.NoError(t, json.Unmarshal([]byte(`10000.10`), &fromNumber))
+	assert.Equal(t, idr(1000010), fromNumber)
+
+	var fromString Money
+	require.NoError(t, json.Unmarshal([]byte(`"0.30"`), &fromString))
+	assert.Equal(t, idr(30), fromString)
+
+	var fromObject Money
+	require.NoError(t, json.Unmarshal([]byte(`{"amount": "12.5", "currency": "USD"}`), &fromObject))
+	assert.Equal(t, NewMoney(1250, CurrencyUSD), fromObject)
+
+	var tooPrecise Money
+	assert.Error(t, json.Unmarshal([]byte(`0.001`), &tooPrecise))
+
+	out, err := json.Marshal(idr(1000010))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount": 10000.10, "currency": "IDR"}`, string(out))
+}
+
+func TestPercentJSON(t *testing.T) {
+	var p Percent
+	require.NoError(t, json.Unmarshal([]byte(`5.25`), &p))
+	assert.Equal(t, Percent(525), p)
+
+	out, err := json.Marshal(p)
+	require.NoError(t, err)
+	assert.Equal(t, "5.25", string(out))
+}
+
+func TestMoneyScan(t *testing.T) {
+	var m Money
+	require.NoError(t, m.Scan("10000.50"))
+	assert.Equal(t, int64(1000050), m.Amount)
+
+	require.NoError(t, m.Scan([]byte("7")))
+	assert.Equal(t, int64(700), m.Amount)
+
+	assert.Error(t, m.Scan(nil))
+}
+
+func TestMoneyAllocate(t *testing.T) {
+	parts, err := idr(100).Allocate([]int64{1, 1, 1})
+	require.NoError(t, err)
+	assert.Equal(t, []Money{idr(34), idr(33), idr(33)}, parts)
+
+	parts, err = idr(1000).Allocate([]int64{750000, 250000})
+	require.NoError(t, err)
+	assert.Equal(t, []Money{idr(750), idr(250)}, parts)
+
+	parts, err = idr(5).Allocate([]int64{0, 3})
+	require.NoError(t, err)
+	assert.Equal(t, []Money{idr(0), idr(5)}, parts)
+
+	_, err = idr(5).Allocate([]int64{0, 0})
+	assert.Error(t, err)
+
+	_, err = idr(-5).Allocate([]int64{1})
+	assert.Error(t, err)
+}
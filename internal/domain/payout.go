@@ -0,0 +1,150 @@
+package domain
+
+import (
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InvestorPayout is an investor's share of a single borrower repayment
+type InvestorPayout struct {
+	ID           uuid.UUID
+	LoanID       uuid.UUID
+	InvestmentID uuid.UUID
+	InvestorID   uuid.UUID
+	RepaymentID  uuid.UUID
+	Principal    Money
+	Interest     Money
+	CreatedAt    time.Time
+}
+
+func (p *InvestorPayout) Total() Money {
+	return p.Principal.Add(p.Interest)
+}
+
+// InvestorShare is what a single investment is entitled to out of a pool of
+// principal and borrower interest
+type InvestorShare struct {
+	Investment *Investment
+	Principal  Money
+	Interest   Money
+}
+
+// InvestorInterest returns the part of borrower interest owed to investors.
+// Borrowers pay Rate and investors earn ROI, so investors receive ROI/Rate of
+// every interest payment and the platform keeps the rest. When ROI exceeds
+// Rate investors receive all of it.
+func (l *Loan) InvestorInterest(interest Money) Money {
+	switch {
+	case l.Rate <= 0:
+		return NewMoney(0, interest.Currency)
+	case l.ROI >= l.Rate:
+		return interest
+	}
+
+	share := new(big.Rat).Mul(big.NewRat(interest.Amount, 1), big.NewRat(int64(l.ROI), int64(l.Rate)))
+	return NewMoney(roundRat(share), interest.Currency)
+}
+
+// SplitAmongInvestors divides principal and the investors' cut of interest
+// pro-rata by investment amount. The parts reconcile to the cent.
+func (l *Loan) SplitAmongInvestors(investments []*Investment, principal, interest Money) ([]*InvestorShare, error) {
+	if len(investments) == 0 {
+		return nil, errors.New("loan has no investments")
+	}
+
+	weights := make([]int64, len(investments))
+	for i, inv := range investments {
+		weights[i] = inv.Amount.Amount
+	}
+
+	principalParts, err := principal.Allocate(weights)
+	if err != nil {
+		return nil, err
+	}
+	interestParts, err := l.InvestorInterest(interest).Allocate(weights)
+	if err != nil {
+		return nil, err
+	}
+
+	shares := make([]*InvestorShare, len(investments))
+	for i, inv := range investments {
+		shares[i] = &InvestorShare{
+			Investment: inv,
+			Principal:  principalParts[i],
+			Interest:   interestParts[i],
+		}
+	}
+
+	return shares, nil
+}
+
+// DistributeRepayment turns the allocations of a repayment into one payout
+// per investment
+func (l *Loan) DistributeRepayment(investments []*Investment, repaymentID uuid.UUID, allocations []*RepaymentAllocation) ([]*InvestorPayout, error) {
+	principal := NewMoney(0, l.PrincipalAmount.Currency)
+	interest := NewMoney(0, l.PrincipalAmount.Currency)
+	for _, a := range allocations {
+		principal = principal.Add(a.Principal)
+		interest = interest.Add(a.Interest)
+	}
+
+	shares, err := l.SplitAmongInvestors(investments, principal, interest)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	payouts := make([]*InvestorPayout, 0, len(shares))
+	for _, share := range shares {
+		if share.Principal.IsZero() && share.Interest.IsZero() {
+			continue
+		}
+		payouts = append(payouts, &InvestorPayout{
+			ID:           uuid.New(),
+			LoanID:       l.ID,
+			InvestmentID: share.Investment.ID,
+			InvestorID:   share.Investment.InvestorID,
+			RepaymentID:  repaymentID,
+			Principal:    share.Principal,
+			Interest:     share.Interest,
+			CreatedAt:    now,
+		})
+	}
+
+	return payouts, nil
+}
+
+// ExpectedInvestorInterest returns, per investment ID, the interest an
+// investment should earn over the whole schedule. Any unfunded part of the
+// principal keeps its share so partially funded loans are not overstated.
+func (l *Loan) ExpectedInvestorInterest(investments []*Investment, installments []*Installment) (map[uuid.UUID]Money, error) {
+	interest := NewMoney(0, l.PrincipalAmount.Currency)
+	for _, inst := range installments {
+		interest = interest.Add(inst.InterestDue)
+	}
+
+	weights := make([]int64, 0, len(investments)+1)
+	funded := int64(0)
+	for _, inv := range investments {
+		weights = append(weights, inv.Amount.Amount)
+		funded += inv.Amount.Amount
+	}
+	if unfunded := l.PrincipalAmount.Amount - funded; unfunded > 0 {
+		weights = append(weights, unfunded)
+	}
+
+	parts, err := l.InvestorInterest(interest).Allocate(weights)
+	if err != nil {
+		return nil, err
+	}
+
+	expected := make(map[uuid.UUID]Money, len(investments))
+	for i, inv := range investments {
+		expected[inv.ID] = parts[i]
+	}
+
+	return expected, nil
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvestorInterest(t *testing.T) {
+	loan := &Loan{Rate: 1200, ROI: 800}
+	assert.Equal(t, idr(6667), loan.InvestorInterest(idr(10000)))
+
+	loan = &Loan{Rate: 1000, ROI: 1200}
+	assert.Equal(t, idr(10000), loan.InvestorInterest(idr(10000)))
+
+	loan = &Loan{Rate: 0, ROI: 500}
+	assert.True(t, loan.InvestorInterest(idr(10000)).IsZero())
+}
+
+func TestDistributeRepayment(t *testing.T) {
+	loan := NewLoan(uuid.New(), idr(1000000), 1200, 800)
+	investments := []*Investment{
+		{ID: uuid.New(), InvestorID: uuid.New(), Amount: idr(333333)},
+		{ID: uuid.New(), InvestorID: uuid.New(), Amount: idr(333333)},
+		{ID: uuid.New(), InvestorID: uuid.New(), Amount: idr(333334)},
+	}
+	allocations := []*RepaymentAllocation{
+		{Principal: idr(78849), Interest: idr(10000)},
+	}
+
+	payouts, err := loan.DistributeRepayment(investments, uuid.New(), allocations)
+	require.NoError(t, err)
+	require.Len(t, payouts, 3)
+
+	principal, interest := idr(0), idr(0)
+	for _, p := range payouts {
+		principal = principal.Add(p.Principal)
+		interest = interest.Add(p.Interest)
+	}
+	assert.Equal(t, idr(78849), principal)
+	assert.Equal(t, idr(6667), interest)
+}
+
+func TestExpectedInvestorInterest_PartiallyFunded(t *testing.T) {
+	loan := NewLoan(uuid.New(), idr(1000000), 1200, 1200)
+	loan.RepaymentType = RepaymentInterestOnly
+	loan.TenorMonths = 2
+	installments, err := GenerateSchedule(loan, time.Now())
+	require.NoError(t, err)
+
+	investment := &Investment{ID: uuid.New(), Amount: idr(500000)}
+	expected, err := loan.ExpectedInvestorInterest([]*Investment{investment}, installments)
+	require.NoError(t, err)
+
+	// half the principal earns half of the 200.00 total interest
+	assert.Equal(t, idr(10000), expected[investment.ID])
+}
@@ -0,0 +1,275 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RepaymentType string
+
+const (
+	RepaymentFlat         RepaymentType = "flat"
+	RepaymentAnnuity      RepaymentType = "annuity"
+	RepaymentInterestOnly RepaymentType = "interest_only"
+)
+
+const (
+	DefaultTenorMonths   = 12
+	DefaultRepaymentType = RepaymentAnnuity
+	MaxTenorMonths       = 360
+)
+
+func (t RepaymentType) IsValid() bool {
+	return t == RepaymentFlat || t == RepaymentAnnuity || t == RepaymentInterestOnly
+}
+
+type InstallmentStatus string
+
+const (
+	InstallmentPending InstallmentStatus = "pending"
+	InstallmentPartial InstallmentStatus = "partial"
+	InstallmentPaid    InstallmentStatus = "paid"
+)
+
+type Installment struct {
+	ID            uuid.UUID
+	LoanID        uuid.UUID
+	Sequence      int
+	DueDate       time.Time
+	PrincipalDue  Money
+	InterestDue   Money
+	PrincipalPaid Money
+	InterestPaid  Money
+	Status        InstallmentStatus
+	PaidAt        *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// Repayment is a single payment received from the borrower
+type Repayment struct {
+	ID        uuid.UUID
+	LoanID    uuid.UUID
+	Amount    Money
+	PaidAt    time.Time
+	CreatedAt time.Time
+}
+
+// RepaymentAllocation records how much of a repayment settled each installment
+type RepaymentAllocation struct {
+	RepaymentID   uuid.UUID
+	InstallmentID uuid.UUID
+	Principal     Money
+	Interest      Money
+}
+
+var (
+	ErrRepaymentExceedsOutstanding = errors.New("repayment exceeds outstanding balance")
+	// ErrLoanNotDisbursed is returned when a loan's schedule is read, or a
+	// repayment made, before the loan has been disbursed
+	ErrLoanNotDisbursed = errors.New("loan is not disbursed")
+)
+
+func (i *Installment) AmountDue() Money {
+	return i.PrincipalDue.Add(i.InterestDue)
+}
+
+func (i *Installment) AmountPaid() Money {
+	return i.PrincipalPaid.Add(i.InterestPaid)
+}
+
+func (i *Installment) Outstanding() Money {
+	return i.AmountDue().Sub(i.AmountPaid())
+}
+
+// ValidateRepaymentTerms checks the tenor and schedule type chosen for a loan
+func ValidateRepaymentTerms(tenorMonths int, repaymentType RepaymentType) error {
+	if tenorMonths <= 0 || tenorMonths > MaxTenorMonths {
+		return fmt.Errorf("%w: tenor must be between 1 and %d months", ErrInvalidInput, MaxTenorMonths)
+	}
+	if !repaymentType.IsValid() {
+		return fmt.Errorf("%w: unknown repayment type %q", ErrInvalidInput, repaymentType)
+	}
+	return nil
+}
+
+// GenerateSchedule builds the monthly installments for a loan starting from
+// the disbursement date. Loan.Rate is an annual percentage. Rounding
+// differences are absorbed by the final installment so principal reconciles
+// exactly.
+func GenerateSchedule(loan *Loan, disbursedAt time.Time) ([]*Installment, error) {
+	if err := ValidateRepaymentTerms(loan.TenorMonths, loan.RepaymentType); err != nil {
+		return nil, err
+	}
+
+	n := loan.TenorMonths
+	principal := loan.PrincipalAmount
+	currency := principal.Currency
+	// monthly rate = rate% / 100 / 12, Rate is held in hundredths of a percent
+	monthlyRate := big.NewRat(int64(loan.Rate), 120000)
+
+	principalParts := make([]int64, n)
+	interestParts := make([]int64, n)
+
+	switch loan.RepaymentType {
+	case RepaymentFlat:
+		interest := roundRat(new(big.Rat).Mul(big.NewRat(principal.Amount, 1), monthlyRate))
+		share := principal.Amount / int64(n)
+		for i := 0; i < n; i++ {
+			principalParts[i] = share
+			interestParts[i] = interest
+		}
+		principalParts[n-1] += principal.Amount - share*int64(n)
+
+	case RepaymentInterestOnly:
+		interest := roundRat(new(big.Rat).Mul(big.NewRat(principal.Amount, 1), monthlyRate))
+		for i := 0; i < n; i++ {
+			interestParts[i] = interest
+		}
+		principalParts[n-1] = principal.Amount
+
+	case RepaymentAnnuity:
+		payment := annuityPayment(principal.Amount, monthlyRate, n)
+		balance := principal.Amount
+		for i := 0; i < n; i++ {
+			interest := roundRat(new(big.Rat).Mul(big.NewRat(balance, 1), monthlyRate))
+			part := payment - interest
+			if i == n-1 || part > balance {
+				part = balance
+			}
+			principalParts[i] = part
+			interestParts[i] = interest
+			balance -= part
+		}
+	}
+
+	now := time.Now()
+	installments := make([]*Installment, n)
+	for i := 0; i < n; i++ {
+		installments[i] = &Installment{
+			ID:            uuid.New(),
+			LoanID:        loan.ID,
+			Sequence:      i + 1,
+			DueDate:       dueDate(disbursedAt, i+1),
+			PrincipalDue:  NewMoney(principalParts[i], currency),
+			InterestDue:   NewMoney(interestParts[i], currency),
+			PrincipalPaid: NewMoney(0, currency),
+			InterestPaid:  NewMoney(0, currency),
+			Status:        InstallmentPending,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+	}
+
+	return installments, nil
+}
+
+// dueDate is the date months after disbursedAt, on the same day of the month
+// or the month's last day when it is shorter
+func dueDate(disbursedAt time.Time, months int) time.Time {
+	year, month, day := disbursedAt.Date()
+	hour, min, sec := disbursedAt.Clock()
+	first := time.Date(year, month+time.Month(months), 1, hour, min, sec, disbursedAt.Nanosecond(), disbursedAt.Location())
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// ApplyRepayment settles installments oldest first, interest before
+// principal, and returns the resulting allocations. Installments are updated
+// in place.
+func ApplyRepayment(installments []*Installment, repayment *Repayment) ([]*RepaymentAllocation, error) {
+	if !repayment.Amount.IsPositive() {
+		return nil, fmt.Errorf("%w: repayment amount must be positive", ErrInvalidInput)
+	}
+
+	outstanding := NewMoney(0, repayment.Amount.Currency)
+	for _, inst := range installments {
+		if !inst.PrincipalDue.SameCurrency(repayment.Amount) {
+			return nil, fmt.Errorf("%w: repayment in %s, loan in %s", ErrCurrencyMismatch, repayment.Amount.Currency, inst.PrincipalDue.Currency)
+		}
+		outstanding = outstanding.Add(inst.Outstanding())
+	}
+	if repayment.Amount.Cmp(outstanding) > 0 {
+		return nil, fmt.Errorf("%w: paying %s, outstanding %s", ErrRepaymentExceedsOutstanding, repayment.Amount, outstanding)
+	}
+
+	remaining := repayment.Amount.Amount
+	var allocations []*RepaymentAllocation
+	for _, inst := range installments {
+		if remaining == 0 {
+			break
+		}
+		if inst.Status == InstallmentPaid {
+			continue
+		}
+
+		interest := min(remaining, inst.InterestDue.Amount-inst.InterestPaid.Amount)
+		remaining -= interest
+		principal := min(remaining, inst.PrincipalDue.Amount-inst.PrincipalPaid.Amount)
+		remaining -= principal
+
+		if interest == 0 && principal == 0 {
+			continue
+		}
+
+		inst.InterestPaid.Amount += interest
+		inst.PrincipalPaid.Amount += principal
+		inst.UpdatedAt = time.Now()
+		if inst.Outstanding().IsZero() {
+			paidAt := repayment.PaidAt
+			inst.Status = InstallmentPaid
+			inst.PaidAt = &paidAt
+		} else {
+			inst.Status = InstallmentPartial
+		}
+
+		allocations = append(allocations, &RepaymentAllocation{
+			RepaymentID:   repayment.ID,
+			InstallmentID: inst.ID,
+			Principal:     NewMoney(principal, repayment.Amount.Currency),
+			Interest:      NewMoney(interest, repayment.Amount.Currency),
+		})
+	}
+
+	return allocations, nil
+}
+
+// annuityPayment returns P*r / (1 - (1+r)^-n) rounded to the nearest minor unit
+func annuityPayment(principal int64, monthlyRate *big.Rat, n int) int64 {
+	if monthlyRate.Sign() == 0 {
+		return roundRat(big.NewRat(principal, int64(n)))
+	}
+
+	one := big.NewRat(1, 1)
+	growth := new(big.Rat).Add(one, monthlyRate)
+	compound := new(big.Rat).Set(one)
+	for i := 0; i < n; i++ {
+		compound.Mul(compound, growth)
+	}
+
+	numerator := new(big.Rat).Mul(big.NewRat(principal, 1), monthlyRate)
+	numerator.Mul(numerator, compound)
+	denominator := new(big.Rat).Sub(compound, one)
+	return roundRat(numerator.Quo(numerator, denominator))
+}
+
+// roundRat rounds half away from zero to an integer
+func roundRat(r *big.Rat) int64 {
+	num := new(big.Int).Set(r.Num())
+	den := r.Denom()
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2)).Cmp(den) >= 0 {
+		if num.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+	return quo.Int64()
+}
@@ -0,0 +1,138 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scheduleLoan(repaymentType RepaymentType, tenor int, rate Percent) *Loan {
+	loan := NewLoan(uuid.New(), idr(1000000), rate, 800)
+	loan.TenorMonths = tenor
+	loan.RepaymentType = repaymentType
+	return loan
+}
+
+func sumPrincipal(installments []*Installment) Money {
+	total := idr(0)
+	for _, inst := range installments {
+		total = total.Add(inst.PrincipalDue)
+	}
+	return total
+}
+
+func TestGenerateSchedule_Annuity(t *testing.T) {
+	installments, err := GenerateSchedule(scheduleLoan(RepaymentAnnuity, 12, 1200), time.Now())
+	require.NoError(t, err)
+	require.Len(t, installments, 12)
+
+	// 10,000.00 at 1% a month over 12 months is 888.49 a month
+	for _, inst := range installments[:11] {
+		assert.Equal(t, idr(88849), inst.AmountDue())
+	}
+	assert.Equal(t, idr(1000000), sumPrincipal(installments))
+	assert.Equal(t, idr(10000), installments[0].InterestDue)
+}
+
+func TestGenerateSchedule_Flat(t *testing.T) {
+	installments, err := GenerateSchedule(scheduleLoan(RepaymentFlat, 3, 1200), time.Now())
+	require.NoError(t, err)
+	require.Len(t, installments, 3)
+
+	assert.Equal(t, idr(333333), installments[0].PrincipalDue)
+	assert.Equal(t, idr(333334), installments[2].PrincipalDue)
+	for _, inst := range installments {
+		assert.Equal(t, idr(10000), inst.InterestDue)
+	}
+	assert.Equal(t, idr(1000000), sumPrincipal(installments))
+}
+
+func TestGenerateSchedule_InterestOnly(t *testing.T) {
+	installments, err := GenerateSchedule(scheduleLoan(RepaymentInterestOnly, 6, 600), time.Now())
+	require.NoError(t, err)
+	require.Len(t, installments, 6)
+
+	for _, inst := range installments[:5] {
+		assert.True(t, inst.PrincipalDue.IsZero())
+		assert.Equal(t, idr(5000), inst.InterestDue)
+	}
+	assert.Equal(t, idr(1000000), installments[5].PrincipalDue)
+}
+
+func TestGenerateSchedule_ZeroRate(t *testing.T) {
+	installments, err := GenerateSchedule(scheduleLoan(RepaymentAnnuity, 3, 0), time.Now())
+	require.NoError(t, err)
+
+	assert.Equal(t, idr(1000000), sumPrincipal(installments))
+	for _, inst := range installments {
+		assert.True(t, inst.InterestDue.IsZero())
+	}
+}
+
+func TestGenerateSchedule_InvalidTerms(t *testing.T) {
+	_, err := GenerateSchedule(scheduleLoan(RepaymentAnnuity, 0, 1200), time.Now())
+	assert.Error(t, err)
+
+	_, err = GenerateSchedule(scheduleLoan("balloon", 12, 1200), time.Now())
+	assert.Error(t, err)
+}
+
+func TestGenerateSchedule_DueDatesStayInTheirMonth(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name        string
+		disbursedAt time.Time
+		want        []time.Time
+	}{
+		{"29th", date(2023, time.January, 29), []time.Time{date(2023, time.February, 28), date(2023, time.March, 29), date(2023, time.April, 29)}},
+		{"30th", date(2024, time.January, 30), []time.Time{date(2024, time.February, 29), date(2024, time.March, 30), date(2024, time.April, 30)}},
+		{"31st", date(2024, time.January, 31), []time.Time{date(2024, time.February, 29), date(2024, time.March, 31), date(2024, time.April, 30)}},
+		{"30th across year end", date(2024, time.November, 30), []time.Time{date(2024, time.December, 30), date(2025, time.January, 30), date(2025, time.February, 28)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installments, err := GenerateSchedule(scheduleLoan(RepaymentFlat, 3, 1200), tt.disbursedAt)
+			require.NoError(t, err)
+
+			for i, inst := range installments {
+				assert.Equal(t, tt.want[i], inst.DueDate, "installment %d", inst.Sequence)
+			}
+		})
+	}
+}
+
+func TestApplyRepayment(t *testing.T) {
+	installments, err := GenerateSchedule(scheduleLoan(RepaymentFlat, 3, 1200), time.Now())
+	require.NoError(t, err)
+
+	// one full installment plus part of the second one's interest
+	payment := &Repayment{ID: uuid.New(), Amount: idr(343333 + 4000), PaidAt: time.Now()}
+	allocations, err := ApplyRepayment(installments, payment)
+	require.NoError(t, err)
+	require.Len(t, allocations, 2)
+
+	assert.Equal(t, InstallmentPaid, installments[0].Status)
+	assert.NotNil(t, installments[0].PaidAt)
+	assert.Equal(t, InstallmentPartial, installments[1].Status)
+	assert.Equal(t, idr(4000), installments[1].InterestPaid)
+	assert.True(t, installments[1].PrincipalPaid.IsZero())
+
+	assert.Equal(t, idr(333333), allocations[0].Principal)
+	assert.Equal(t, idr(10000), allocations[0].Interest)
+	assert.Equal(t, idr(4000), allocations[1].Interest)
+}
+
+func TestApplyRepayment_RejectsOverpayment(t *testing.T) {
+	installments, err := GenerateSchedule(scheduleLoan(RepaymentFlat, 3, 1200), time.Now())
+	require.NoError(t, err)
+
+	_, err = ApplyRepayment(installments, &Repayment{Amount: idr(2000000), PaidAt: time.Now()})
+	assert.ErrorIs(t, err, ErrRepaymentExceedsOutstanding)
+}
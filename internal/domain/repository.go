@@ -2,14 +2,23 @@ package domain
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+// TxManager runs fn in a single unit of work; repository calls made with the
+// ctx passed to fn commit or roll back together.
+type TxManager interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 type LoanRepository interface {
 	Create(ctx context.Context, loan *Loan) error
 	GetByID(ctx context.Context, id uuid.UUID) (*Loan, error)
-	GetByState(ctx context.Context, state LoanState) ([]*Loan, error)
+	Search(ctx context.Context, query LoanQuery) (*LoanPage, error)
+	GetByBorrowerID(ctx context.Context, borrowerID uuid.UUID) ([]*Loan, error)
+	GetFundingExpired(ctx context.Context, now time.Time) ([]*Loan, error)
 	Update(ctx context.Context, loan *Loan) error
 }
 
@@ -21,7 +30,15 @@ type ApprovalRepository interface {
 type InvestmentRepository interface {
 	Create(ctx context.Context, investment *Investment) error
 	GetByLoanID(ctx context.Context, loanID uuid.UUID) ([]*Investment, error)
-	GetTotalByLoanID(ctx context.Context, loanID uuid.UUID) (float64, error)
+	GetByInvestorID(ctx context.Context, investorID uuid.UUID) ([]*Investment, error)
+	GetTotalByLoanID(ctx context.Context, loanID uuid.UUID) (Money, error)
+	VoidByLoanID(ctx context.Context, loanID uuid.UUID, voidedAt time.Time) ([]*Investment, error)
+	SetAgreementLetterURL(ctx context.Context, id uuid.UUID, url string) error
+}
+
+type ClosureRepository interface {
+	Create(ctx context.Context, closure *LoanClosure) error
+	GetByLoanID(ctx context.Context, loanID uuid.UUID) (*LoanClosure, error)
 }
 
 type DisbursementRepository interface {
@@ -29,6 +46,23 @@ type DisbursementRepository interface {
 	GetByLoanID(ctx context.Context, loanID uuid.UUID) (*Disbursement, error)
 }
 
+type InstallmentRepository interface {
+	CreateBatch(ctx context.Context, installments []*Installment) error
+	GetByLoanID(ctx context.Context, loanID uuid.UUID) ([]*Installment, error)
+	Update(ctx context.Context, installment *Installment) error
+}
+
+type RepaymentRepository interface {
+	Create(ctx context.Context, repayment *Repayment) error
+	CreateAllocations(ctx context.Context, allocations []*RepaymentAllocation) error
+	GetByLoanID(ctx context.Context, loanID uuid.UUID) ([]*Repayment, error)
+}
+
+type PayoutRepository interface {
+	CreateBatch(ctx context.Context, payouts []*InvestorPayout) error
+	GetByInvestorID(ctx context.Context, investorID uuid.UUID) ([]*InvestorPayout, error)
+}
+
 type UserRepository interface {
 	Create(ctx context.Context, user *User) error
 	GetByID(ctx context.Context, id uuid.UUID) (*User, error)
@@ -48,3 +82,10 @@ type InvestorRepository interface {
 	GetByUserID(ctx context.Context, userID uuid.UUID) (*Investor, error)
 	GetAll(ctx context.Context) ([]*Investor, error)
 }
+
+type BorrowerRepository interface {
+	Create(ctx context.Context, borrower *Borrower) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Borrower, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID) (*Borrower, error)
+	Update(ctx context.Context, borrower *Borrower) error
+}
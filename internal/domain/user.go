@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,8 +13,24 @@ type UserType string
 const (
 	UserTypeEmployee UserType = "employee"
 	UserTypeInvestor UserType = "investor"
+	UserTypeBorrower UserType = "borrower"
 )
 
+var (
+	ErrInvestorNotFound = errors.New("investor not found")
+	// ErrForbidden is returned when the signed-in user may not see or do
+	// what they asked for
+	ErrForbidden = errors.New("forbidden")
+)
+
+// Actor is the signed-in user a request is made by, as taken from their token
+type Actor struct {
+	UserID   uuid.UUID
+	UserType UserType
+	// Role is only set for employees
+	Role EmployeeRole
+}
+
 type User struct {
 	ID        uuid.UUID
 	Email     string
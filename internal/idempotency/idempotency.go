@@ -0,0 +1,190 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInProgress = errors.New("a request with this idempotency key is still in progress")
+	ErrKeyReused  = errors.New("idempotency key was already used for a different request")
+)
+
+type Status string
+
+const (
+	StatusInProgress Status = "in_progress"
+	StatusCompleted  Status = "completed"
+)
+
+// Record is what is remembered about an idempotency key: the request it was
+// first used for and, once that request succeeded, its response
+type Record struct {
+	Key         string
+	Fingerprint string
+	// Token identifies the reservation, so only the request holding it can
+	// renew, complete or release the key
+	Token    string
+	Status   Status
+	Response json.RawMessage
+	// ExpiresAt ends the lease of an in-progress record, or the retention of
+	// a completed one; the key is free to use again afterwards
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+type Store interface {
+	// Reserve stores record unless an unexpired record with the same key
+	// exists, in which case it returns false and the existing record
+	Reserve(ctx context.Context, record *Record) (bool, *Record, error)
+	// Renew extends the lease of a key still in progress under token until
+	// expiresAt. It returns false once the key is no longer held by that
+	// reservation.
+	Renew(ctx context.Context, key, token string, expiresAt time.Time) (bool, error)
+	// Complete stores the response of a key still reserved under token and
+	// keeps it until expiresAt
+	Complete(ctx context.Context, key, token string, response json.RawMessage, expiresAt time.Time) error
+	// Release removes a key still reserved under token so the request can be
+	// retried
+	Release(ctx context.Context, key, token string) error
+}
+
+// Fingerprint identifies a request by its parts, so a key reused for a
+// different request can be told apart from a retry
+func Fingerprint(parts ...any) (string, error) {
+	data, err := json.Marshal(parts)
+	if err != nil {
+		return "", fmt.Errorf("failed to fingerprint request: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Keeper runs each request at most once per idempotency key. A key is marked
+// in progress while its request runs, under a lease that is renewed until the
+// request returns, and keeps the response for ttl once it succeeds.
+type Keeper struct {
+	store Store
+	ttl   time.Duration
+	lease time.Duration
+}
+
+func NewKeeper(store Store, ttl, lease time.Duration) *Keeper {
+	return &Keeper{store: store, ttl: ttl, lease: lease}
+}
+
+// Do runs fn unless key has been used before. A retry of a completed request
+// gets its original response without running fn; a retry while the request
+// is still running gets ErrInProgress, and a different request under the same
+// key gets ErrKeyReused. When fn fails the key is released so the client can
+// retry.
+//
+// The lease is renewed every third of its length while fn runs, so a slow
+// request keeps its key however long it takes. The response is stored after
+// fn returns, outside its transaction. If the process dies, the key stays in
+// progress until its last lease ends. Each reservation has its own token, so
+// a request that lost its lease cannot release or complete the reservation
+// of the request that took the key over.
+func Do[T any](ctx context.Context, k *Keeper, key, fingerprint string, fn func() (T, error)) (T, error) {
+	var zero T
+
+	now := time.Now()
+	token := uuid.NewString()
+	reserved, existing, err := k.store.Reserve(ctx, &Record{
+		Key:         key,
+		Fingerprint: fingerprint,
+		Token:       token,
+		Status:      StatusInProgress,
+		ExpiresAt:   now.Add(k.lease),
+		CreatedAt:   now,
+	})
+	if err != nil {
+		return zero, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	if !reserved {
+		return replay[T](existing, fingerprint)
+	}
+
+	stop := k.renew(key, token)
+	res, err := fn()
+	stop()
+	if err != nil {
+		if rerr := k.store.Release(ctx, key, token); rerr != nil {
+			log.Printf("idempotency: failed to release key %s: %v", key, rerr)
+		}
+		return zero, err
+	}
+
+	// the request has been carried out; failing to remember its response
+	// must not report it as failed
+	response, err := json.Marshal(res)
+	if err == nil {
+		err = k.store.Complete(ctx, key, token, response, time.Now().Add(k.ttl))
+	}
+	if err != nil {
+		log.Printf("idempotency: failed to store response for key %s: %v", key, err)
+	}
+
+	return res, nil
+}
+
+// renew extends key's lease until the returned stop is called, and gives up
+// once it finds the key lost
+func (k *Keeper) renew(key, token string) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(k.lease / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), k.lease/3)
+			renewed, err := k.store.Renew(ctx, key, token, time.Now().Add(k.lease))
+			cancel()
+			if err != nil {
+				log.Printf("idempotency: failed to renew key %s: %v", key, err)
+				continue
+			}
+			if !renewed {
+				log.Printf("idempotency: key %s was lost while its request ran", key)
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+func replay[T any](record *Record, fingerprint string) (T, error) {
+	var res T
+	if record.Fingerprint != fingerprint {
+		return res, ErrKeyReused
+	}
+	if record.Status != StatusCompleted {
+		return res, ErrInProgress
+	}
+	if err := json.Unmarshal(record.Response, &res); err != nil {
+		return res, fmt.Errorf("failed to decode stored response: %w", err)
+	}
+	return res, nil
+}
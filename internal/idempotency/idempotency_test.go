@@ -0,0 +1,266 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore struct {
+	mu      sync.Mutex
+	records map[string]*Record
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{records: make(map[string]*Record)}
+}
+
+func (m *memoryStore) Reserve(ctx context.Context, record *Record) (bool, *Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, ok := m.records[record.Key]; ok && existing.ExpiresAt.After(record.CreatedAt) {
+		copied := *existing
+		return false, &copied, nil
+	}
+	m.records[record.Key] = record
+	return true, nil, nil
+}
+
+func (m *memoryStore) Renew(ctx context.Context, key, token string, expiresAt time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record, ok := m.records[key]
+	if !ok || record.Status != StatusInProgress || record.Token != token {
+		return false, nil
+	}
+	record.ExpiresAt = expiresAt
+	return true, nil
+}
+
+func (m *memoryStore) Complete(ctx context.Context, key, token string, response json.RawMessage, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record, ok := m.records[key]
+	if !ok || record.Status != StatusInProgress || record.Token != token {
+		return nil
+	}
+	record.Status = StatusCompleted
+	record.Response = response
+	record.ExpiresAt = expiresAt
+	return nil
+}
+
+func (m *memoryStore) Release(ctx context.Context, key, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if record, ok := m.records[key]; ok && record.Status == StatusInProgress && record.Token == token {
+		delete(m.records, key)
+	}
+	return nil
+}
+
+type result struct {
+	ID string
+}
+
+func fingerprint(t *testing.T, parts ...any) string {
+	fp, err := Fingerprint(parts...)
+	require.NoError(t, err)
+	return fp
+}
+
+func TestDo_ReplaysStoredResponse(t *testing.T) {
+	keeper := NewKeeper(newMemoryStore(), time.Hour, time.Minute)
+	fp := fingerprint(t, "loan-1", 500)
+
+	calls := 0
+	fn := func() (*result, error) {
+		calls++
+		return &result{ID: "approval-1"}, nil
+	}
+
+	first, err := Do(context.Background(), keeper, "approve:key", fp, fn)
+	require.NoError(t, err)
+	second, err := Do(context.Background(), keeper, "approve:key", fp, fn)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, first, second)
+}
+
+func TestDo_RejectsKeyReusedForDifferentRequest(t *testing.T) {
+	keeper := NewKeeper(newMemoryStore(), time.Hour, time.Minute)
+
+	_, err := Do(context.Background(), keeper, "invest:key", fingerprint(t, "loan-1", 500), func() (*result, error) {
+		return &result{ID: "investment-1"}, nil
+	})
+	require.NoError(t, err)
+
+	_, err = Do(context.Background(), keeper, "invest:key", fingerprint(t, "loan-1", 600), func() (*result, error) {
+		t.Fatal("a reused key must not run the request")
+		return nil, nil
+	})
+
+	assert.ErrorIs(t, err, ErrKeyReused)
+}
+
+func TestDo_RejectsRetryWhileInProgress(t *testing.T) {
+	keeper := NewKeeper(newMemoryStore(), time.Hour, time.Minute)
+	fp := fingerprint(t, "loan-1")
+
+	_, err := Do(context.Background(), keeper, "disburse:key", fp, func() (*result, error) {
+		_, err := Do(context.Background(), keeper, "disburse:key", fp, func() (*result, error) {
+			t.Fatal("a duplicate must not run while the first request is in flight")
+			return nil, nil
+		})
+		assert.ErrorIs(t, err, ErrInProgress)
+		return &result{ID: "disbursement-1"}, nil
+	})
+
+	require.NoError(t, err)
+}
+
+func TestDo_ReleasesKeyWhenRequestFails(t *testing.T) {
+	store := newMemoryStore()
+	keeper := NewKeeper(store, time.Hour, time.Minute)
+	fp := fingerprint(t, "loan-1")
+
+	_, err := Do(context.Background(), keeper, "cancel:key", fp, func() (*result, error) {
+		return nil, errors.New("loan must be approved")
+	})
+	require.Error(t, err)
+	assert.Empty(t, store.records)
+
+	res, err := Do(context.Background(), keeper, "cancel:key", fp, func() (*result, error) {
+		return &result{ID: "closure-1"}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "closure-1", res.ID)
+}
+
+func TestDo_TakesOverExpiredLease(t *testing.T) {
+	store := newMemoryStore()
+	keeper := NewKeeper(store, time.Hour, time.Minute)
+	fp := fingerprint(t, "loan-1")
+
+	// a request that died before completing
+	store.records["repay:key"] = &Record{
+		Key:         "repay:key",
+		Fingerprint: fp,
+		Status:      StatusInProgress,
+		ExpiresAt:   time.Now().Add(-time.Second),
+	}
+
+	res, err := Do(context.Background(), keeper, "repay:key", fp, func() (*result, error) {
+		return &result{ID: "repayment-1"}, nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "repayment-1", res.ID)
+	assert.Equal(t, StatusCompleted, store.records["repay:key"].Status)
+}
+
+func TestDo_ConcurrentRequestsRunOnce(t *testing.T) {
+	keeper := NewKeeper(newMemoryStore(), time.Hour, time.Minute)
+	fp := fingerprint(t, "loan-1")
+
+	var calls atomic.Int32
+	var wg sync.WaitGroup
+	errs := make([]error, 50)
+	start := make(chan struct{})
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			_, errs[i] = Do(context.Background(), keeper, "approve:key", fp, func() (*result, error) {
+				calls.Add(1)
+				time.Sleep(10 * time.Millisecond)
+				return &result{ID: "approval-1"}, nil
+			})
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	for _, err := range errs {
+		if err != nil {
+			assert.ErrorIs(t, err, ErrInProgress)
+		}
+	}
+}
+
+func TestDo_RenewsLeaseWhileRequestRuns(t *testing.T) {
+	store := newMemoryStore()
+	keeper := NewKeeper(store, time.Hour, 30*time.Millisecond)
+	fp := fingerprint(t, "loan-1")
+
+	var calls atomic.Int32
+	retried := make(chan error)
+	res, err := Do(context.Background(), keeper, "disburse:key", fp, func() (*result, error) {
+		calls.Add(1)
+		// the request outlives several leases, e.g. while uploading a file;
+		// a retry meanwhile must still find it in progress
+		time.Sleep(100 * time.Millisecond)
+		go func() {
+			_, err := Do(context.Background(), keeper, "disburse:key", fp, func() (*result, error) {
+				calls.Add(1)
+				return &result{ID: "disbursement-2"}, nil
+			})
+			retried <- err
+		}()
+		assert.ErrorIs(t, <-retried, ErrInProgress)
+		return &result{ID: "disbursement-1"}, nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "disbursement-1", res.ID)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, StatusCompleted, store.records["disburse:key"].Status)
+}
+
+func TestDo_LeavesKeyTakenOverByAnotherRequest(t *testing.T) {
+	for name, fnErr := range map[string]error{
+		"failed request does not release it":    errors.New("db down"),
+		"finished request does not complete it": nil,
+	} {
+		t.Run(name, func(t *testing.T) {
+			store := newMemoryStore()
+			keeper := NewKeeper(store, time.Hour, time.Minute)
+			fp := fingerprint(t, "loan-1")
+
+			_, _ = Do(context.Background(), keeper, "approve:key", fp, func() (*result, error) {
+				// the lease ran out, e.g. during a long pause, and a retry
+				// reserved the key for itself
+				store.mu.Lock()
+				store.records["approve:key"] = &Record{
+					Key:         "approve:key",
+					Fingerprint: fp,
+					Token:       "retry",
+					Status:      StatusInProgress,
+					ExpiresAt:   time.Now().Add(time.Minute),
+				}
+				store.mu.Unlock()
+				return &result{ID: "approval-1"}, fnErr
+			})
+
+			record := store.records["approve:key"]
+			require.NotNil(t, record)
+			assert.Equal(t, "retry", record.Token)
+			assert.Equal(t, StatusInProgress, record.Status)
+			assert.Nil(t, record.Response)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package agreement
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/mungkiice/-loan-service/internal/domain"
+	"github.com/mungkiice/-loan-service/internal/infrastructure/pdf"
+)
+
+//go:embed templates/*.tmpl
+var templates embed.FS
+
+// Generator renders agreement letters
+type Generator interface {
+	Generate(ctx context.Context, agreement *domain.Agreement) ([]byte, error)
+}
+
+// PDFGenerator renders agreements as PDF from a text template. Template lines
+// starting with "# " become headings, blank lines separate paragraphs and
+// every other line is wrapped as body text.
+type PDFGenerator struct {
+	tmpl *template.Template
+}
+
+func NewPDFGenerator() (*PDFGenerator, error) {
+	tmpl, err := template.New("loan_agreement.tmpl").Funcs(template.FuncMap{
+		"money": func(m domain.Money) string {
+			return fmt.Sprintf("%s %s", m.Currency, m)
+		},
+		"percent": func(p domain.Percent) string {
+			return p.String() + "%"
+		},
+		"date": func(t time.Time) string {
+			return t.Format("2 January 2006")
+		},
+		"inc": func(i int) int {
+			return i + 1
+		},
+	}).ParseFS(templates, "templates/loan_agreement.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse agreement template: %w", err)
+	}
+
+	return &PDFGenerator{tmpl: tmpl}, nil
+}
+
+func (g *PDFGenerator) Generate(ctx context.Context, agreement *domain.Agreement) ([]byte, error) {
+	var text bytes.Buffer
+	if err := g.tmpl.Execute(&text, agreement); err != nil {
+		return nil, fmt.Errorf("failed to render agreement: %w", err)
+	}
+
+	doc := pdf.New()
+	scanner := bufio.NewScanner(&text)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t")
+		switch {
+		case line == "":
+			doc.Blank()
+		case strings.HasPrefix(line, "# "):
+			doc.Heading(strings.TrimPrefix(line, "# "))
+		default:
+			doc.Text(line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return doc.Bytes(), nil
+}
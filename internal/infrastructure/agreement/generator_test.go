@@ -0,0 +1,93 @@
+package agreement
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mungkiice/-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func idr(minorUnits int64) domain.Money {
+	return domain.NewMoney(minorUnits, domain.CurrencyIDR)
+}
+
+func testAgreement(t *testing.T) *domain.Agreement {
+	loan := domain.NewLoan(uuid.New(), idr(100000000), 1200, 800)
+	loan.TenorMonths = 6
+	borrower := &domain.Borrower{ID: loan.BorrowerID, Name: `Budi (Jr.) \ Santoso`}
+	first := &domain.Investor{ID: uuid.New(), Name: "Ani (Capital)"}
+	second := &domain.Investor{ID: uuid.New(), Name: "Citra"}
+	investments := []*domain.Investment{
+		{ID: uuid.New(), LoanID: loan.ID, InvestorID: first.ID, Amount: idr(75000000)},
+		{ID: uuid.New(), LoanID: loan.ID, InvestorID: second.ID, Amount: idr(25000000)},
+	}
+
+	letter, err := domain.NewAgreement(loan, borrower, investments, map[uuid.UUID]*domain.Investor{first.ID: first, second.ID: second}, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	return letter
+}
+
+func generate(t *testing.T, letter *domain.Agreement) string {
+	gen, err := NewPDFGenerator()
+	require.NoError(t, err)
+
+	out, err := gen.Generate(context.Background(), letter)
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(out, []byte("%PDF-")))
+	return string(out)
+}
+
+func TestGenerate_EscapesNames(t *testing.T) {
+	out := generate(t, testAgreement(t))
+
+	assert.Contains(t, out, `Name: Budi \(Jr.\) \\ Santoso`)
+	assert.Contains(t, out, `Ani \(Capital\) \(investor`)
+	assert.NotContains(t, out, "(Jr.)")
+}
+
+func TestGenerate_BorrowerCopyListsEveryInvestor(t *testing.T) {
+	letter := testAgreement(t)
+	out := generate(t, letter)
+
+	for _, p := range letter.Parties {
+		assert.Contains(t, out, "Invested IDR "+p.Investment.Amount.String()+", "+p.Share.String()+"% of the principal.")
+	}
+}
+
+func TestGenerate_InvestorCopyShowsOnlyTheirTerms(t *testing.T) {
+	letter := testAgreement(t)
+
+	for i, party := range letter.Parties {
+		other := letter.Parties[1-i]
+		out := generate(t, letter.For(party))
+
+		assert.Contains(t, out, "(investor "+party.Investor.ID.String())
+		assert.Contains(t, out, party.Share.String()+"% of the principal")
+		assert.Contains(t, out, "expected total return IDR "+party.ExpectedReturn().String())
+
+		assert.NotContains(t, out, other.Investor.ID.String())
+		assert.NotContains(t, out, other.Share.String()+"% of the principal")
+		assert.NotContains(t, out, "IDR "+other.ExpectedInterest.String())
+		assert.NotContains(t, out, "IDR "+other.ExpectedReturn().String())
+	}
+
+	// each copy is a separate letter; the borrower's stays unaddressed
+	assert.Nil(t, letter.Recipient)
+}
+
+func TestGenerate_WrapsLongLines(t *testing.T) {
+	out := generate(t, testAgreement(t))
+
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasSuffix(line, ") Tj ET") {
+			assert.LessOrEqual(t, len(line), 140, line)
+		}
+	}
+	assert.Contains(t, out, "(The borrower agrees to repay")
+}
@@ -6,21 +6,37 @@ import (
 	"os"
 )
 
-type EmailService interface {
-	SendAgreementEmail(ctx context.Context, investorEmail string, agreementURL string) error
+// Attachment is a file sent along with an email
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
 }
 
-type MockEmailService struct {
+// Message is a rendered email with a plain-text and an HTML body
+type Message struct {
+	To          string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []Attachment
+}
+
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+type MockSender struct {
 	logger *log.Logger
 }
 
-func NewMockEmailService() *MockEmailService {
-	return &MockEmailService{
+func NewMockSender() *MockSender {
+	return &MockSender{
 		logger: log.New(os.Stdout, "[EMAIL] ", log.LstdFlags),
 	}
 }
 
-func (s *MockEmailService) SendAgreementEmail(ctx context.Context, investorEmail string, agreementURL string) error {
-	s.logger.Printf("Sending agreement email to %s with URL: %s", investorEmail, agreementURL)
+func (s *MockSender) Send(ctx context.Context, msg Message) error {
+	s.logger.Printf("Sending %q to %s (%d attachment(s))", msg.Subject, msg.To, len(msg.Attachments))
 	return nil
 }
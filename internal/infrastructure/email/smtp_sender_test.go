@@ -0,0 +1,277 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type receivedMail struct {
+	auth string
+	from string
+	to   []string
+	data []byte
+}
+
+// fakeSMTPServer speaks just enough SMTP for net/smtp: EHLO with AUTH PLAIN,
+// MAIL, RCPT, DATA and QUIT. Recipients in reject are refused.
+type fakeSMTPServer struct {
+	ln     net.Listener
+	reject map[string]bool
+
+	mu   sync.Mutex
+	mail []receivedMail
+}
+
+func startFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &fakeSMTPServer{ln: ln, reject: make(map[string]bool)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+
+	return s
+}
+
+func (s *fakeSMTPServer) port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTPServer) received() []receivedMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]receivedMail(nil), s.mail...)
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+
+	var current receivedMail
+	_ = tp.PrintfLine("220 localhost ESMTP fake")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			_ = tp.PrintfLine("250-localhost")
+			_ = tp.PrintfLine("250-AUTH PLAIN")
+			_ = tp.PrintfLine("250 8BITMIME")
+		case "HELO", "NOOP", "RSET":
+			_ = tp.PrintfLine("250 OK")
+		case "AUTH":
+			current.auth = arg
+			_ = tp.PrintfLine("235 2.7.0 Authentication successful")
+		case "MAIL":
+			current.from = addressArg(arg)
+			_ = tp.PrintfLine("250 OK")
+		case "RCPT":
+			to := addressArg(arg)
+			if s.reject[to] {
+				_ = tp.PrintfLine("550 5.1.1 mailbox unavailable")
+				continue
+			}
+			current.to = append(current.to, to)
+			_ = tp.PrintfLine("250 OK")
+		case "DATA":
+			_ = tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			current.data = data
+			s.mu.Lock()
+			s.mail = append(s.mail, current)
+			s.mu.Unlock()
+			current = receivedMail{auth: current.auth}
+			_ = tp.PrintfLine("250 OK queued")
+		case "QUIT":
+			_ = tp.PrintfLine("221 Bye")
+			return
+		default:
+			_ = tp.PrintfLine("502 command not implemented")
+		}
+	}
+}
+
+// addressArg extracts the address from "FROM:<a@b>" or "TO:<a@b>"
+func addressArg(arg string) string {
+	start, end := strings.Index(arg, "<"), strings.Index(arg, ">")
+	if start < 0 || end < start {
+		return arg
+	}
+	return arg[start+1 : end]
+}
+
+type mailPart struct {
+	contentType string
+	filename    string
+	content     []byte
+}
+
+// readParts flattens a received message into its leaf MIME parts, decoding
+// base64 bodies. multipart.Reader already decodes quoted-printable.
+func readParts(t *testing.T, data []byte) (*mail.Message, []mailPart) {
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	var parts []mailPart
+	var walk func(contentType string, body io.Reader)
+	walk = func(contentType string, body io.Reader) {
+		mediaType, params, err := mime.ParseMediaType(contentType)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(mediaType, "multipart/"), mediaType)
+
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			p, err := mr.NextPart()
+			if err == io.EOF {
+				return
+			}
+			require.NoError(t, err)
+
+			partType := p.Header.Get("Content-Type")
+			if strings.HasPrefix(partType, "multipart/") {
+				walk(partType, p)
+				continue
+			}
+
+			content, err := io.ReadAll(p)
+			require.NoError(t, err)
+			if p.Header.Get("Content-Transfer-Encoding") == "base64" {
+				content, err = base64.StdEncoding.DecodeString(strings.ReplaceAll(string(content), "\r\n", ""))
+				require.NoError(t, err)
+			}
+			parts = append(parts, mailPart{contentType: partType, filename: p.FileName(), content: content})
+		}
+	}
+	walk(msg.Header.Get("Content-Type"), msg.Body)
+
+	return msg, parts
+}
+
+func newTestSMTPSender(t *testing.T, server *fakeSMTPServer) *SMTPSender {
+	s, err := NewSMTPSender("127.0.0.1", server.port(), "mailer", "secret", "Loan Service <noreply@example.com>")
+	require.NoError(t, err)
+	return s
+}
+
+func TestSMTPSender_SendWithAttachment(t *testing.T) {
+	server := startFakeSMTPServer(t)
+	s := newTestSMTPSender(t, server)
+	pdf := append([]byte("%PDF-1.4\n"), bytes.Repeat([]byte{0xff, 0x00, 0x7f}, 100)...)
+
+	err := s.Send(context.Background(), Message{
+		To:      "investor@example.com",
+		Subject: "Your loan agreement letter",
+		Text:    "Download your agreement letter: http://example.com/agreement.pdf",
+		HTML:    `<p><a href="http://example.com/agreement.pdf">Download your agreement letter</a></p>`,
+		Attachments: []Attachment{{
+			Filename:    "loan-agreement.pdf",
+			ContentType: "application/pdf",
+			Content:     pdf,
+		}},
+	})
+
+	require.NoError(t, err)
+	received := server.received()
+	require.Len(t, received, 1)
+	assert.Equal(t, "noreply@example.com", received[0].from)
+	assert.Equal(t, []string{"investor@example.com"}, received[0].to)
+	assert.Equal(t, "PLAIN "+base64.StdEncoding.EncodeToString([]byte("\x00mailer\x00secret")), received[0].auth)
+
+	msg, parts := readParts(t, received[0].data)
+	assert.Equal(t, "Your loan agreement letter", msg.Header.Get("Subject"))
+	assert.Equal(t, `"Loan Service" <noreply@example.com>`, msg.Header.Get("From"))
+	assert.Equal(t, "<investor@example.com>", msg.Header.Get("To"))
+
+	require.Len(t, parts, 3)
+	assert.Equal(t, "text/plain; charset=utf-8", parts[0].contentType)
+	assert.Equal(t, "Download your agreement letter: http://example.com/agreement.pdf", string(parts[0].content))
+	assert.Equal(t, "text/html; charset=utf-8", parts[1].contentType)
+	assert.Contains(t, string(parts[1].content), `<a href="http://example.com/agreement.pdf">`)
+	assert.Equal(t, "loan-agreement.pdf", parts[2].filename)
+	assert.Equal(t, pdf, parts[2].content)
+}
+
+func TestSMTPSender_EncodesNonASCIISubjectAndLongLines(t *testing.T) {
+	server := startFakeSMTPServer(t)
+	s := newTestSMTPSender(t, server)
+	long := strings.Repeat("Pinjaman Anda telah didanai sepenuhnya. ", 10)
+
+	err := s.Send(context.Background(), Message{
+		To:      "investor@example.com",
+		Subject: "Dana dikembalikan – Rp 4.000",
+		Text:    long,
+		HTML:    "<p>" + long + "</p>",
+	})
+
+	require.NoError(t, err)
+	msg, parts := readParts(t, server.received()[0].data)
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Dana dikembalikan – Rp 4.000", subject)
+	require.Len(t, parts, 2)
+	assert.Equal(t, long, string(parts[0].content))
+}
+
+func TestSMTPSender_ReturnsErrorWhenRecipientRejected(t *testing.T) {
+	server := startFakeSMTPServer(t)
+	server.reject["nobody@example.com"] = true
+	s := newTestSMTPSender(t, server)
+
+	err := s.Send(context.Background(), Message{To: "nobody@example.com", Subject: "Hello", Text: "Hello"})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "mailbox unavailable")
+	assert.Empty(t, server.received())
+}
+
+func TestSMTPSender_ReturnsErrorWhenServerUnreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	s, err := NewSMTPSender("127.0.0.1", port, "", "", "noreply@example.com")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err = s.Send(ctx, Message{To: "investor@example.com", Subject: "Hello", Text: "Hello"})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), strconv.Itoa(port))
+}
+
+func TestNewSMTPSender_RejectsInvalidFrom(t *testing.T) {
+	_, err := NewSMTPSender("127.0.0.1", 25, "", "", "not an address")
+
+	assert.Error(t, err)
+}
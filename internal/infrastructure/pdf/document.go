@@ -0,0 +1,188 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A4 portrait in points
+const (
+	pageWidth  = 595
+	pageHeight = 842
+	margin     = 56
+)
+
+type font string
+
+const (
+	fontRegular font = "F1"
+	fontBold    font = "F2"
+)
+
+type style struct {
+	font    font
+	size    int
+	leading int
+	// wrap is the number of characters per line, an approximation of
+	// Helvetica's average glyph width at this size
+	wrap int
+}
+
+var (
+	headingStyle = style{font: fontBold, size: 14, leading: 22, wrap: 62}
+	bodyStyle    = style{font: fontRegular, size: 10, leading: 14, wrap: 92}
+)
+
+type line struct {
+	style style
+	text  string
+}
+
+// Document is a minimal text-only PDF writer using the standard Helvetica
+// fonts, enough for generated letters without an external dependency.
+// Text outside Latin-1 is replaced with '?'.
+type Document struct {
+	lines []line
+}
+
+func New() *Document {
+	return &Document{}
+}
+
+// Heading adds a bold line
+func (d *Document) Heading(text string) {
+	d.add(headingStyle, text)
+}
+
+// Text adds a paragraph, wrapped to the page width
+func (d *Document) Text(text string) {
+	d.add(bodyStyle, text)
+}
+
+// Blank adds an empty line
+func (d *Document) Blank() {
+	d.lines = append(d.lines, line{style: bodyStyle})
+}
+
+func (d *Document) add(s style, text string) {
+	for _, l := range wrap(text, s.wrap) {
+		d.lines = append(d.lines, line{style: s, text: l})
+	}
+}
+
+// WriteTo renders the document, paginating as needed
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	pages := d.paginate()
+
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// objects 1-4 are fixed, then a page and its content stream per page
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range pages {
+		object(fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 6+2*i,
+		))
+		content := pageContent(page)
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.WriteTo(w)
+}
+
+// Bytes renders the document into memory
+func (d *Document) Bytes() []byte {
+	var buf bytes.Buffer
+	_, _ = d.WriteTo(&buf)
+	return buf.Bytes()
+}
+
+func (d *Document) paginate() [][]line {
+	pages := [][]line{{}}
+	used := 0
+	for _, l := range d.lines {
+		if used+l.style.leading > pageHeight-2*margin {
+			pages = append(pages, []line{})
+			used = 0
+		}
+		pages[len(pages)-1] = append(pages[len(pages)-1], l)
+		used += l.style.leading
+	}
+	return pages
+}
+
+func pageContent(lines []line) string {
+	var b strings.Builder
+	y := pageHeight - margin
+	for _, l := range lines {
+		y -= l.style.leading
+		if l.text == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "BT /%s %d Tf %d %d Td (%s) Tj ET\n", l.style.font, l.style.size, margin, y, escape(l.text))
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// escape encodes text as a WinAnsi PDF string literal
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// wrap breaks text on spaces into lines of at most width characters
+func wrap(text string, width int) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{""}
+	}
+
+	var lines []string
+	current := words[0]
+	for _, w := range words[1:] {
+		if len(current)+1+len(w) > width {
+			lines = append(lines, current)
+			current = w
+			continue
+		}
+		current += " " + w
+	}
+	return append(lines, current)
+}
@@ -0,0 +1,124 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func letter() *Document {
+	doc := New()
+	doc.Heading("LOAN AGREEMENT LETTER")
+	doc.Text("Name: Budi")
+	doc.Blank()
+	doc.Text("Invested IDR 1000000.00")
+	return doc
+}
+
+func TestWriteTo_StartsWithHeader(t *testing.T) {
+	out := letter().Bytes()
+
+	assert.True(t, bytes.HasPrefix(out, []byte("%PDF-")))
+	assert.True(t, bytes.HasSuffix(out, []byte("%%EOF\n")))
+}
+
+func TestWriteTo_XrefPointsAtEachObject(t *testing.T) {
+	out := letter().Bytes()
+
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(out)
+	require.NotNil(t, startxref)
+	xref, err := strconv.Atoi(string(startxref[1]))
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(out[xref:], []byte("xref\n")), "startxref must point at the xref table")
+
+	table := strings.Split(string(out[xref:]), "\n")
+	var first, count int
+	_, err = fmt.Sscanf(table[1], "%d %d", &first, &count)
+	require.NoError(t, err)
+	require.Equal(t, 0, first)
+	assert.Equal(t, "0000000000 65535 f ", table[2])
+
+	// the free entry, catalog, page tree and two fonts, then the one page and
+	// its content stream
+	require.Equal(t, 7, count)
+	for obj := 1; obj < count; obj++ {
+		entry := table[2+obj]
+		require.Len(t, entry, 19, "xref entries are 20 bytes with the newline")
+		offset, err := strconv.Atoi(entry[:10])
+		require.NoError(t, err)
+
+		header := fmt.Sprintf("%d 0 obj\n", obj)
+		assert.True(t, bytes.HasPrefix(out[offset:], []byte(header)), "object %d at offset %d", obj, offset)
+	}
+	assert.Contains(t, string(out), fmt.Sprintf("/Size %d", count))
+}
+
+func TestWriteTo_StreamLengthMatchesContent(t *testing.T) {
+	out := string(letter().Bytes())
+
+	m := regexp.MustCompile(`<< /Length (\d+) >>\nstream\n`).FindStringSubmatchIndex(out)
+	require.NotNil(t, m)
+	length, err := strconv.Atoi(out[m[2]:m[3]])
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(out[m[1]+length:], "\nendstream"))
+}
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"PT Maju (Jaya)", `PT Maju \(Jaya\)`},
+		{`C:\docs`, `C:\\docs`},
+		{"unbalanced )(", `unbalanced \)\(`},
+		{"Café", `Caf\351`},
+		{"€ and 漢", "? and ?"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, escape(tt.in), tt.in)
+	}
+}
+
+func TestText_WrapsLongParagraphs(t *testing.T) {
+	doc := New()
+	doc.Text(strings.Repeat("word ", 60))
+
+	require.Greater(t, len(doc.lines), 1)
+	for _, l := range doc.lines {
+		assert.LessOrEqual(t, len(l.text), bodyStyle.wrap)
+	}
+	assert.Equal(t, strings.Repeat("word ", 60), strings.Join(texts(doc.lines), " ")+" ")
+}
+
+func TestWriteTo_PaginatesLongDocuments(t *testing.T) {
+	doc := New()
+	for i := 0; i < 120; i++ {
+		doc.Text(fmt.Sprintf("Installment %d", i+1))
+	}
+
+	pages := doc.paginate()
+	out := string(doc.Bytes())
+
+	require.Len(t, pages, 3)
+	assert.Contains(t, out, "/Count 3")
+	assert.Equal(t, 3, strings.Count(out, "/Type /Page "))
+	for _, page := range pages {
+		assert.LessOrEqual(t, len(page)*bodyStyle.leading, pageHeight-2*margin)
+	}
+	assert.Contains(t, out, "(Installment 120) Tj")
+}
+
+func texts(lines []line) []string {
+	res := make([]string, len(lines))
+	for i, l := range lines {
+		res[i] = l.text
+	}
+	return res
+}
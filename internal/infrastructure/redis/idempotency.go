@@ -0,0 +1,186 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mungkiice/-loan-service/internal/idempotency"
+	"github.com/redis/go-redis/v9"
+)
+
+// reserveAttempts bounds how often a reservation is retried when the key it
+// conflicted with expires or is released before it can be read
+const reserveAttempts = 3
+
+// idempotencyRecord is how an idempotency.Record is stored; its key expires
+// with the record
+type idempotencyRecord struct {
+	Fingerprint string             `json:"fingerprint"`
+	Token       string             `json:"token"`
+	Status      idempotency.Status `json:"status"`
+	Response    json.RawMessage    `json:"response,omitempty"`
+	ExpiresAt   time.Time          `json:"expires_at"`
+	CreatedAt   time.Time          `json:"created_at"`
+}
+
+// replaceScript replaces a record with an updated one unless it changed since
+// it was read, e.g. because its lease ran out and another request took the
+// key
+var replaceScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+return 1
+`)
+
+// releaseScript deletes a record only while it is in progress under the
+// given token, so a late release can neither drop a stored response nor free
+// a key another request has reserved since
+var releaseScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if not current then
+	return 0
+end
+local record = cjson.decode(current)
+if record.status ~= 'in_progress' or record.token ~= ARGV[1] then
+	return 0
+end
+return redis.call('DEL', KEYS[1])
+`)
+
+func idempotencyKey(key string) string {
+	return fmt.Sprintf("idempotency:%s", key)
+}
+
+// ReserveIdempotencyKey sets the key with SET NX, so exactly one of several
+// concurrent requests reserves it
+func (c *Client) ReserveIdempotencyKey(ctx context.Context, record *idempotency.Record) (bool, *idempotency.Record, error) {
+	data, err := json.Marshal(idempotencyRecord{
+		Fingerprint: record.Fingerprint,
+		Token:       record.Token,
+		Status:      record.Status,
+		Response:    record.Response,
+		ExpiresAt:   record.ExpiresAt,
+		CreatedAt:   record.CreatedAt,
+	})
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to encode idempotency record: %w", err)
+	}
+
+	for i := 0; i < reserveAttempts; i++ {
+		reserved, err := c.client.SetNX(ctx, idempotencyKey(record.Key), data, record.ExpiresAt.Sub(record.CreatedAt)).Result()
+		if err != nil {
+			return false, nil, err
+		}
+		if reserved {
+			return true, nil, nil
+		}
+
+		current, err := c.client.Get(ctx, idempotencyKey(record.Key)).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return false, nil, err
+		}
+
+		var existing idempotencyRecord
+		if err := json.Unmarshal(current, &existing); err != nil {
+			return false, nil, fmt.Errorf("failed to decode idempotency record: %w", err)
+		}
+		return false, &idempotency.Record{
+			Key:         record.Key,
+			Fingerprint: existing.Fingerprint,
+			Status:      existing.Status,
+			Response:    existing.Response,
+			ExpiresAt:   existing.ExpiresAt,
+			CreatedAt:   existing.CreatedAt,
+		}, nil
+	}
+
+	return false, nil, fmt.Errorf("idempotency key %s is contended", record.Key)
+}
+
+func (c *Client) RenewIdempotencyKey(ctx context.Context, key, token string, expiresAt time.Time) (bool, error) {
+	current, err := c.client.Get(ctx, idempotencyKey(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get idempotency record: %w", err)
+	}
+
+	var record idempotencyRecord
+	if err := json.Unmarshal(current, &record); err != nil {
+		return false, fmt.Errorf("failed to decode idempotency record: %w", err)
+	}
+	if record.Status != idempotency.StatusInProgress || record.Token != token {
+		return false, nil
+	}
+	record.ExpiresAt = expiresAt
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode idempotency record: %w", err)
+	}
+
+	return replaceScript.Run(ctx, c.client, []string{idempotencyKey(key)}, current, data, time.Until(expiresAt).Milliseconds()).Bool()
+}
+
+func (c *Client) CompleteIdempotencyKey(ctx context.Context, key, token string, response json.RawMessage, expiresAt time.Time) error {
+	current, err := c.client.Get(ctx, idempotencyKey(key)).Bytes()
+	if err != nil {
+		return fmt.Errorf("failed to get idempotency record: %w", err)
+	}
+
+	var record idempotencyRecord
+	if err := json.Unmarshal(current, &record); err != nil {
+		return fmt.Errorf("failed to decode idempotency record: %w", err)
+	}
+	if record.Status != idempotency.StatusInProgress || record.Token != token {
+		return nil
+	}
+	record.Status = idempotency.StatusCompleted
+	record.Response = response
+	record.ExpiresAt = expiresAt
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode idempotency record: %w", err)
+	}
+
+	return replaceScript.Run(ctx, c.client, []string{idempotencyKey(key)}, current, data, time.Until(expiresAt).Milliseconds()).Err()
+}
+
+func (c *Client) ReleaseIdempotencyKey(ctx context.Context, key, token string) error {
+	return releaseScript.Run(ctx, c.client, []string{idempotencyKey(key)}, token).Err()
+}
+
+// IdempotencyStore adapts a RedisClient to idempotency.Store
+type IdempotencyStore struct {
+	client RedisClient
+}
+
+func NewIdempotencyStore(client RedisClient) *IdempotencyStore {
+	return &IdempotencyStore{client: client}
+}
+
+func (s *IdempotencyStore) Reserve(ctx context.Context, record *idempotency.Record) (bool, *idempotency.Record, error) {
+	return s.client.ReserveIdempotencyKey(ctx, record)
+}
+
+func (s *IdempotencyStore) Renew(ctx context.Context, key, token string, expiresAt time.Time) (bool, error) {
+	return s.client.RenewIdempotencyKey(ctx, key, token, expiresAt)
+}
+
+func (s *IdempotencyStore) Complete(ctx context.Context, key, token string, response json.RawMessage, expiresAt time.Time) error {
+	return s.client.CompleteIdempotencyKey(ctx, key, token, response, expiresAt)
+}
+
+func (s *IdempotencyStore) Release(ctx context.Context, key, token string) error {
+	return s.client.ReleaseIdempotencyKey(ctx, key, token)
+}
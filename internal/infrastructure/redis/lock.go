@@ -0,0 +1,189 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrLockNotObtained = errors.New("resource is busy, please try again")
+	ErrLockNotHeld     = errors.New("lock is no longer held")
+)
+
+// renewScript extends a lock only while it is still held by the given token
+var renewScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+`)
+
+// unlockScript deletes a lock only while it is still held by the given token,
+// so a holder that outlived its lock cannot release someone else's
+var unlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+return redis.call('DEL', KEYS[1])
+`)
+
+func lockKey(key string) string {
+	return fmt.Sprintf("lock:%s", key)
+}
+
+// AcquireLock takes the lock for token unless it is already held
+func (c *Client) AcquireLock(ctx context.Context, key, token string, expiration time.Duration) (bool, error) {
+	return c.client.SetNX(ctx, lockKey(key), token, expiration).Result()
+}
+
+// RenewLock resets the expiration of a lock still held by token
+func (c *Client) RenewLock(ctx context.Context, key, token string, expiration time.Duration) (bool, error) {
+	return renewScript.Run(ctx, c.client, []string{lockKey(key)}, token, expiration.Milliseconds()).Bool()
+}
+
+// ReleaseLock deletes a lock still held by token
+func (c *Client) ReleaseLock(ctx context.Context, key, token string) (bool, error) {
+	return unlockScript.Run(ctx, c.client, []string{lockKey(key)}, token).Bool()
+}
+
+type LockOptions struct {
+	// TTL is how long a lock is held unless it is released or renewed
+	TTL time.Duration
+	// Wait is how long Obtain waits for a lock held by someone else
+	Wait time.Duration
+	// RetryInterval is how often a held lock is retried while waiting
+	RetryInterval time.Duration
+	// Renew keeps a lock alive for as long as its holder has not released it,
+	// by extending it every TTL/3
+	Renew bool
+}
+
+// Locker hands out locks that are owned by a random token, so only the holder
+// can renew or release them
+type Locker struct {
+	client RedisClient
+	opts   LockOptions
+}
+
+func NewLocker(client RedisClient, opts LockOptions) *Locker {
+	return &Locker{client: client, opts: opts}
+}
+
+// Obtain takes the lock on key, waiting up to Wait for it to be released. It
+// returns ErrLockNotObtained when the lock is still held after that, and the
+// context's error when ctx ends first.
+func (l *Locker) Obtain(ctx context.Context, key string) (*Lock, error) {
+	token := uuid.NewString()
+	deadline := time.NewTimer(l.opts.Wait)
+	defer deadline.Stop()
+
+	for {
+		acquired, err := l.client.AcquireLock(ctx, key, token, l.opts.TTL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to acquire lock %s: %w", key, err)
+		}
+		if acquired {
+			return l.hold(key, token), nil
+		}
+
+		retry := time.NewTimer(l.opts.RetryInterval)
+		select {
+		case <-ctx.Done():
+			retry.Stop()
+			return nil, ctx.Err()
+		case <-deadline.C:
+			retry.Stop()
+			return nil, fmt.Errorf("%w: %s", ErrLockNotObtained, key)
+		case <-retry.C:
+		}
+	}
+}
+
+// TryObtain takes the lock on key without waiting
+func (l *Locker) TryObtain(ctx context.Context, key string) (*Lock, error) {
+	token := uuid.NewString()
+	acquired, err := l.client.AcquireLock(ctx, key, token, l.opts.TTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock %s: %w", key, err)
+	}
+	if !acquired {
+		return nil, fmt.Errorf("%w: %s", ErrLockNotObtained, key)
+	}
+	return l.hold(key, token), nil
+}
+
+func (l *Locker) hold(key, token string) *Lock {
+	lock := &Lock{client: l.client, key: key, token: token, stop: make(chan struct{}), done: make(chan struct{})}
+	if l.opts.Renew {
+		go lock.renew(l.opts.TTL)
+	} else {
+		close(lock.done)
+	}
+	return lock
+}
+
+type Lock struct {
+	client RedisClient
+	key    string
+	token  string
+	stop   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+}
+
+func (l *Lock) Token() string {
+	return l.token
+}
+
+// Release stops renewing the lock and deletes it. It returns ErrLockNotHeld
+// when the lock expired and may have been taken by someone else meanwhile.
+func (l *Lock) Release(ctx context.Context) error {
+	l.once.Do(func() { close(l.stop) })
+	<-l.done
+
+	// a cancelled request must still give its lock back
+	released, err := l.client.ReleaseLock(context.WithoutCancel(ctx), l.key, l.token)
+	if err != nil {
+		return fmt.Errorf("failed to release lock %s: %w", l.key, err)
+	}
+	if !released {
+		return fmt.Errorf("%w: %s", ErrLockNotHeld, l.key)
+	}
+	return nil
+}
+
+// renew extends the lock until it is released, and gives up once it finds the
+// lock lost
+func (l *Lock) renew(ttl time.Duration) {
+	defer close(l.done)
+
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), ttl/3)
+		renewed, err := l.client.RenewLock(ctx, l.key, l.token, ttl)
+		cancel()
+		if err != nil {
+			log.Printf("lock: failed to renew %s: %v", l.key, err)
+			continue
+		}
+		if !renewed {
+			log.Printf("lock: %s was lost before it was released", l.key)
+			return
+		}
+	}
+}
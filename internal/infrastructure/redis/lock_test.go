@@ -0,0 +1,156 @@
+package redis
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryLocks keeps locks in memory for Locker; its other RedisClient methods
+// are not implemented
+type memoryLocks struct {
+	RedisClient
+
+	mu      sync.Mutex
+	locks   map[string]string
+	renewed int
+}
+
+func newMemoryLocks() *memoryLocks {
+	return &memoryLocks{locks: make(map[string]string)}
+}
+
+func (m *memoryLocks) AcquireLock(ctx context.Context, key, token string, expiration time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, held := m.locks[key]; held {
+		return false, nil
+	}
+	m.locks[key] = token
+	return true, nil
+}
+
+func (m *memoryLocks) RenewLock(ctx context.Context, key, token string, expiration time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.locks[key] != token {
+		return false, nil
+	}
+	m.renewed++
+	return true, nil
+}
+
+func (m *memoryLocks) ReleaseLock(ctx context.Context, key, token string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.locks[key] != token {
+		return false, nil
+	}
+	delete(m.locks, key)
+	return true, nil
+}
+
+// expire drops a lock as if its TTL ran out
+func (m *memoryLocks) expire(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.locks, key)
+}
+
+func (m *memoryLocks) holder(key string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.locks[key]
+}
+
+func (m *memoryLocks) renewals() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.renewed
+}
+
+func TestLock_ReleaseLeavesLockTakenOverByOthers(t *testing.T) {
+	client := newMemoryLocks()
+	locker := NewLocker(client, LockOptions{TTL: time.Minute})
+
+	first, err := locker.TryObtain(context.Background(), "invest:loan-1")
+	require.NoError(t, err)
+
+	client.expire("invest:loan-1")
+	second, err := locker.TryObtain(context.Background(), "invest:loan-1")
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, first.Release(context.Background()), ErrLockNotHeld)
+	assert.Equal(t, second.Token(), client.holder("invest:loan-1"))
+	require.NoError(t, second.Release(context.Background()))
+	assert.Empty(t, client.holder("invest:loan-1"))
+}
+
+func TestLocker_ObtainWaitsForRelease(t *testing.T) {
+	client := newMemoryLocks()
+	locker := NewLocker(client, LockOptions{TTL: time.Minute, Wait: time.Second, RetryInterval: time.Millisecond})
+
+	held, err := locker.TryObtain(context.Background(), "repay:loan-1")
+	require.NoError(t, err)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		held.Release(context.Background())
+	}()
+
+	lock, err := locker.Obtain(context.Background(), "repay:loan-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, lock.Token(), client.holder("repay:loan-1"))
+}
+
+func TestLocker_ObtainGivesUpAfterWait(t *testing.T) {
+	client := newMemoryLocks()
+	locker := NewLocker(client, LockOptions{TTL: time.Minute, Wait: 20 * time.Millisecond, RetryInterval: time.Millisecond})
+
+	held, err := locker.TryObtain(context.Background(), "invest:loan-1")
+	require.NoError(t, err)
+
+	_, err = locker.Obtain(context.Background(), "invest:loan-1")
+
+	assert.ErrorIs(t, err, ErrLockNotObtained)
+	assert.Equal(t, held.Token(), client.holder("invest:loan-1"))
+}
+
+func TestLocker_ObtainStopsWhenContextEnds(t *testing.T) {
+	client := newMemoryLocks()
+	locker := NewLocker(client, LockOptions{TTL: time.Minute, Wait: time.Minute, RetryInterval: time.Millisecond})
+
+	_, err := locker.TryObtain(context.Background(), "invest:loan-1")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = locker.Obtain(ctx, "invest:loan-1")
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestLock_RenewsUntilReleased(t *testing.T) {
+	client := newMemoryLocks()
+	locker := NewLocker(client, LockOptions{TTL: 30 * time.Millisecond, Renew: true})
+
+	lock, err := locker.TryObtain(context.Background(), "invest:loan-1")
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool { return client.renewals() >= 2 }, time.Second, time.Millisecond)
+	require.NoError(t, lock.Release(context.Background()))
+
+	renewals := client.renewals()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, renewals, client.renewals())
+}
@@ -2,19 +2,39 @@ package redis
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/mungkiice/-loan-service/internal/idempotency"
 	"github.com/redis/go-redis/v9"
 )
 
+var ErrCacheMiss = errors.New("cache miss")
+
 type RedisClient interface {
-	SetIdempotencyKey(ctx context.Context, key string, value string, expiration time.Duration) error
-	CheckIdempotencyKey(ctx context.Context, key string) (bool, error)
-	AcquireLock(ctx context.Context, key string, expiration time.Duration) (bool, error)
-	ReleaseLock(ctx context.Context, key string) error
+	// ReserveIdempotencyKey stores record unless its key is already held, in
+	// which case it returns false and the record holding it
+	ReserveIdempotencyKey(ctx context.Context, record *idempotency.Record) (bool, *idempotency.Record, error)
+	// RenewIdempotencyKey extends the lease of a key still in progress under
+	// token
+	RenewIdempotencyKey(ctx context.Context, key, token string, expiresAt time.Time) (bool, error)
+	// CompleteIdempotencyKey stores the response of a key that is still in
+	// progress under token
+	CompleteIdempotencyKey(ctx context.Context, key, token string, response json.RawMessage, expiresAt time.Time) error
+	// ReleaseIdempotencyKey frees a key that is still in progress under token
+	ReleaseIdempotencyKey(ctx context.Context, key, token string) error
+	// AcquireLock takes the lock on key for token unless it is already held
+	AcquireLock(ctx context.Context, key, token string, expiration time.Duration) (bool, error)
+	// RenewLock extends the lock on key if token still holds it
+	RenewLock(ctx context.Context, key, token string, expiration time.Duration) (bool, error)
+	// ReleaseLock deletes the lock on key if token still holds it
+	ReleaseLock(ctx context.Context, key, token string) (bool, error)
 	SetCache(ctx context.Context, key string, value string, expiration time.Duration) error
+	// GetCache returns ErrCacheMiss when key is not cached
 	GetCache(ctx context.Context, key string) (string, error)
+	DeleteCache(ctx context.Context, key string) error
 	Close() error
 }
 
@@ -35,29 +55,20 @@ func NewClient(addr string) (*Client, error) {
 	return &Client{client: rdb}, nil
 }
 
-func (c *Client) SetIdempotencyKey(ctx context.Context, key string, value string, expiration time.Duration) error {
-	return c.client.SetNX(ctx, fmt.Sprintf("idempotency:%s", key), value, expiration).Err()
-}
-
-func (c *Client) CheckIdempotencyKey(ctx context.Context, key string) (bool, error) {
-	exists, err := c.client.Exists(ctx, fmt.Sprintf("idempotency:%s", key)).Result()
-	return exists > 0, err
-}
-
-func (c *Client) AcquireLock(ctx context.Context, key string, expiration time.Duration) (bool, error) {
-	return c.client.SetNX(ctx, fmt.Sprintf("lock:%s", key), "1", expiration).Result()
-}
-
-func (c *Client) ReleaseLock(ctx context.Context, key string) error {
-	return c.client.Del(ctx, fmt.Sprintf("lock:%s", key)).Err()
-}
-
 func (c *Client) SetCache(ctx context.Context, key string, value string, expiration time.Duration) error {
 	return c.client.Set(ctx, fmt.Sprintf("cache:%s", key), value, expiration).Err()
 }
 
 func (c *Client) GetCache(ctx context.Context, key string) (string, error) {
-	return c.client.Get(ctx, fmt.Sprintf("cache:%s", key)).Result()
+	value, err := c.client.Get(ctx, fmt.Sprintf("cache:%s", key)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrCacheMiss
+	}
+	return value, err
+}
+
+func (c *Client) DeleteCache(ctx context.Context, key string) error {
+	return c.client.Del(ctx, fmt.Sprintf("cache:%s", key)).Err()
 }
 
 func (c *Client) Close() error {
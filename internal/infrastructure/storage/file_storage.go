@@ -11,6 +11,7 @@ import (
 
 type FileStorage interface {
 	Store(ctx context.Context, file io.Reader, filename string) (string, error)
+	Open(ctx context.Context, path string) (io.ReadCloser, error)
 	GetURL(path string) string
 	Delete(ctx context.Context, path string) error
 }
@@ -49,6 +50,14 @@ func (s *LocalFileStorage) Store(ctx context.Context, file io.Reader, filename s
 	return uniqueFilename, nil
 }
 
+func (s *LocalFileStorage) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	file, err := os.Open(filepath.Join(s.basePath, path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	return file, nil
+}
+
 func (s *LocalFileStorage) GetURL(path string) string {
 	return fmt.Sprintf("%s/%s", s.baseURL, path)
 }
@@ -0,0 +1,282 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mungkiice/-loan-service/internal/domain"
+)
+
+type AccountType string
+
+const (
+	AccountInvestorWallet AccountType = "investor_wallet"
+	AccountLoanEscrow     AccountType = "loan_escrow"
+	AccountBorrower       AccountType = "borrower"
+	AccountPlatformFees   AccountType = "platform_fees"
+)
+
+// Account identifies a ledger account by type and owner. The platform fee
+// account has no owner.
+type Account struct {
+	Type    AccountType
+	OwnerID uuid.UUID
+}
+
+func InvestorWallet(investorID uuid.UUID) Account {
+	return Account{Type: AccountInvestorWallet, OwnerID: investorID}
+}
+
+func LoanEscrow(loanID uuid.UUID) Account {
+	return Account{Type: AccountLoanEscrow, OwnerID: loanID}
+}
+
+func Borrower(borrowerID uuid.UUID) Account {
+	return Account{Type: AccountBorrower, OwnerID: borrowerID}
+}
+
+func PlatformFees() Account {
+	return Account{Type: AccountPlatformFees}
+}
+
+func (a Account) String() string {
+	if a.OwnerID == uuid.Nil {
+		return string(a.Type)
+	}
+	return fmt.Sprintf("%s:%s", a.Type, a.OwnerID)
+}
+
+type Side string
+
+const (
+	Debit  Side = "debit"
+	Credit Side = "credit"
+)
+
+type Line struct {
+	Account Account
+	Side    Side
+	Amount  domain.Money
+}
+
+// Entry is a journal entry. Reference is unique so the same business event
+// can never be posted twice.
+type Entry struct {
+	ID          uuid.UUID
+	Reference   string
+	Description string
+	Lines       []Line
+	PostedAt    time.Time
+}
+
+var (
+	ErrUnbalancedEntry = errors.New("journal entry is not balanced")
+	ErrEscrowMismatch  = errors.New("escrow balance does not match investments")
+)
+
+// Validate checks that every line is positive and that debits equal credits
+// per currency
+func (e *Entry) Validate() error {
+	if len(e.Lines) < 2 {
+		return fmt.Errorf("%w: needs at least two lines", ErrUnbalancedEntry)
+	}
+
+	net := make(map[domain.Currency]int64)
+	for _, line := range e.Lines {
+		if !line.Amount.IsPositive() {
+			return fmt.Errorf("%w: line for %s must be positive", ErrUnbalancedEntry, line.Account)
+		}
+		switch line.Side {
+		case Debit:
+			net[line.Amount.Currency] += line.Amount.Amount
+		case Credit:
+			net[line.Amount.Currency] -= line.Amount.Amount
+		default:
+			return fmt.Errorf("%w: unknown side %q", ErrUnbalancedEntry, line.Side)
+		}
+	}
+
+	for currency, diff := range net {
+		if diff != 0 {
+			return fmt.Errorf("%w: %s debits and credits differ by %s", ErrUnbalancedEntry, currency, domain.NewMoney(diff, currency))
+		}
+	}
+
+	return nil
+}
+
+// Balance is the total posted to an account in one currency
+type Balance struct {
+	Account Account
+	Debits  domain.Money
+	Credits domain.Money
+}
+
+// Net returns credits minus debits, the amount held on the account
+func (b *Balance) Net() domain.Money {
+	return b.Credits.Sub(b.Debits)
+}
+
+type TrialBalance struct {
+	Balances     []*Balance
+	TotalDebits  map[domain.Currency]domain.Money
+	TotalCredits map[domain.Currency]domain.Money
+}
+
+// IsBalanced reports whether total debits equal total credits in every currency
+func (t *TrialBalance) IsBalanced() bool {
+	for currency, debits := range t.TotalDebits {
+		if debits.Cmp(t.TotalCredits[currency]) != 0 {
+			return false
+		}
+	}
+	for currency, credits := range t.TotalCredits {
+		if credits.Cmp(t.TotalDebits[currency]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+type Repository interface {
+	Post(ctx context.Context, entry *Entry) error
+	GetBalance(ctx context.Context, account Account, currency domain.Currency) (*Balance, error)
+	GetBalances(ctx context.Context) ([]*Balance, error)
+}
+
+// Ledger posts balanced journal entries for loan money movements. Posting
+// joins the caller's transaction when ctx carries one.
+type Ledger struct {
+	repo Repository
+}
+
+func New(repo Repository) *Ledger {
+	return &Ledger{repo: repo}
+}
+
+func (l *Ledger) Post(ctx context.Context, entry *Entry) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+	if entry.ID == uuid.Nil {
+		entry.ID = uuid.New()
+	}
+	if entry.PostedAt.IsZero() {
+		entry.PostedAt = time.Now()
+	}
+	return l.repo.Post(ctx, entry)
+}
+
+// RecordInvestment moves the invested amount from the investor's wallet into
+// the loan's escrow
+func (l *Ledger) RecordInvestment(ctx context.Context, investment *domain.Investment) error {
+	return l.Post(ctx, &Entry{
+		Reference:   fmt.Sprintf("investment:%s", investment.ID),
+		Description: fmt.Sprintf("investment in loan %s", investment.LoanID),
+		Lines: []Line{
+			{Account: InvestorWallet(investment.InvestorID), Side: Debit, Amount: investment.Amount},
+			{Account: LoanEscrow(investment.LoanID), Side: Credit, Amount: investment.Amount},
+		},
+	})
+}
+
+// RecordRefund returns voided investments from the loan's escrow to each
+// investor's wallet when a loan is cancelled or expires
+func (l *Ledger) RecordRefund(ctx context.Context, loanID uuid.UUID, investments []*domain.Investment) error {
+	if len(investments) == 0 {
+		return nil
+	}
+
+	total := domain.Money{}
+	lines := make([]Line, 0, len(investments)+1)
+	for _, inv := range investments {
+		lines = append(lines, Line{Account: InvestorWallet(inv.InvestorID), Side: Credit, Amount: inv.Amount})
+		total = total.Add(inv.Amount)
+	}
+	lines = append(lines, Line{Account: LoanEscrow(loanID), Side: Debit, Amount: total})
+
+	return l.Post(ctx, &Entry{
+		Reference:   fmt.Sprintf("refund:%s", loanID),
+		Description: fmt.Sprintf("refund of voided investments in loan %s", loanID),
+		Lines:       lines,
+	})
+}
+
+// RecordDisbursement releases the escrowed principal to the borrower
+func (l *Ledger) RecordDisbursement(ctx context.Context, loan *domain.Loan) error {
+	return l.Post(ctx, &Entry{
+		Reference:   fmt.Sprintf("disbursement:%s", loan.ID),
+		Description: fmt.Sprintf("disbursement of loan %s", loan.ID),
+		Lines: []Line{
+			{Account: LoanEscrow(loan.ID), Side: Debit, Amount: loan.PrincipalAmount},
+			{Account: Borrower(loan.BorrowerID), Side: Credit, Amount: loan.PrincipalAmount},
+		},
+	})
+}
+
+// RecordRepayment debits the borrower for the full payment, credits each
+// investor with their payout and books the remainder as platform fees
+func (l *Ledger) RecordRepayment(ctx context.Context, loan *domain.Loan, repayment *domain.Repayment, payouts []*domain.InvestorPayout) error {
+	lines := []Line{
+		{Account: Borrower(loan.BorrowerID), Side: Debit, Amount: repayment.Amount},
+	}
+
+	fees := repayment.Amount
+	for _, p := range payouts {
+		lines = append(lines, Line{Account: InvestorWallet(p.InvestorID), Side: Credit, Amount: p.Total()})
+		fees = fees.Sub(p.Total())
+	}
+	if fees.IsPositive() {
+		lines = append(lines, Line{Account: PlatformFees(), Side: Credit, Amount: fees})
+	}
+
+	return l.Post(ctx, &Entry{
+		Reference:   fmt.Sprintf("repayment:%s", repayment.ID),
+		Description: fmt.Sprintf("repayment of loan %s", loan.ID),
+		Lines:       lines,
+	})
+}
+
+// TrialBalance lists every account balance together with the grand totals
+func (l *Ledger) TrialBalance(ctx context.Context) (*TrialBalance, error) {
+	balances, err := l.repo.GetBalances(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balances: %w", err)
+	}
+
+	tb := &TrialBalance{
+		Balances:     balances,
+		TotalDebits:  make(map[domain.Currency]domain.Money),
+		TotalCredits: make(map[domain.Currency]domain.Money),
+	}
+	for _, b := range balances {
+		currency := b.Debits.Currency
+		tb.TotalDebits[currency] = tb.TotalDebits[currency].Add(b.Debits)
+		tb.TotalCredits[currency] = tb.TotalCredits[currency].Add(b.Credits)
+	}
+
+	return tb, nil
+}
+
+// CheckEscrow verifies that a loan's escrow holds exactly the invested total
+// until disbursement and is empty afterwards
+func (l *Ledger) CheckEscrow(ctx context.Context, loan *domain.Loan, invested domain.Money) error {
+	balance, err := l.repo.GetBalance(ctx, LoanEscrow(loan.ID), loan.PrincipalAmount.Currency)
+	if err != nil {
+		return fmt.Errorf("failed to get escrow balance: %w", err)
+	}
+
+	expected := invested
+	if loan.State == domain.StateDisbursed {
+		expected = domain.NewMoney(0, invested.Currency)
+	}
+
+	if balance.Net().Cmp(expected) != 0 {
+		return fmt.Errorf("%w: escrow for loan %s holds %s, expected %s", ErrEscrowMismatch, loan.ID, balance.Net(), expected)
+	}
+
+	return nil
+}
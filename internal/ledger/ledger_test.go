@@ -0,0 +1,39 @@
+package ledger
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mungkiice/-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntryValidate(t *testing.T) {
+	idr := func(minor int64) domain.Money { return domain.NewMoney(minor, domain.CurrencyIDR) }
+	wallet := InvestorWallet(uuid.New())
+	escrow := LoanEscrow(uuid.New())
+
+	balanced := &Entry{Lines: []Line{
+		{Account: wallet, Side: Debit, Amount: idr(1000)},
+		{Account: escrow, Side: Credit, Amount: idr(1000)},
+	}}
+	assert.NoError(t, balanced.Validate())
+
+	unbalanced := &Entry{Lines: []Line{
+		{Account: wallet, Side: Debit, Amount: idr(1000)},
+		{Account: escrow, Side: Credit, Amount: idr(999)},
+	}}
+	assert.ErrorIs(t, unbalanced.Validate(), ErrUnbalancedEntry)
+
+	mixedCurrency := &Entry{Lines: []Line{
+		{Account: wallet, Side: Debit, Amount: idr(1000)},
+		{Account: escrow, Side: Credit, Amount: domain.NewMoney(1000, domain.CurrencyUSD)},
+	}}
+	assert.ErrorIs(t, mixedCurrency.Validate(), ErrUnbalancedEntry)
+
+	zeroLine := &Entry{Lines: []Line{
+		{Account: wallet, Side: Debit, Amount: idr(0)},
+		{Account: escrow, Side: Credit, Amount: idr(0)},
+	}}
+	assert.ErrorIs(t, zeroLine.Validate(), ErrUnbalancedEntry)
+}
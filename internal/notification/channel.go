@@ -0,0 +1,126 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/mungkiice/-loan-service/internal/infrastructure/email"
+	"github.com/mungkiice/-loan-service/internal/infrastructure/storage"
+	"github.com/mungkiice/-loan-service/internal/outbox"
+)
+
+// Sender delivers a rendered notification on one channel
+type Sender interface {
+	Send(ctx context.Context, n *Notification, content *Content) error
+}
+
+// RegisterHandlers delivers the messages queued by Publisher on each channel
+// through its sender. Channels without a sender are dead-lettered by the
+// dispatcher.
+func RegisterHandlers(d *outbox.Dispatcher, renderer *Renderer, senders map[Channel]Sender) {
+	for channel, sender := range senders {
+		sender := sender
+		d.Handle(Topic(channel), func(ctx context.Context, m *outbox.Message) error {
+			var n Notification
+			if err := m.Decode(&n); err != nil {
+				return err
+			}
+
+			content, err := renderer.Render(&n)
+			if err != nil {
+				return outbox.Permanent(err)
+			}
+
+			return sender.Send(ctx, &n, content)
+		})
+	}
+}
+
+// EmailChannel sends notifications by email, attaching the agreement letter
+// when the notification refers to one
+type EmailChannel struct {
+	sender      email.Sender
+	fileStorage storage.FileStorage
+}
+
+func NewEmailChannel(sender email.Sender, fileStorage storage.FileStorage) *EmailChannel {
+	return &EmailChannel{sender: sender, fileStorage: fileStorage}
+}
+
+func (c *EmailChannel) Send(ctx context.Context, n *Notification, content *Content) error {
+	msg := email.Message{
+		To:      n.Recipient.Email,
+		Subject: content.Subject,
+		Text:    content.Text,
+		HTML:    content.HTML,
+	}
+
+	if n.Data.AgreementPath != "" {
+		attachment, err := c.attachment(ctx, n.Data.AgreementPath)
+		if err != nil {
+			return err
+		}
+		msg.Attachments = append(msg.Attachments, attachment)
+	}
+
+	return c.sender.Send(ctx, msg)
+}
+
+func (c *EmailChannel) attachment(ctx context.Context, path string) (email.Attachment, error) {
+	file, err := c.fileStorage.Open(ctx, path)
+	if err != nil {
+		return email.Attachment{}, fmt.Errorf("failed to open agreement letter %s: %w", path, err)
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return email.Attachment{}, fmt.Errorf("failed to read agreement letter %s: %w", path, err)
+	}
+
+	return email.Attachment{
+		Filename:    "loan-agreement.pdf",
+		ContentType: "application/pdf",
+		Content:     content,
+	}, nil
+}
+
+// SMSChannel logs text messages until an SMS gateway is integrated
+type SMSChannel struct {
+	logger *log.Logger
+}
+
+func NewSMSChannel() *SMSChannel {
+	return &SMSChannel{
+		logger: log.New(os.Stdout, "[SMS] ", log.LstdFlags),
+	}
+}
+
+func (c *SMSChannel) Send(ctx context.Context, n *Notification, content *Content) error {
+	c.logger.Printf("Sending to %s: %s", n.Recipient.Phone, content.SMS)
+	return nil
+}
+
+// InAppChannel stores notifications in the recipient's inbox
+type InAppChannel struct {
+	repo InAppRepository
+}
+
+func NewInAppChannel(repo InAppRepository) *InAppChannel {
+	return &InAppChannel{repo: repo}
+}
+
+func (c *InAppChannel) Send(ctx context.Context, n *Notification, content *Content) error {
+	return c.repo.Create(ctx, &InAppNotification{
+		ID:        n.ID,
+		UserID:    n.Recipient.UserID,
+		Kind:      n.Kind,
+		Title:     content.Subject,
+		Body:      content.Body,
+		CreatedAt: time.Now(),
+	})
+}
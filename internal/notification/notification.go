@@ -0,0 +1,168 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Channel is a way of reaching a user
+type Channel string
+
+const (
+	ChannelEmail Channel = "email"
+	ChannelSMS   Channel = "sms"
+	ChannelInApp Channel = "in_app"
+)
+
+// Channels lists every supported channel
+var Channels = []Channel{ChannelEmail, ChannelSMS, ChannelInApp}
+
+func (c Channel) IsValid() bool {
+	return c == ChannelEmail || c == ChannelSMS || c == ChannelInApp
+}
+
+// Kind is the type of event a notification tells its recipient about. Each
+// kind has a template in templates/<kind>.txt.tmpl.
+type Kind string
+
+const (
+	KindLoanApproved       Kind = "loan_approved"
+	KindLoanRejected       Kind = "loan_rejected"
+	KindLoanCancelled      Kind = "loan_cancelled"
+	KindLoanExpired        Kind = "loan_expired"
+	KindLoanDisbursed      Kind = "loan_disbursed"
+	KindFundingProgress    Kind = "funding_progress"
+	KindAgreementReady     Kind = "agreement_ready"
+	KindInvestmentReceipt  Kind = "investment_receipt"
+	KindInvestmentRefunded Kind = "investment_refunded"
+)
+
+// Kinds lists every notification kind
+var Kinds = []Kind{
+	KindLoanApproved,
+	KindLoanRejected,
+	KindLoanCancelled,
+	KindLoanExpired,
+	KindLoanDisbursed,
+	KindFundingProgress,
+	KindAgreementReady,
+	KindInvestmentReceipt,
+	KindInvestmentRefunded,
+}
+
+// Recipient is who a notification is for and how to reach them on each
+// channel. Channels without an address are skipped.
+type Recipient struct {
+	UserID uuid.UUID `json:"user_id"`
+	Name   string    `json:"name"`
+	Email  string    `json:"email,omitempty"`
+	Phone  string    `json:"phone,omitempty"`
+}
+
+// Data holds the values the templates render. Amounts are already formatted.
+type Data struct {
+	LoanID        uuid.UUID `json:"loan_id"`
+	Amount        string    `json:"amount,omitempty"`
+	Principal     string    `json:"principal,omitempty"`
+	Funded        string    `json:"funded,omitempty"`
+	FundedPercent string    `json:"funded_percent,omitempty"`
+	Remaining     string    `json:"remaining,omitempty"`
+	AgreementURL  string    `json:"agreement_url,omitempty"`
+	// AgreementPath is the letter's file storage path, used to attach it to
+	// emails
+	AgreementPath string    `json:"agreement_path,omitempty"`
+	Reason        string    `json:"reason,omitempty"`
+	Note          string    `json:"note,omitempty"`
+	Date          time.Time `json:"date"`
+}
+
+// Notification is one message to one recipient. It is delivered once per
+// channel the recipient has enabled.
+type Notification struct {
+	ID        uuid.UUID `json:"id"`
+	Kind      Kind      `json:"kind"`
+	Recipient Recipient `json:"recipient"`
+	Data      Data      `json:"data"`
+}
+
+func New(kind Kind, recipient Recipient, data Data) *Notification {
+	return &Notification{
+		ID:        uuid.New(),
+		Kind:      kind,
+		Recipient: recipient,
+		Data:      data,
+	}
+}
+
+// Preferences says which channels a user receives notifications on
+type Preferences map[Channel]bool
+
+// DefaultPreferences are used for channels a user has not chosen: email and
+// in-app on, SMS off
+func DefaultPreferences() Preferences {
+	return Preferences{
+		ChannelEmail: true,
+		ChannelSMS:   false,
+		ChannelInApp: true,
+	}
+}
+
+// Merge returns the defaults overlaid with p
+func (p Preferences) Merge() Preferences {
+	merged := DefaultPreferences()
+	for channel, enabled := range p {
+		merged[channel] = enabled
+	}
+	return merged
+}
+
+// Enabled returns the enabled channels in Channels order
+func (p Preferences) Enabled() []Channel {
+	var enabled []Channel
+	for _, channel := range Channels {
+		if p[channel] {
+			enabled = append(enabled, channel)
+		}
+	}
+	return enabled
+}
+
+func (p Preferences) Validate() error {
+	for channel := range p {
+		if !channel.IsValid() {
+			return fmt.Errorf("unknown notification channel %q", channel)
+		}
+	}
+	return nil
+}
+
+type PreferenceRepository interface {
+	// Get returns the channels the user has set; unset channels are absent
+	Get(ctx context.Context, userID uuid.UUID) (Preferences, error)
+	Save(ctx context.Context, userID uuid.UUID, prefs Preferences) error
+}
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// InAppNotification is a notification shown in the user's inbox
+type InAppNotification struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Kind      Kind
+	Title     string
+	Body      string
+	CreatedAt time.Time
+	ReadAt    *time.Time
+}
+
+type InAppRepository interface {
+	// Create stores n; storing the same ID twice is a no-op so redelivered
+	// messages do not duplicate the inbox
+	Create(ctx context.Context, n *InAppNotification) error
+	GetByUserID(ctx context.Context, userID uuid.UUID, limit int) ([]*InAppNotification, error)
+	MarkRead(ctx context.Context, id, userID uuid.UUID, at time.Time) error
+}
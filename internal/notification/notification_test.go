@@ -0,0 +1,254 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mungkiice/-loan-service/internal/infrastructure/email"
+	"github.com/mungkiice/-loan-service/internal/outbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryPreferences map[uuid.UUID]Preferences
+
+func (m memoryPreferences) Get(ctx context.Context, userID uuid.UUID) (Preferences, error) {
+	return m[userID], nil
+}
+
+func (m memoryPreferences) Save(ctx context.Context, userID uuid.UUID, prefs Preferences) error {
+	m[userID] = prefs
+	return nil
+}
+
+type memoryOutbox struct {
+	messages []*outbox.Message
+}
+
+func (m *memoryOutbox) Create(ctx context.Context, messages []*outbox.Message) error {
+	m.messages = append(m.messages, messages...)
+	return nil
+}
+
+func (m *memoryOutbox) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*outbox.Message, error) {
+	var due []*outbox.Message
+	for _, msg := range m.messages {
+		if msg.Status == outbox.StatusPending && !msg.NextAttemptAt.After(now) && len(due) < limit {
+			msg.NextAttemptAt = now.Add(lease)
+			due = append(due, msg)
+		}
+	}
+	return due, nil
+}
+
+func (m *memoryOutbox) Update(ctx context.Context, message *outbox.Message) error {
+	return nil
+}
+
+func (m *memoryOutbox) topics() []string {
+	var topics []string
+	for _, msg := range m.messages {
+		topics = append(topics, msg.Topic)
+	}
+	return topics
+}
+
+type stubSender struct {
+	errs []error
+	sent []email.Message
+}
+
+func (s *stubSender) Send(ctx context.Context, msg email.Message) error {
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		if err != nil {
+			return err
+		}
+	}
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+type stubStorage struct{}
+
+func (stubStorage) Store(ctx context.Context, file io.Reader, filename string) (string, error) {
+	return filename, nil
+}
+
+func (stubStorage) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader([]byte("%PDF"))), nil
+}
+
+func (stubStorage) GetURL(path string) string { return "http://example.com/" + path }
+
+func (stubStorage) Delete(ctx context.Context, path string) error { return nil }
+
+func newTestDispatcher(repo outbox.Repository) *outbox.Dispatcher {
+	return outbox.NewDispatcher(repo, outbox.Config{
+		BatchSize:   10,
+		MaxAttempts: 3,
+		BaseBackoff: time.Second,
+		MaxBackoff:  time.Minute,
+		Lease:       time.Minute,
+	})
+}
+
+func TestRenderer_RendersEveryKind(t *testing.T) {
+	renderer, err := NewRenderer()
+	require.NoError(t, err)
+
+	data := Data{
+		LoanID:        uuid.New(),
+		Amount:        "2500.00",
+		Principal:     "10000.00",
+		Funded:        "7500.00",
+		FundedPercent: "75.00",
+		Remaining:     "2500.00",
+		Reason:        "credit_risk",
+		Date:          time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for _, kind := range Kinds {
+		t.Run(string(kind), func(t *testing.T) {
+			content, err := renderer.Render(New(kind, Recipient{Name: "Budi"}, data))
+
+			require.NoError(t, err)
+			assert.NotEmpty(t, content.Subject)
+			assert.NotEmpty(t, content.Body)
+			assert.NotEmpty(t, content.SMS)
+			assert.Contains(t, content.Text, "Hello Budi,")
+			assert.Contains(t, content.HTML, "<p>Hello Budi,</p>")
+		})
+	}
+}
+
+func TestRenderer_FundingProgress(t *testing.T) {
+	renderer, err := NewRenderer()
+	require.NoError(t, err)
+
+	content, err := renderer.Render(New(KindFundingProgress, Recipient{}, Data{
+		Amount:        "2500.00",
+		Principal:     "10000.00",
+		Funded:        "7500.00",
+		FundedPercent: "75.00",
+		Remaining:     "2500.00",
+	}))
+
+	require.NoError(t, err)
+	assert.Equal(t, "Your loan is 75.00% funded", content.Subject)
+	assert.Contains(t, content.Body, "raised 7500.00 of 10000.00 (75.00%), with 2500.00 to go")
+	assert.Contains(t, content.Text, "Hello there,")
+	assert.NotContains(t, content.HTML, "Download your agreement letter")
+}
+
+func TestRenderer_EscapesHTML(t *testing.T) {
+	renderer, err := NewRenderer()
+	require.NoError(t, err)
+
+	content, err := renderer.Render(New(KindLoanRejected, Recipient{Name: "<b>Budi</b>"}, Data{
+		Reason:       "other",
+		Note:         "<script>alert(1)</script>",
+		AgreementURL: "http://example.com/a.pdf",
+	}))
+
+	require.NoError(t, err)
+	assert.NotContains(t, content.HTML, "<script>")
+	assert.Contains(t, content.HTML, "&lt;b&gt;Budi&lt;/b&gt;")
+	assert.Contains(t, content.HTML, `<a href="http://example.com/a.pdf">`)
+}
+
+func TestPreferences_Merge(t *testing.T) {
+	prefs := Preferences{ChannelSMS: true, ChannelEmail: false}.Merge()
+
+	assert.Equal(t, []Channel{ChannelSMS, ChannelInApp}, prefs.Enabled())
+	assert.Equal(t, []Channel{ChannelEmail, ChannelInApp}, Preferences(nil).Merge().Enabled())
+	assert.Error(t, Preferences{"pigeon": true}.Validate())
+}
+
+func TestPublisher_Publish(t *testing.T) {
+	userID := uuid.New()
+	recipient := Recipient{UserID: userID, Email: "budi@example.com", Phone: "+62812"}
+
+	t.Run("uses the default channels", func(t *testing.T) {
+		repo := new(memoryOutbox)
+		publisher := NewPublisher(memoryPreferences{}, outbox.New(repo))
+
+		require.NoError(t, publisher.Publish(context.Background(), New(KindLoanApproved, recipient, Data{})))
+
+		assert.Equal(t, []string{"notification.email", "notification.in_app"}, repo.topics())
+	})
+
+	t.Run("follows the recipient's preferences", func(t *testing.T) {
+		repo := new(memoryOutbox)
+		prefs := memoryPreferences{userID: {ChannelEmail: false, ChannelSMS: true}}
+		publisher := NewPublisher(prefs, outbox.New(repo))
+
+		require.NoError(t, publisher.Publish(context.Background(), New(KindLoanApproved, recipient, Data{})))
+
+		assert.Equal(t, []string{"notification.sms", "notification.in_app"}, repo.topics())
+	})
+
+	t.Run("skips channels the recipient cannot be reached on", func(t *testing.T) {
+		repo := new(memoryOutbox)
+		publisher := NewPublisher(memoryPreferences{}, outbox.New(repo))
+
+		require.NoError(t, publisher.Publish(context.Background(), New(KindLoanApproved, Recipient{Email: "budi@example.com"}, Data{})))
+
+		assert.Equal(t, []string{"notification.email"}, repo.topics())
+	})
+}
+
+func TestHandlers_RetryEmailUntilDelivered(t *testing.T) {
+	repo := new(memoryOutbox)
+	sender := &stubSender{errs: []error{errors.New("mail server unavailable")}}
+	renderer, err := NewRenderer()
+	require.NoError(t, err)
+	dispatcher := newTestDispatcher(repo)
+	RegisterHandlers(dispatcher, renderer, map[Channel]Sender{ChannelEmail: NewEmailChannel(sender, stubStorage{})})
+
+	n := New(KindAgreementReady, Recipient{Email: "investor@example.com"}, Data{
+		AgreementURL:  "http://example.com/agreement.pdf",
+		AgreementPath: "agreement.pdf",
+	})
+	require.NoError(t, NewPublisher(memoryPreferences{}, outbox.New(repo)).Publish(context.Background(), n))
+
+	delivered, err := dispatcher.DispatchDue(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 0, delivered)
+	message := repo.messages[0]
+	assert.Equal(t, outbox.StatusPending, message.Status)
+
+	delivered, err = dispatcher.DispatchDue(context.Background(), message.NextAttemptAt)
+	require.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, outbox.StatusDelivered, message.Status)
+
+	require.Len(t, sender.sent, 1)
+	sent := sender.sent[0]
+	assert.Equal(t, "investor@example.com", sent.To)
+	assert.Equal(t, "Your loan agreement letter", sent.Subject)
+	assert.Equal(t, []email.Attachment{{Filename: "loan-agreement.pdf", ContentType: "application/pdf", Content: []byte("%PDF")}}, sent.Attachments)
+}
+
+func TestHandlers_DeadLetterUnreadablePayload(t *testing.T) {
+	repo := new(memoryOutbox)
+	renderer, err := NewRenderer()
+	require.NoError(t, err)
+	dispatcher := newTestDispatcher(repo)
+	RegisterHandlers(dispatcher, renderer, map[Channel]Sender{ChannelSMS: NewSMSChannel()})
+
+	message, err := outbox.NewMessage(Topic(ChannelSMS), "not an object")
+	require.NoError(t, err)
+	require.NoError(t, repo.Create(context.Background(), []*outbox.Message{message}))
+
+	_, err = dispatcher.DispatchDue(context.Background(), time.Now())
+
+	require.NoError(t, err)
+	assert.Equal(t, outbox.StatusDead, message.Status)
+	assert.Equal(t, 1, message.Attempts)
+}
@@ -0,0 +1,61 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/mungkiice/-loan-service/internal/outbox"
+)
+
+// Topic is the outbox topic a channel's deliveries are published on
+func Topic(channel Channel) string {
+	return "notification." + string(channel)
+}
+
+// Publisher queues notifications on the outbox, one message per channel the
+// recipient has enabled and can be reached on. Publishing joins the caller's
+// transaction, like outbox.Outbox.
+type Publisher struct {
+	prefs  PreferenceRepository
+	outbox *outbox.Outbox
+}
+
+func NewPublisher(prefs PreferenceRepository, outbox *outbox.Outbox) *Publisher {
+	return &Publisher{prefs: prefs, outbox: outbox}
+}
+
+func (p *Publisher) Publish(ctx context.Context, n *Notification) error {
+	prefs := DefaultPreferences()
+	if n.Recipient.UserID != uuid.Nil {
+		stored, err := p.prefs.Get(ctx, n.Recipient.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to get notification preferences: %w", err)
+		}
+		prefs = stored.Merge()
+	}
+
+	for _, channel := range prefs.Enabled() {
+		if !n.Recipient.Reachable(channel) {
+			continue
+		}
+		if err := p.outbox.Publish(ctx, Topic(channel), n); err != nil {
+			return fmt.Errorf("failed to queue %s %s notification: %w", n.Kind, channel, err)
+		}
+	}
+
+	return nil
+}
+
+// Reachable reports whether the recipient has an address on channel
+func (r Recipient) Reachable(channel Channel) bool {
+	switch channel {
+	case ChannelEmail:
+		return r.Email != ""
+	case ChannelSMS:
+		return r.Phone != ""
+	case ChannelInApp:
+		return r.UserID != uuid.Nil
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package notification
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed templates/*.tmpl
+var templates embed.FS
+
+// Content is a notification rendered for every channel
+type Content struct {
+	Subject string
+	// Body is the message without greeting or sign-off, as shown in-app
+	Body string
+	Text string
+	HTML string
+	SMS  string
+}
+
+// Renderer renders notifications from templates/<kind>.tmpl, which define
+// "subject", "body" and "sms". Email bodies wrap "body" in the shared
+// layout.txt.tmpl and layout.html.tmpl.
+type Renderer struct {
+	kinds map[Kind]*template.Template
+	text  *template.Template
+	html  *htmltemplate.Template
+}
+
+var funcs = template.FuncMap{
+	"date": func(t time.Time) string { return t.Format("2 January 2006") },
+}
+
+func NewRenderer() (*Renderer, error) {
+	r := &Renderer{kinds: make(map[Kind]*template.Template, len(Kinds))}
+
+	for _, kind := range Kinds {
+		tmpl, err := template.New(string(kind)).Funcs(funcs).ParseFS(templates, "templates/"+string(kind)+".tmpl")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s template: %w", kind, err)
+		}
+		r.kinds[kind] = tmpl
+	}
+
+	var err error
+	if r.text, err = template.ParseFS(templates, "templates/layout.txt.tmpl"); err != nil {
+		return nil, fmt.Errorf("failed to parse text layout: %w", err)
+	}
+	if r.html, err = htmltemplate.ParseFS(templates, "templates/layout.html.tmpl"); err != nil {
+		return nil, fmt.Errorf("failed to parse html layout: %w", err)
+	}
+
+	return r, nil
+}
+
+func (r *Renderer) Render(n *Notification) (*Content, error) {
+	tmpl, ok := r.kinds[n.Kind]
+	if !ok {
+		return nil, fmt.Errorf("no template for notification kind %q", n.Kind)
+	}
+
+	var c Content
+	for name, out := range map[string]*string{"subject": &c.Subject, "body": &c.Body, "sms": &c.SMS} {
+		var buf bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&buf, name, n); err != nil {
+			return nil, fmt.Errorf("failed to render %s %s: %w", n.Kind, name, err)
+		}
+		*out = strings.TrimSpace(buf.String())
+	}
+
+	layout := struct {
+		Name         string
+		Body         string
+		Paragraphs   []string
+		AgreementURL string
+	}{
+		Name:         n.Recipient.Name,
+		Body:         c.Body,
+		Paragraphs:   strings.Split(c.Body, "\n\n"),
+		AgreementURL: n.Data.AgreementURL,
+	}
+
+	var text, html bytes.Buffer
+	if err := r.text.Execute(&text, layout); err != nil {
+		return nil, fmt.Errorf("failed to render %s text: %w", n.Kind, err)
+	}
+	if err := r.html.Execute(&html, layout); err != nil {
+		return nil, fmt.Errorf("failed to render %s html: %w", n.Kind, err)
+	}
+	c.Text = text.String()
+	c.HTML = html.String()
+
+	return &c, nil
+}
@@ -0,0 +1,120 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Handler delivers one message. Returning an error schedules a retry unless
+// the error is wrapped with Permanent.
+type Handler func(ctx context.Context, message *Message) error
+
+type Config struct {
+	// BatchSize is how many messages one DispatchDue delivers at most
+	BatchSize   int
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// Lease is how long a claimed message is hidden from other dispatchers
+	// and bounds how long a handler may run
+	Lease time.Duration
+}
+
+// Dispatcher delivers due outbox messages to the handler registered for their
+// topic, retrying failures with exponential backoff
+type Dispatcher struct {
+	repo     Repository
+	handlers map[string]Handler
+	cfg      Config
+}
+
+func NewDispatcher(repo Repository, cfg Config) *Dispatcher {
+	return &Dispatcher{
+		repo:     repo,
+		handlers: make(map[string]Handler),
+		cfg:      cfg,
+	}
+}
+
+func (d *Dispatcher) Handle(topic string, handler Handler) {
+	d.handlers[topic] = handler
+}
+
+// Backoff returns the delay before the next attempt after the given number of
+// failed attempts: BaseBackoff doubled per attempt, capped at MaxBackoff
+func (d *Dispatcher) Backoff(attempts int) time.Duration {
+	backoff := d.cfg.BaseBackoff
+	for i := 1; i < attempts && backoff < d.cfg.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, d.cfg.MaxBackoff)
+}
+
+// DispatchDue delivers up to BatchSize messages due at now and returns how
+// many were delivered. Messages are claimed one at a time, just before their
+// handler runs, so a lease only has to cover a single delivery and cannot
+// lapse while earlier messages of the batch are still being delivered.
+func (d *Dispatcher) DispatchDue(ctx context.Context, now time.Time) (int, error) {
+	start := time.Now()
+	delivered := 0
+	var errs []error
+	for i := 0; i < d.cfg.BatchSize; i++ {
+		messages, err := d.repo.ClaimDue(ctx, now.Add(time.Since(start)), d.cfg.Lease, 1)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to claim outbox message: %w", err))
+			break
+		}
+		if len(messages) == 0 {
+			break
+		}
+
+		message := messages[0]
+		if err := d.deliver(ctx, message); err != nil {
+			message.MarkFailed(err, time.Now(), d.Backoff(message.Attempts+1), d.cfg.MaxAttempts)
+			if message.Status == StatusDead {
+				log.Printf("outbox: dead-lettered %s message %s after %d attempt(s): %v", message.Topic, message.ID, message.Attempts, err)
+			}
+		} else {
+			message.MarkDelivered(time.Now())
+			delivered++
+		}
+
+		if err := d.repo.Update(ctx, message); err != nil {
+			errs = append(errs, fmt.Errorf("failed to update outbox message %s: %w", message.ID, err))
+		}
+	}
+
+	return delivered, errors.Join(errs...)
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, message *Message) error {
+	handler, ok := d.handlers[message.Topic]
+	if !ok {
+		return Permanent(fmt.Errorf("no handler for topic %q", message.Topic))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.cfg.Lease)
+	defer cancel()
+
+	return handler(ctx, message)
+}
+
+// Run dispatches due messages every interval until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := d.DispatchDue(ctx, now); err != nil {
+				log.Printf("outbox dispatcher: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusDelivered Status = "delivered"
+	StatusDead      Status = "dead"
+)
+
+// Message is a side effect recorded in the same transaction as the business
+// change that caused it and delivered later by the Dispatcher
+type Message struct {
+	ID            uuid.UUID
+	Topic         string
+	Payload       json.RawMessage
+	Status        Status
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     *string
+	CreatedAt     time.Time
+	DeliveredAt   *time.Time
+}
+
+func NewMessage(topic string, payload any) (*Message, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s payload: %w", topic, err)
+	}
+
+	now := time.Now()
+	return &Message{
+		ID:            uuid.New(),
+		Topic:         topic,
+		Payload:       data,
+		Status:        StatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}, nil
+}
+
+// Decode unmarshals the payload into v
+func (m *Message) Decode(v any) error {
+	if err := json.Unmarshal(m.Payload, v); err != nil {
+		return Permanent(fmt.Errorf("failed to decode %s payload: %w", m.Topic, err))
+	}
+	return nil
+}
+
+func (m *Message) MarkDelivered(at time.Time) {
+	m.Attempts++
+	m.Status = StatusDelivered
+	m.DeliveredAt = &at
+	m.LastError = nil
+}
+
+// MarkFailed records a failed attempt and schedules a retry after backoff, or
+// moves the message to the dead-letter status once it is out of attempts or
+// the error is permanent
+func (m *Message) MarkFailed(err error, at time.Time, backoff time.Duration, maxAttempts int) {
+	m.Attempts++
+	msg := err.Error()
+	m.LastError = &msg
+
+	if IsPermanent(err) || m.Attempts >= maxAttempts {
+		m.Status = StatusDead
+		return
+	}
+	m.NextAttemptAt = at.Add(backoff)
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying; the message is dead-lettered
+// straight away
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+type Repository interface {
+	Create(ctx context.Context, messages []*Message) error
+	// ClaimDue returns up to limit pending messages due at now and pushes
+	// their next attempt out by lease so no other dispatcher picks them up
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*Message, error)
+	Update(ctx context.Context, message *Message) error
+}
+
+// Outbox records messages. Publishing joins the caller's transaction when ctx
+// carries one, so a message exists if and only if the change that caused it
+// was committed.
+type Outbox struct {
+	repo Repository
+}
+
+func New(repo Repository) *Outbox {
+	return &Outbox{repo: repo}
+}
+
+func (o *Outbox) Publish(ctx context.Context, topic string, payload any) error {
+	message, err := NewMessage(topic, payload)
+	if err != nil {
+		return err
+	}
+	return o.repo.Create(ctx, []*Message{message})
+}
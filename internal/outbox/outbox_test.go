@@ -0,0 +1,155 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRepo claims messages the way OutboxRepository does: a claimed message
+// is hidden from other dispatchers until its lease runs out
+type memoryRepo struct {
+	mu       sync.Mutex
+	messages map[uuid.UUID]Message
+}
+
+func newMemoryRepo() *memoryRepo {
+	return &memoryRepo{messages: make(map[uuid.UUID]Message)}
+}
+
+func (r *memoryRepo) Create(ctx context.Context, messages []*Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range messages {
+		r.messages[m.ID] = *m
+	}
+	return nil
+}
+
+func (r *memoryRepo) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var due []Message
+	for _, m := range r.messages {
+		if m.Status == StatusPending && !m.NextAttemptAt.After(now) {
+			due = append(due, m)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].NextAttemptAt.Before(due[j].NextAttemptAt) })
+
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	claimed := make([]*Message, len(due))
+	for i := range due {
+		due[i].NextAttemptAt = now.Add(lease)
+		r.messages[due[i].ID] = due[i]
+		claimed[i] = &due[i]
+	}
+	return claimed, nil
+}
+
+func (r *memoryRepo) Update(ctx context.Context, message *Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages[message.ID] = *message
+	return nil
+}
+
+func TestBackoff(t *testing.T) {
+	d := NewDispatcher(nil, Config{BaseBackoff: 30 * time.Second, MaxBackoff: 5 * time.Minute})
+
+	assert.Equal(t, 30*time.Second, d.Backoff(1))
+	assert.Equal(t, time.Minute, d.Backoff(2))
+	assert.Equal(t, 4*time.Minute, d.Backoff(4))
+	assert.Equal(t, 5*time.Minute, d.Backoff(5))
+	assert.Equal(t, 5*time.Minute, d.Backoff(50))
+}
+
+func TestMarkFailed(t *testing.T) {
+	now := time.Now()
+
+	t.Run("schedules a retry", func(t *testing.T) {
+		m := &Message{Status: StatusPending}
+		m.MarkFailed(errors.New("timeout"), now, time.Minute, 3)
+
+		assert.Equal(t, StatusPending, m.Status)
+		assert.Equal(t, 1, m.Attempts)
+		assert.Equal(t, now.Add(time.Minute), m.NextAttemptAt)
+		assert.Equal(t, "timeout", *m.LastError)
+	})
+
+	t.Run("dead-letters after the last attempt", func(t *testing.T) {
+		m := &Message{Status: StatusPending, Attempts: 2}
+		m.MarkFailed(errors.New("timeout"), now, time.Minute, 3)
+
+		assert.Equal(t, StatusDead, m.Status)
+		assert.Equal(t, 3, m.Attempts)
+	})
+
+	t.Run("dead-letters permanent errors straight away", func(t *testing.T) {
+		m := &Message{Status: StatusPending}
+		m.MarkFailed(Permanent(errors.New("bad payload")), now, time.Minute, 3)
+
+		assert.Equal(t, StatusDead, m.Status)
+	})
+}
+
+func TestDispatchDue_TwoDispatchersDeliverEachMessageOnce(t *testing.T) {
+	repo := newMemoryRepo()
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		m, err := NewMessage("email", i)
+		require.NoError(t, err)
+		m.NextAttemptAt = start.Add(time.Duration(i) * time.Millisecond)
+		require.NoError(t, repo.Create(context.Background(), []*Message{m}))
+	}
+
+	var mu sync.Mutex
+	deliveries := make(map[uuid.UUID]int)
+	// every delivery takes most of the lease, so a batch claimed up front
+	// would outlive its lease long before it is delivered
+	cfg := Config{BatchSize: 50, MaxAttempts: 3, BaseBackoff: time.Minute, MaxBackoff: time.Minute, Lease: 100 * time.Millisecond}
+	handler := func(ctx context.Context, message *Message) error {
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		deliveries[message.ID]++
+		mu.Unlock()
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	total := make([]int, 2)
+	for i := range total {
+		d := NewDispatcher(repo, cfg)
+		d.Handle("email", handler)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// keep polling the way Run does until nothing is left
+			for time.Since(start) < 500*time.Millisecond {
+				n, err := d.DispatchDue(context.Background(), time.Now())
+				assert.NoError(t, err)
+				total[i] += n
+				time.Sleep(5 * time.Millisecond)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Len(t, deliveries, 6)
+	for id, n := range deliveries {
+		assert.Equal(t, 1, n, "message %s", id)
+	}
+	assert.Equal(t, 6, total[0]+total[1])
+	assert.Positive(t, total[0])
+	assert.Positive(t, total[1])
+}
@@ -27,7 +27,7 @@ func (r *ApprovalRepository) Create(ctx context.Context, approval *domain.LoanAp
 		VALUES ($1, $2, $3, $4, $5)
 	`
 
-	_, err := r.db.Exec(ctx, query,
+	_, err := conn(ctx, r.db).Exec(ctx, query,
 		approval.LoanID,
 		approval.EmployeeID,
 		approval.PictureProof,
@@ -47,7 +47,7 @@ func (r *ApprovalRepository) GetByLoanID(ctx context.Context, loanID uuid.UUID)
 	`
 
 	var approval domain.LoanApproval
-	err := r.db.QueryRow(ctx, query, loanID).Scan(
+	err := conn(ctx, r.db).QueryRow(ctx, query, loanID).Scan(
 		&approval.LoanID,
 		&approval.EmployeeID,
 		&approval.PictureProof,
@@ -56,7 +56,7 @@ func (r *ApprovalRepository) GetByLoanID(ctx context.Context, loanID uuid.UUID)
 	)
 
 	if err == pgx.ErrNoRows {
-		return nil, fmt.Errorf("approval not found: %w", err)
+		return nil, fmt.Errorf("%w: loan %s", domain.ErrApprovalNotFound, loanID)
 	}
 	if err != nil {
 		return nil, err
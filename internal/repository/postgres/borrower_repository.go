@@ -0,0 +1,142 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mungkiice/-loan-service/internal/domain"
+)
+
+const borrowerColumns = `id, user_id, name, national_id, phone, address, date_of_birth, kyc_status, kyc_reviewed_by, kyc_reviewed_at, created_at, updated_at`
+
+// BorrowerRepository implements domain.BorrowerRepository using PostgreSQL
+type BorrowerRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewBorrowerRepository creates a new borrower repository
+func NewBorrowerRepository(db *pgxpool.Pool) *BorrowerRepository {
+	return &BorrowerRepository{db: db}
+}
+
+// Create inserts a new borrower
+func (r *BorrowerRepository) Create(ctx context.Context, borrower *domain.Borrower) error {
+	query := `
+		INSERT INTO borrowers (` + borrowerColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+
+	_, err := conn(ctx, r.db).Exec(ctx, query,
+		borrower.ID,
+		borrower.UserID,
+		borrower.Name,
+		borrower.NationalID,
+		borrower.Phone,
+		borrower.Address,
+		borrower.DateOfBirth,
+		borrower.KYCStatus,
+		borrower.KYCReviewedBy,
+		borrower.KYCReviewedAt,
+		borrower.CreatedAt,
+		borrower.UpdatedAt,
+	)
+
+	return err
+}
+
+// GetByID retrieves a borrower by ID
+func (r *BorrowerRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Borrower, error) {
+	query := `
+		SELECT ` + borrowerColumns + `
+		FROM borrowers
+		WHERE id = $1
+	`
+
+	return r.get(ctx, query, id)
+}
+
+// GetByUserID retrieves the borrower linked to a login
+func (r *BorrowerRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*domain.Borrower, error) {
+	query := `
+		SELECT ` + borrowerColumns + `
+		FROM borrowers
+		WHERE user_id = $1
+	`
+
+	return r.get(ctx, query, userID)
+}
+
+// Update saves the borrower's profile and KYC review
+func (r *BorrowerRepository) Update(ctx context.Context, borrower *domain.Borrower) error {
+	query := `
+		UPDATE borrowers
+		SET name = $2, national_id = $3, phone = $4, address = $5, date_of_birth = $6,
+			kyc_status = $7, kyc_reviewed_by = $8, kyc_reviewed_at = $9, updated_at = $10
+		WHERE id = $1
+	`
+
+	_, err := conn(ctx, r.db).Exec(ctx, query,
+		borrower.ID,
+		borrower.Name,
+		borrower.NationalID,
+		borrower.Phone,
+		borrower.Address,
+		borrower.DateOfBirth,
+		borrower.KYCStatus,
+		borrower.KYCReviewedBy,
+		borrower.KYCReviewedAt,
+		borrower.UpdatedAt,
+	)
+
+	return err
+}
+
+func (r *BorrowerRepository) get(ctx context.Context, query string, args ...any) (*domain.Borrower, error) {
+	var borrower domain.Borrower
+	var nationalID, phone, address sql.NullString
+	var dateOfBirth, reviewedAt sql.NullTime
+
+	err := conn(ctx, r.db).QueryRow(ctx, query, args...).Scan(
+		&borrower.ID,
+		&borrower.UserID,
+		&borrower.Name,
+		&nationalID,
+		&phone,
+		&address,
+		&dateOfBirth,
+		&borrower.KYCStatus,
+		&borrower.KYCReviewedBy,
+		&reviewedAt,
+		&borrower.CreatedAt,
+		&borrower.UpdatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("%w: %w", domain.ErrBorrowerNotFound, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if nationalID.Valid {
+		borrower.NationalID = &nationalID.String
+	}
+	if phone.Valid {
+		borrower.Phone = &phone.String
+	}
+	if address.Valid {
+		borrower.Address = &address.String
+	}
+	if dateOfBirth.Valid {
+		borrower.DateOfBirth = &dateOfBirth.Time
+	}
+	if reviewedAt.Valid {
+		borrower.KYCReviewedAt = &reviewedAt.Time
+	}
+
+	return &borrower, nil
+}
@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mungkiice/-loan-service/internal/domain"
+)
+
+// ClosureRepository implements domain.ClosureRepository using PostgreSQL
+type ClosureRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewClosureRepository creates a new closure repository
+func NewClosureRepository(db *pgxpool.Pool) *ClosureRepository {
+	return &ClosureRepository{db: db}
+}
+
+// Create inserts the rejection or cancellation record of a loan
+func (r *ClosureRepository) Create(ctx context.Context, closure *domain.LoanClosure) error {
+	query := `
+		INSERT INTO loan_closures (loan_id, state, reason, note, employee_id, closed_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := conn(ctx, r.db).Exec(ctx, query,
+		closure.LoanID,
+		closure.State,
+		closure.Reason,
+		closure.Note,
+		closure.EmployeeID,
+		closure.ClosedAt,
+		closure.CreatedAt,
+	)
+
+	return err
+}
+
+// GetByLoanID retrieves the closure record of a loan
+func (r *ClosureRepository) GetByLoanID(ctx context.Context, loanID uuid.UUID) (*domain.LoanClosure, error) {
+	query := `
+		SELECT loan_id, state, reason, note, employee_id, closed_at, created_at
+		FROM loan_closures
+		WHERE loan_id = $1
+	`
+
+	var closure domain.LoanClosure
+	err := conn(ctx, r.db).QueryRow(ctx, query, loanID).Scan(
+		&closure.LoanID,
+		&closure.State,
+		&closure.Reason,
+		&closure.Note,
+		&closure.EmployeeID,
+		&closure.ClosedAt,
+		&closure.CreatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("closure not found: %w", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &closure, nil
+}
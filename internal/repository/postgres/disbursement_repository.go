@@ -27,7 +27,7 @@ func (r *DisbursementRepository) Create(ctx context.Context, disbursement *domai
 		VALUES ($1, $2, $3, $4, $5)
 	`
 
-	_, err := r.db.Exec(ctx, query,
+	_, err := conn(ctx, r.db).Exec(ctx, query,
 		disbursement.LoanID,
 		disbursement.EmployeeID,
 		disbursement.SignedAgreementURL,
@@ -47,7 +47,7 @@ func (r *DisbursementRepository) GetByLoanID(ctx context.Context, loanID uuid.UU
 	`
 
 	var disbursement domain.Disbursement
-	err := r.db.QueryRow(ctx, query, loanID).Scan(
+	err := conn(ctx, r.db).QueryRow(ctx, query, loanID).Scan(
 		&disbursement.LoanID,
 		&disbursement.EmployeeID,
 		&disbursement.SignedAgreementURL,
@@ -56,7 +56,7 @@ func (r *DisbursementRepository) GetByLoanID(ctx context.Context, loanID uuid.UU
 	)
 
 	if err == pgx.ErrNoRows {
-		return nil, fmt.Errorf("disbursement not found: %w", err)
+		return nil, fmt.Errorf("%w: loan %s", domain.ErrDisbursementNotFound, loanID)
 	}
 	if err != nil {
 		return nil, err
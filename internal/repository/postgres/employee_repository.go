@@ -27,7 +27,7 @@ func (r *EmployeeRepository) Create(ctx context.Context, employee *domain.Employ
 		VALUES ($1, $2, $3, $4, $5)
 	`
 
-	_, err := r.db.Exec(ctx, query,
+	_, err := conn(ctx, r.db).Exec(ctx, query,
 		employee.ID,
 		employee.Name,
 		employee.Role,
@@ -47,7 +47,7 @@ func (r *EmployeeRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain
 	`
 
 	var employee domain.Employee
-	err := r.db.QueryRow(ctx, query, id).Scan(
+	err := conn(ctx, r.db).QueryRow(ctx, query, id).Scan(
 		&employee.ID,
 		&employee.Name,
 		&employee.Role,
@@ -56,7 +56,7 @@ func (r *EmployeeRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain
 	)
 
 	if err == pgx.ErrNoRows {
-		return nil, fmt.Errorf("employee not found: %w", err)
+		return nil, fmt.Errorf("%w: %w", domain.ErrEmployeeNotFound, err)
 	}
 	if err != nil {
 		return nil, err
@@ -76,7 +76,7 @@ func (r *EmployeeRepository) GetByUserID(ctx context.Context, userID uuid.UUID)
 	`
 
 	var employee domain.Employee
-	err := r.db.QueryRow(ctx, query, userID).Scan(
+	err := conn(ctx, r.db).QueryRow(ctx, query, userID).Scan(
 		&employee.ID,
 		&employee.Name,
 		&employee.Role,
@@ -85,7 +85,7 @@ func (r *EmployeeRepository) GetByUserID(ctx context.Context, userID uuid.UUID)
 	)
 
 	if err == pgx.ErrNoRows {
-		return nil, fmt.Errorf("employee not found: %w", err)
+		return nil, fmt.Errorf("%w: %w", domain.ErrEmployeeNotFound, err)
 	}
 	if err != nil {
 		return nil, err
@@ -104,7 +104,7 @@ func (r *EmployeeRepository) GetAll(ctx context.Context) ([]*domain.Employee, er
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.Query(ctx, query)
+	rows, err := conn(ctx, r.db).Query(ctx, query)
 	if err != nil {
 		return nil, err
 	}
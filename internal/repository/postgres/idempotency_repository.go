@@ -0,0 +1,127 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mungkiice/-loan-service/internal/idempotency"
+)
+
+// reserveAttempts bounds how often Reserve retries when the key it conflicted
+// with is released before it can be read
+const reserveAttempts = 3
+
+// IdempotencyRepository implements idempotency.Store using PostgreSQL.
+// It always uses the pool, never the caller's transaction, so a reservation
+// is visible to concurrent requests as soon as it is made.
+type IdempotencyRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewIdempotencyRepository creates a new idempotency repository
+func NewIdempotencyRepository(db *pgxpool.Pool) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Reserve inserts the record, taking over an existing one whose lease or
+// retention has ended
+func (r *IdempotencyRepository) Reserve(ctx context.Context, record *idempotency.Record) (bool, *idempotency.Record, error) {
+	insert := `
+		INSERT INTO idempotency_keys (key, fingerprint, token, status, response, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint,
+			token = EXCLUDED.token,
+			status = EXCLUDED.status,
+			response = EXCLUDED.response,
+			expires_at = EXCLUDED.expires_at,
+			created_at = EXCLUDED.created_at
+		WHERE idempotency_keys.expires_at <= EXCLUDED.created_at
+	`
+	get := `
+		SELECT key, fingerprint, status, response, expires_at, created_at
+		FROM idempotency_keys
+		WHERE key = $1
+	`
+
+	for i := 0; i < reserveAttempts; i++ {
+		tag, err := r.db.Exec(ctx, insert,
+			record.Key,
+			record.Fingerprint,
+			record.Token,
+			record.Status,
+			record.Response,
+			record.ExpiresAt,
+			record.CreatedAt,
+		)
+		if err != nil {
+			return false, nil, err
+		}
+		if tag.RowsAffected() == 1 {
+			return true, nil, nil
+		}
+
+		var existing idempotency.Record
+		err = r.db.QueryRow(ctx, get, record.Key).Scan(
+			&existing.Key,
+			&existing.Fingerprint,
+			&existing.Status,
+			&existing.Response,
+			&existing.ExpiresAt,
+			&existing.CreatedAt,
+		)
+		if errors.Is(err, pgx.ErrNoRows) {
+			// released in the meantime
+			continue
+		}
+		if err != nil {
+			return false, nil, err
+		}
+		return false, &existing, nil
+	}
+
+	return false, nil, fmt.Errorf("idempotency key %s is contended", record.Key)
+}
+
+// Renew extends the lease of a key still in progress under token
+func (r *IdempotencyRepository) Renew(ctx context.Context, key, token string, expiresAt time.Time) (bool, error) {
+	query := `
+		UPDATE idempotency_keys
+		SET expires_at = $3
+		WHERE key = $1 AND token = $2 AND status = $4
+	`
+
+	tag, err := r.db.Exec(ctx, query, key, token, expiresAt, idempotency.StatusInProgress)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// Complete stores the response of a key in progress under token
+func (r *IdempotencyRepository) Complete(ctx context.Context, key, token string, response json.RawMessage, expiresAt time.Time) error {
+	query := `
+		UPDATE idempotency_keys
+		SET status = $3, response = $4, expires_at = $5
+		WHERE key = $1 AND token = $2 AND status = $6
+	`
+
+	_, err := r.db.Exec(ctx, query, key, token, idempotency.StatusCompleted, response, expiresAt, idempotency.StatusInProgress)
+	return err
+}
+
+// Release deletes a key in progress under token
+func (r *IdempotencyRepository) Release(ctx context.Context, key, token string) error {
+	query := `
+		DELETE FROM idempotency_keys
+		WHERE key = $1 AND token = $2 AND status = $3
+	`
+
+	_, err := r.db.Exec(ctx, query, key, token, idempotency.StatusInProgress)
+	return err
+}
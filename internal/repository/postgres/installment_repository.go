@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mungkiice/-loan-service/internal/domain"
+)
+
+// InstallmentRepository implements domain.InstallmentRepository using PostgreSQL
+type InstallmentRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewInstallmentRepository creates a new installment repository
+func NewInstallmentRepository(db *pgxpool.Pool) *InstallmentRepository {
+	return &InstallmentRepository{db: db}
+}
+
+// CreateBatch inserts a loan's repayment schedule
+func (r *InstallmentRepository) CreateBatch(ctx context.Context, installments []*domain.Installment) error {
+	query := `
+		INSERT INTO installments (id, loan_id, sequence, due_date, principal_due, interest_due, principal_paid, interest_paid, currency, status, paid_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`
+
+	batch := &pgx.Batch{}
+	for _, inst := range installments {
+		batch.Queue(query,
+			inst.ID,
+			inst.LoanID,
+			inst.Sequence,
+			inst.DueDate,
+			inst.PrincipalDue,
+			inst.InterestDue,
+			inst.PrincipalPaid,
+			inst.InterestPaid,
+			inst.PrincipalDue.Currency,
+			inst.Status,
+			inst.PaidAt,
+			inst.CreatedAt,
+			inst.UpdatedAt,
+		)
+	}
+
+	return sendBatch(ctx, conn(ctx, r.db), batch)
+}
+
+// GetByLoanID retrieves a loan's installments ordered by sequence
+func (r *InstallmentRepository) GetByLoanID(ctx context.Context, loanID uuid.UUID) ([]*domain.Installment, error) {
+	query := `
+		SELECT id, loan_id, sequence, due_date, principal_due, interest_due, principal_paid, interest_paid, currency, status, paid_at, created_at, updated_at
+		FROM installments
+		WHERE loan_id = $1
+		ORDER BY sequence ASC
+	`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, loanID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	installments := make([]*domain.Installment, 0)
+	for rows.Next() {
+		var inst domain.Installment
+		var currency domain.Currency
+		var paidAt sql.NullTime
+
+		if err := rows.Scan(
+			&inst.ID,
+			&inst.LoanID,
+			&inst.Sequence,
+			&inst.DueDate,
+			&inst.PrincipalDue,
+			&inst.InterestDue,
+			&inst.PrincipalPaid,
+			&inst.InterestPaid,
+			&currency,
+			&inst.Status,
+			&paidAt,
+			&inst.CreatedAt,
+			&inst.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		inst.PrincipalDue.Currency = currency
+		inst.InterestDue.Currency = currency
+		inst.PrincipalPaid.Currency = currency
+		inst.InterestPaid.Currency = currency
+		if paidAt.Valid {
+			inst.PaidAt = &paidAt.Time
+		}
+
+		installments = append(installments, &inst)
+	}
+
+	return installments, rows.Err()
+}
+
+// Update stores the paid amounts and status of an installment
+func (r *InstallmentRepository) Update(ctx context.Context, installment *domain.Installment) error {
+	query := `
+		UPDATE installments
+		SET principal_paid = $2, interest_paid = $3, status = $4, paid_at = $5, updated_at = $6
+		WHERE id = $1
+	`
+
+	_, err := conn(ctx, r.db).Exec(ctx, query,
+		installment.ID,
+		installment.PrincipalPaid,
+		installment.InterestPaid,
+		installment.Status,
+		installment.PaidAt,
+		installment.UpdatedAt,
+	)
+
+	return err
+}
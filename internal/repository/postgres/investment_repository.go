@@ -2,13 +2,18 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/mungkiice/-loan-service/internal/domain"
 )
 
+const investmentColumns = `id, loan_id, investor_id, amount, currency, agreement_letter_url, placed_by, created_at, voided_at`
+
 // InvestmentRepository implements domain.InvestmentRepository using PostgreSQL
 type InvestmentRepository struct {
 	db *pgxpool.Pool
@@ -19,34 +24,74 @@ func NewInvestmentRepository(db *pgxpool.Pool) *InvestmentRepository {
 	return &InvestmentRepository{db: db}
 }
 
-// Create inserts a new investment
+// Create inserts a new investment and adds it to the loan's funded amount.
+// A loan that is no longer approved is rejected with domain.ErrLoanNotOpen,
+// and the database rejects a funded amount past the principal with
+// domain.ErrOverInvestment. The loan row stays locked
+// until the transaction ends, so concurrent investments queue up behind it.
 func (r *InvestmentRepository) Create(ctx context.Context, investment *domain.Investment) error {
 	query := `
-		INSERT INTO investments (id, loan_id, investor_id, amount, created_at)
-		VALUES ($1, $2, $3, $4, $5)
+		WITH funded AS (
+			UPDATE loans
+			SET funded_amount = funded_amount + $4
+			WHERE id = $2 AND state = 'approved'
+			RETURNING id
+		)
+		INSERT INTO investments (id, loan_id, investor_id, amount, currency, agreement_letter_url, placed_by, created_at)
+		SELECT $1, funded.id, $3, $4, $5, $6, $7, $8
+		FROM funded
 	`
 
-	_, err := r.db.Exec(ctx, query,
+	tag, err := conn(ctx, r.db).Exec(ctx, query,
 		investment.ID,
 		investment.LoanID,
 		investment.InvestorID,
 		investment.Amount,
+		investment.Amount.Currency,
+		investment.AgreementLetterURL,
+		investment.PlacedBy,
 		investment.CreatedAt,
 	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.ConstraintName == "chk_loans_funded_amount" {
+		return fmt.Errorf("%w: loan %s", domain.ErrOverInvestment, investment.LoanID)
+	}
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: loan %s", domain.ErrLoanNotOpen, investment.LoanID)
+	}
 
-	return err
+	return nil
 }
 
-// GetByLoanID retrieves all investments for a loan
+// GetByLoanID retrieves all active investments for a loan
 func (r *InvestmentRepository) GetByLoanID(ctx context.Context, loanID uuid.UUID) ([]*domain.Investment, error) {
 	query := `
-		SELECT id, loan_id, investor_id, amount, created_at
+		SELECT ` + investmentColumns + `
+		FROM investments
+		WHERE loan_id = $1 AND voided_at IS NULL
+		ORDER BY created_at ASC
+	`
+
+	return r.query(ctx, query, loanID)
+}
+
+// GetByInvestorID retrieves all active investments made by an investor
+func (r *InvestmentRepository) GetByInvestorID(ctx context.Context, investorID uuid.UUID) ([]*domain.Investment, error) {
+	query := `
+		SELECT ` + investmentColumns + `
 		FROM investments
-		WHERE loan_id = $1
+		WHERE investor_id = $1 AND voided_at IS NULL
 		ORDER BY created_at ASC
 	`
 
-	rows, err := r.db.Query(ctx, query, loanID)
+	return r.query(ctx, query, investorID)
+}
+
+func (r *InvestmentRepository) query(ctx context.Context, query string, args ...any) ([]*domain.Investment, error) {
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -60,7 +105,11 @@ func (r *InvestmentRepository) GetByLoanID(ctx context.Context, loanID uuid.UUID
 			&investment.LoanID,
 			&investment.InvestorID,
 			&investment.Amount,
+			&investment.Amount.Currency,
+			&investment.AgreementLetterURL,
+			&investment.PlacedBy,
 			&investment.CreatedAt,
+			&investment.VoidedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -70,18 +119,53 @@ func (r *InvestmentRepository) GetByLoanID(ctx context.Context, loanID uuid.UUID
 	return investments, rows.Err()
 }
 
-// GetTotalByLoanID calculates the total investment amount for a loan
-func (r *InvestmentRepository) GetTotalByLoanID(ctx context.Context, loanID uuid.UUID) (float64, error) {
+// VoidByLoanID marks every active investment in a loan as voided, clears the
+// loan's funded amount and returns the investments so the amounts can be
+// refunded
+func (r *InvestmentRepository) VoidByLoanID(ctx context.Context, loanID uuid.UUID, voidedAt time.Time) ([]*domain.Investment, error) {
 	query := `
-		SELECT COALESCE(SUM(amount), 0)
-		FROM investments
-		WHERE loan_id = $1
+		WITH voided AS (
+			UPDATE investments
+			SET voided_at = $2
+			WHERE loan_id = $1 AND voided_at IS NULL
+			RETURNING ` + investmentColumns + `
+		), unfunded AS (
+			UPDATE loans SET funded_amount = 0 WHERE id = $1
+		)
+		SELECT ` + investmentColumns + `
+		FROM voided
+	`
+
+	return r.query(ctx, query, loanID, voidedAt)
+}
+
+// SetAgreementLetterURL links an investment to its agreement letter
+func (r *InvestmentRepository) SetAgreementLetterURL(ctx context.Context, id uuid.UUID, url string) error {
+	query := `UPDATE investments SET agreement_letter_url = $2 WHERE id = $1`
+
+	tag, err := conn(ctx, r.db).Exec(ctx, query, id, url)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("investment not found: %s", id)
+	}
+
+	return nil
+}
+
+// GetTotalByLoanID returns the total active investment amount for a loan in the loan's currency
+func (r *InvestmentRepository) GetTotalByLoanID(ctx context.Context, loanID uuid.UUID) (domain.Money, error) {
+	query := `
+		SELECT funded_amount, currency
+		FROM loans
+		WHERE id = $1
 	`
 
-	var total float64
-	err := r.db.QueryRow(ctx, query, loanID).Scan(&total)
+	var total domain.Money
+	err := conn(ctx, r.db).QueryRow(ctx, query, loanID).Scan(&total, &total.Currency)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get total investment: %w", err)
+		return domain.Money{}, fmt.Errorf("failed to get total investment: %w", err)
 	}
 
 	return total, nil
@@ -0,0 +1,222 @@
+package postgres
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mungkiice/-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestDB migrates a fresh schema in the database at TEST_DATABASE_URL and
+// drops it when the test ends. Tests using it are skipped without one.
+func newTestDB(t *testing.T) *pgxpool.Pool {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	ctx := context.Background()
+	schema := "test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+
+	admin, err := pgxpool.New(ctx, dsn)
+	require.NoError(t, err)
+	t.Cleanup(admin.Close)
+	_, err = admin.Exec(ctx, "CREATE SCHEMA "+schema)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		admin.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+	})
+
+	config, err := pgxpool.ParseConfig(dsn)
+	require.NoError(t, err)
+	config.ConnConfig.RuntimeParams["search_path"] = schema
+	config.MaxConns = 20
+	db, err := pgxpool.NewWithConfig(ctx, config)
+	require.NoError(t, err)
+	t.Cleanup(db.Close)
+
+	migrations, err := filepath.Glob(filepath.Join("..", "..", "..", "migrations", "*.up.sql"))
+	require.NoError(t, err)
+	sort.Strings(migrations)
+	for _, path := range migrations {
+		sql, err := os.ReadFile(path)
+		require.NoError(t, err)
+		for _, stmt := range migrationStatements(string(sql)) {
+			_, err = db.Exec(ctx, stmt)
+			require.NoError(t, err, "migration %s", filepath.Base(path))
+		}
+	}
+
+	return db
+}
+
+var addEnumValue = regexp.MustCompile(`(?im)^\s*ALTER TYPE\s+\S+\s+ADD VALUE[^;]*;`)
+
+// migrationStatements splits a migration into what to run one Exec at a time.
+// A multi-statement Exec runs as one transaction, and Postgres does not let a
+// transaction use an enum value it added, so each ALTER TYPE ... ADD VALUE
+// runs on its own first, as it does under psql -f. The types they extend all
+// come from earlier migrations.
+func migrationStatements(sql string) []string {
+	var stmts []string
+	for _, stmt := range addEnumValue.FindAllString(sql, -1) {
+		stmts = append(stmts, strings.TrimSpace(stmt))
+	}
+	if rest := strings.TrimSpace(addEnumValue.ReplaceAllString(sql, "")); rest != "" {
+		stmts = append(stmts, rest)
+	}
+	return stmts
+}
+
+// Every investor reads the total and validates their amount without the Redis
+// lock, as if Redis were down; the database alone must keep the loan from
+// being overfunded.
+func TestInvestmentRepository_ConcurrentInvestorsCannotOverfundLoan(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	txManager := NewTxManager(db)
+	loans := NewLoanRepository(db)
+	investments := NewInvestmentRepository(db)
+
+	borrower := domain.NewBorrower(nil, "Borrower", nil, nil, nil, nil)
+	require.NoError(t, NewBorrowerRepository(db).Create(ctx, borrower))
+	loan := domain.NewLoan(borrower.ID, domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+	loan.State = domain.StateApproved
+	require.NoError(t, loans.Create(ctx, loan))
+
+	const investors = 50
+	amount := domain.NewMoney(100000, domain.CurrencyIDR)
+
+	var wg sync.WaitGroup
+	errs := make([]error, investors)
+	start := make(chan struct{})
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			errs[i] = txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+				total, err := investments.GetTotalByLoanID(ctx, loan.ID)
+				if err != nil {
+					return err
+				}
+				if err := loan.ValidateInvestmentAmount(amount, total); err != nil {
+					return err
+				}
+				return investments.Create(ctx, &domain.Investment{
+					ID:         uuid.New(),
+					LoanID:     loan.ID,
+					InvestorID: uuid.New(),
+					Amount:     amount,
+					CreatedAt:  time.Now(),
+				})
+			})
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assert.ErrorIs(t, err, domain.ErrOverInvestment)
+	}
+	assert.Equal(t, 10, succeeded)
+
+	funded, err := investments.GetTotalByLoanID(ctx, loan.ID)
+	require.NoError(t, err)
+	assert.Equal(t, loan.PrincipalAmount, funded)
+
+	active, err := investments.GetByLoanID(ctx, loan.ID)
+	require.NoError(t, err)
+	sum := domain.NewMoney(0, domain.CurrencyIDR)
+	for _, inv := range active {
+		sum = sum.Add(inv.Amount)
+	}
+	assert.Equal(t, loan.PrincipalAmount, sum, "%d active investments", len(active))
+}
+
+func TestInvestmentRepository_RejectsLoanNotOpen(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	investments := NewInvestmentRepository(db)
+
+	borrower := domain.NewBorrower(nil, "Borrower", nil, nil, nil, nil)
+	require.NoError(t, NewBorrowerRepository(db).Create(ctx, borrower))
+	loan := domain.NewLoan(borrower.ID, domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+	require.NoError(t, NewLoanRepository(db).Create(ctx, loan))
+
+	err := investments.Create(ctx, &domain.Investment{
+		ID:         uuid.New(),
+		LoanID:     loan.ID,
+		InvestorID: uuid.New(),
+		Amount:     domain.NewMoney(400000, domain.CurrencyIDR),
+		CreatedAt:  time.Now(),
+	})
+
+	assert.ErrorIs(t, err, domain.ErrLoanNotOpen)
+}
+
+func TestInvestmentRepository_VoidByLoanIDClearsFundedAmount(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	investments := NewInvestmentRepository(db)
+
+	borrower := domain.NewBorrower(nil, "Borrower", nil, nil, nil, nil)
+	require.NoError(t, NewBorrowerRepository(db).Create(ctx, borrower))
+	loan := domain.NewLoan(borrower.ID, domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+	loan.State = domain.StateApproved
+	require.NoError(t, NewLoanRepository(db).Create(ctx, loan))
+	require.NoError(t, investments.Create(ctx, &domain.Investment{
+		ID:         uuid.New(),
+		LoanID:     loan.ID,
+		InvestorID: uuid.New(),
+		Amount:     domain.NewMoney(400000, domain.CurrencyIDR),
+		CreatedAt:  time.Now(),
+	}))
+
+	voided, err := investments.VoidByLoanID(ctx, loan.ID, time.Now())
+	require.NoError(t, err)
+	assert.Len(t, voided, 1)
+
+	funded, err := investments.GetTotalByLoanID(ctx, loan.ID)
+	require.NoError(t, err)
+	assert.True(t, funded.IsZero())
+}
+
+// Postgres refuses to use an enum value in the transaction that added it, so
+// no migration may add one in the same Exec as the rest of its statements
+func TestMigrationStatements_RunsEnumAdditionsSeparately(t *testing.T) {
+	stmts := migrationStatements("-- Add states\nALTER TYPE loan_state ADD VALUE IF NOT EXISTS 'rejected';\nALTER TYPE loan_state ADD VALUE IF NOT EXISTS 'cancelled';\n\nUPDATE loans SET state = 'rejected';\n")
+	require.Len(t, stmts, 3)
+	assert.Equal(t, "ALTER TYPE loan_state ADD VALUE IF NOT EXISTS 'rejected';", stmts[0])
+	assert.Equal(t, "ALTER TYPE loan_state ADD VALUE IF NOT EXISTS 'cancelled';", stmts[1])
+	assert.NotContains(t, stmts[2], "ADD VALUE")
+	assert.Contains(t, stmts[2], "UPDATE loans SET state = 'rejected';")
+
+	migrations, err := filepath.Glob(filepath.Join("..", "..", "..", "migrations", "*.up.sql"))
+	require.NoError(t, err)
+	for _, path := range migrations {
+		sql, err := os.ReadFile(path)
+		require.NoError(t, err)
+		for _, stmt := range migrationStatements(string(sql)) {
+			if !addEnumValue.MatchString(stmt) {
+				assert.NotContains(t, strings.ToUpper(stmt), "ADD VALUE", filepath.Base(path))
+			}
+		}
+	}
+}
@@ -28,7 +28,7 @@ func (r *InvestorRepository) Create(ctx context.Context, investor *domain.Invest
 		VALUES ($1, $2, $3, $4, $5, $6)
 	`
 
-	_, err := r.db.Exec(ctx, query,
+	_, err := conn(ctx, r.db).Exec(ctx, query,
 		investor.ID,
 		investor.Name,
 		investor.Phone,
@@ -51,7 +51,7 @@ func (r *InvestorRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain
 	var investor domain.Investor
 	var phone, address sql.NullString
 
-	err := r.db.QueryRow(ctx, query, id).Scan(
+	err := conn(ctx, r.db).QueryRow(ctx, query, id).Scan(
 		&investor.ID,
 		&investor.Name,
 		&phone,
@@ -61,7 +61,7 @@ func (r *InvestorRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain
 	)
 
 	if err == pgx.ErrNoRows {
-		return nil, fmt.Errorf("investor not found: %w", err)
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvestorNotFound, err)
 	}
 	if err != nil {
 		return nil, err
@@ -90,7 +90,7 @@ func (r *InvestorRepository) GetByUserID(ctx context.Context, userID uuid.UUID)
 	var investor domain.Investor
 	var phone, address sql.NullString
 
-	err := r.db.QueryRow(ctx, query, userID).Scan(
+	err := conn(ctx, r.db).QueryRow(ctx, query, userID).Scan(
 		&investor.ID,
 		&investor.Name,
 		&phone,
@@ -100,7 +100,7 @@ func (r *InvestorRepository) GetByUserID(ctx context.Context, userID uuid.UUID)
 	)
 
 	if err == pgx.ErrNoRows {
-		return nil, fmt.Errorf("investor not found: %w", err)
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvestorNotFound, err)
 	}
 	if err != nil {
 		return nil, err
@@ -126,7 +126,7 @@ func (r *InvestorRepository) GetAll(ctx context.Context) ([]*domain.Investor, er
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.Query(ctx, query)
+	rows, err := conn(ctx, r.db).Query(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mungkiice/-loan-service/internal/domain"
+	"github.com/mungkiice/-loan-service/internal/ledger"
+)
+
+// LedgerRepository implements ledger.Repository using PostgreSQL
+type LedgerRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewLedgerRepository creates a new ledger repository
+func NewLedgerRepository(db *pgxpool.Pool) *LedgerRepository {
+	return &LedgerRepository{db: db}
+}
+
+// Post inserts a journal entry with its lines. A deferred trigger rejects
+// unbalanced entries at commit.
+func (r *LedgerRepository) Post(ctx context.Context, entry *ledger.Entry) error {
+	db := conn(ctx, r.db)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO journal_entries (id, reference, description, posted_at)
+		VALUES ($1, $2, $3, $4)
+	`, entry.ID, entry.Reference, entry.Description, entry.PostedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert journal entry: %w", err)
+	}
+
+	query := `
+		INSERT INTO journal_lines (entry_id, account_type, owner_id, side, amount, currency)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	batch := &pgx.Batch{}
+	for _, line := range entry.Lines {
+		batch.Queue(query,
+			entry.ID,
+			line.Account.Type,
+			line.Account.OwnerID,
+			line.Side,
+			line.Amount,
+			line.Amount.Currency,
+		)
+	}
+
+	return sendBatch(ctx, db, batch)
+}
+
+// GetBalance sums the debits and credits posted to an account
+func (r *LedgerRepository) GetBalance(ctx context.Context, account ledger.Account, currency domain.Currency) (*ledger.Balance, error) {
+	query := `
+		SELECT
+			COALESCE(SUM(amount) FILTER (WHERE side = 'debit'), 0),
+			COALESCE(SUM(amount) FILTER (WHERE side = 'credit'), 0)
+		FROM journal_lines
+		WHERE account_type = $1 AND owner_id = $2 AND currency = $3
+	`
+
+	balance := &ledger.Balance{Account: account}
+	err := conn(ctx, r.db).QueryRow(ctx, query, account.Type, account.OwnerID, currency).Scan(
+		&balance.Debits,
+		&balance.Credits,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance: %w", err)
+	}
+
+	balance.Debits.Currency = currency
+	balance.Credits.Currency = currency
+
+	return balance, nil
+}
+
+// GetBalances returns the debit and credit totals of every account
+func (r *LedgerRepository) GetBalances(ctx context.Context) ([]*ledger.Balance, error) {
+	query := `
+		SELECT
+			account_type,
+			owner_id,
+			currency,
+			COALESCE(SUM(amount) FILTER (WHERE side = 'debit'), 0),
+			COALESCE(SUM(amount) FILTER (WHERE side = 'credit'), 0)
+		FROM journal_lines
+		GROUP BY account_type, owner_id, currency
+		ORDER BY account_type, owner_id, currency
+	`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	balances := make([]*ledger.Balance, 0)
+	for rows.Next() {
+		var b ledger.Balance
+		var currency domain.Currency
+		if err := rows.Scan(
+			&b.Account.Type,
+			&b.Account.OwnerID,
+			&currency,
+			&b.Debits,
+			&b.Credits,
+		); err != nil {
+			return nil, err
+		}
+		b.Debits.Currency = currency
+		b.Credits.Currency = currency
+		balances = append(balances, &b)
+	}
+
+	return balances, rows.Err()
+}
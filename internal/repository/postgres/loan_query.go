@@ -0,0 +1,118 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mungkiice/-loan-service/internal/domain"
+)
+
+// fundedPercentExpr is the share of a loan's principal invested so far
+const fundedPercentExpr = `(funded_amount * 100 / principal_amount)`
+
+// loanSortKey is the expression a sort orders by and the type its cursor
+// value is cast back to
+type loanSortKey struct {
+	expr string
+	typ  string
+}
+
+var loanSortKeys = map[domain.LoanSort]loanSortKey{
+	domain.SortByCreatedAt:     {expr: "created_at", typ: "timestamp"},
+	domain.SortByUpdatedAt:     {expr: "updated_at", typ: "timestamp"},
+	domain.SortByPrincipal:     {expr: "principal_amount", typ: "numeric"},
+	domain.SortByRate:          {expr: "rate", typ: "numeric"},
+	domain.SortByFundedPercent: {expr: fundedPercentExpr, typ: "numeric"},
+}
+
+// loanSearch collects the conditions of a loan search and numbers their
+// arguments
+type loanSearch struct {
+	conds []string
+	args  []any
+}
+
+func (s *loanSearch) arg(v any) string {
+	s.args = append(s.args, v)
+	return fmt.Sprintf("$%d", len(s.args))
+}
+
+func (s *loanSearch) where(cond string) {
+	s.conds = append(s.conds, cond)
+}
+
+// buildLoanSearch renders a normalized query as a keyset-paginated SELECT of
+// loanColumns followed by the sort value as text. It fetches one row more than
+// the limit so the caller can tell whether another page follows.
+func buildLoanSearch(q domain.LoanQuery) (string, []any, error) {
+	key, ok := loanSortKeys[q.Sort]
+	if !ok {
+		return "", nil, fmt.Errorf("invalid sort %q", q.Sort)
+	}
+
+	s := &loanSearch{}
+
+	if len(q.States) > 0 {
+		states := make([]string, len(q.States))
+		for i, state := range q.States {
+			states[i] = string(state)
+		}
+		s.where("state = ANY(" + s.arg(states) + "::text[]::loan_state[])")
+	}
+	if q.BorrowerID != nil {
+		s.where("borrower_id = " + s.arg(*q.BorrowerID))
+	}
+	if q.OpenOrInvestedBy != nil {
+		s.where("(state = 'approved' OR id IN (SELECT loan_id FROM investments WHERE investor_id = " + s.arg(*q.OpenOrInvestedBy) + " AND voided_at IS NULL))")
+	}
+	if q.Currency != "" {
+		s.where("currency = " + s.arg(q.Currency))
+	}
+	if q.MinPrincipal != nil {
+		s.where("principal_amount >= " + s.arg(*q.MinPrincipal))
+	}
+	if q.MaxPrincipal != nil {
+		s.where("principal_amount <= " + s.arg(*q.MaxPrincipal))
+	}
+	if q.MinRate != nil {
+		s.where("rate >= " + s.arg(*q.MinRate))
+	}
+	if q.MaxRate != nil {
+		s.where("rate <= " + s.arg(*q.MaxRate))
+	}
+	if q.CreatedFrom != nil {
+		s.where("created_at >= " + s.arg(*q.CreatedFrom))
+	}
+	if q.CreatedTo != nil {
+		s.where("created_at <= " + s.arg(*q.CreatedTo))
+	}
+	if q.UpdatedFrom != nil {
+		s.where("updated_at >= " + s.arg(*q.UpdatedFrom))
+	}
+	if q.UpdatedTo != nil {
+		s.where("updated_at <= " + s.arg(*q.UpdatedTo))
+	}
+	if q.MinFundedPercent != nil {
+		s.where(fundedPercentExpr + " >= " + s.arg(*q.MinFundedPercent))
+	}
+	if q.MaxFundedPercent != nil {
+		s.where(fundedPercentExpr + " <= " + s.arg(*q.MaxFundedPercent))
+	}
+
+	direction, after := "DESC", "<"
+	if q.Order == domain.SortAsc {
+		direction, after = "ASC", ">"
+	}
+	if q.After != nil {
+		s.where(fmt.Sprintf("(%s, id) %s (%s::text::%s, %s)", key.expr, after, s.arg(q.After.Value), key.typ, s.arg(q.After.ID)))
+	}
+
+	var query strings.Builder
+	query.WriteString("SELECT " + loanColumns + ", (" + key.expr + ")::text FROM loans")
+	if len(s.conds) > 0 {
+		query.WriteString(" WHERE " + strings.Join(s.conds, " AND "))
+	}
+	fmt.Fprintf(&query, " ORDER BY %s %s, id %s LIMIT %s", key.expr, direction, direction, s.arg(q.Limit+1))
+
+	return query.String(), s.args, nil
+}
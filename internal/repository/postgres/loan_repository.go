@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -11,6 +12,8 @@ import (
 	"github.com/mungkiice/-loan-service/internal/domain"
 )
 
+const loanColumns = `id, borrower_id, principal_amount, currency, rate, roi, tenor_months, repayment_type, agreement_letter_url, funding_deadline, state, version, created_at, updated_at`
+
 type LoanRepository struct {
 	db *pgxpool.Pool
 }
@@ -21,18 +24,23 @@ func NewLoanRepository(db *pgxpool.Pool) *LoanRepository {
 
 func (r *LoanRepository) Create(ctx context.Context, loan *domain.Loan) error {
 	query := `
-		INSERT INTO loans (id, borrower_id, principal_amount, rate, roi, agreement_letter_url, state, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO loans (` + loanColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	`
 
-	_, err := r.db.Exec(ctx, query,
+	_, err := conn(ctx, r.db).Exec(ctx, query,
 		loan.ID,
 		loan.BorrowerID,
 		loan.PrincipalAmount,
+		loan.PrincipalAmount.Currency,
 		loan.Rate,
 		loan.ROI,
+		loan.TenorMonths,
+		loan.RepaymentType,
 		loan.AgreementLetterURL,
+		loan.FundingDeadline,
 		loan.State,
+		loan.Version,
 		loan.CreatedAt,
 		loan.UpdatedAt,
 	)
@@ -42,49 +50,79 @@ func (r *LoanRepository) Create(ctx context.Context, loan *domain.Loan) error {
 
 func (r *LoanRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Loan, error) {
 	query := `
-		SELECT id, borrower_id, principal_amount, rate, roi, agreement_letter_url, state, created_at, updated_at
+		SELECT ` + loanColumns + `
 		FROM loans
 		WHERE id = $1
 	`
 
-	var loan domain.Loan
-	var agreementLetterURL sql.NullString
+	loan, err := scanLoan(conn(ctx, r.db).QueryRow(ctx, query, id))
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("%w: %w", domain.ErrLoanNotFound, err)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	err := r.db.QueryRow(ctx, query, id).Scan(
-		&loan.ID,
-		&loan.BorrowerID,
-		&loan.PrincipalAmount,
-		&loan.Rate,
-		&loan.ROI,
-		&agreementLetterURL,
-		&loan.State,
-		&loan.CreatedAt,
-		&loan.UpdatedAt,
-	)
+	return loan, nil
+}
 
-	if err == pgx.ErrNoRows {
-		return nil, fmt.Errorf("loan not found: %w", err)
+// Search returns the page of loans matching a normalized query
+func (r *LoanRepository) Search(ctx context.Context, q domain.LoanQuery) (*domain.LoanPage, error) {
+	query, args, err := buildLoanSearch(q)
+	if err != nil {
+		return nil, err
 	}
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	if agreementLetterURL.Valid {
-		loan.AgreementLetterURL = &agreementLetterURL.String
+	page := &domain.LoanPage{Loans: make([]*domain.Loan, 0, q.Limit)}
+	var sortValue string
+	for rows.Next() {
+		if len(page.Loans) == q.Limit {
+			last := page.Loans[len(page.Loans)-1]
+			page.NextCursor = domain.LoanCursor{Sort: q.Sort, Order: q.Order, Value: sortValue, ID: last.ID}.Encode()
+			break
+		}
+
+		loan, err := scanLoan(rows, &sortValue)
+		if err != nil {
+			return nil, err
+		}
+		page.Loans = append(page.Loans, loan)
 	}
 
-	return &loan, nil
+	return page, rows.Err()
 }
 
-func (r *LoanRepository) GetByState(ctx context.Context, state domain.LoanState) ([]*domain.Loan, error) {
+func (r *LoanRepository) GetByBorrowerID(ctx context.Context, borrowerID uuid.UUID) ([]*domain.Loan, error) {
 	query := `
-		SELECT id, borrower_id, principal_amount, rate, roi, agreement_letter_url, state, created_at, updated_at
+		SELECT ` + loanColumns + `
 		FROM loans
-		WHERE state = $1
+		WHERE borrower_id = $1
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.Query(ctx, query, state)
+	return r.query(ctx, query, borrowerID)
+}
+
+// GetFundingExpired returns approved loans whose funding deadline is before now
+func (r *LoanRepository) GetFundingExpired(ctx context.Context, now time.Time) ([]*domain.Loan, error) {
+	query := `
+		SELECT ` + loanColumns + `
+		FROM loans
+		WHERE state = 'approved' AND funding_deadline < $1
+		ORDER BY funding_deadline ASC
+	`
+
+	return r.query(ctx, query, now)
+}
+
+func (r *LoanRepository) query(ctx context.Context, query string, args ...any) ([]*domain.Loan, error) {
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -92,49 +130,76 @@ func (r *LoanRepository) GetByState(ctx context.Context, state domain.LoanState)
 
 	loans := make([]*domain.Loan, 0)
 	for rows.Next() {
-		var loan domain.Loan
-		var agreementLetterURL sql.NullString
-
-		if err := rows.Scan(
-			&loan.ID,
-			&loan.BorrowerID,
-			&loan.PrincipalAmount,
-			&loan.Rate,
-			&loan.ROI,
-			&agreementLetterURL,
-			&loan.State,
-			&loan.CreatedAt,
-			&loan.UpdatedAt,
-		); err != nil {
+		loan, err := scanLoan(rows)
+		if err != nil {
 			return nil, err
 		}
-
-		if agreementLetterURL.Valid {
-			loan.AgreementLetterURL = &agreementLetterURL.String
-		}
-
-		loans = append(loans, &loan)
+		loans = append(loans, loan)
 	}
 
 	return loans, rows.Err()
 }
 
+// Update saves a loan read at loan.Version and moves it to the next version.
+// It returns domain.ErrLoanVersionConflict when the loan was updated since.
 func (r *LoanRepository) Update(ctx context.Context, loan *domain.Loan) error {
 	query := `
 		UPDATE loans
-		SET principal_amount = $2, rate = $3, roi = $4, agreement_letter_url = $5, state = $6, updated_at = $7
-		WHERE id = $1
+		SET principal_amount = $2, rate = $3, roi = $4, agreement_letter_url = $5, funding_deadline = $6, state = $7, updated_at = $8, version = version + 1
+		WHERE id = $1 AND version = $9
 	`
 
-	_, err := r.db.Exec(ctx, query,
+	tag, err := conn(ctx, r.db).Exec(ctx, query,
 		loan.ID,
 		loan.PrincipalAmount,
 		loan.Rate,
 		loan.ROI,
 		loan.AgreementLetterURL,
+		loan.FundingDeadline,
 		loan.State,
 		loan.UpdatedAt,
+		loan.Version,
 	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: loan %s at version %d", domain.ErrLoanVersionConflict, loan.ID, loan.Version)
+	}
 
-	return err
+	loan.Version++
+	return nil
+}
+
+// scanLoan reads a row selected with loanColumns, followed by any extra
+// columns into extra
+func scanLoan(row pgx.Row, extra ...any) (*domain.Loan, error) {
+	var loan domain.Loan
+	var agreementLetterURL sql.NullString
+
+	dest := []any{
+		&loan.ID,
+		&loan.BorrowerID,
+		&loan.PrincipalAmount,
+		&loan.PrincipalAmount.Currency,
+		&loan.Rate,
+		&loan.ROI,
+		&loan.TenorMonths,
+		&loan.RepaymentType,
+		&agreementLetterURL,
+		&loan.FundingDeadline,
+		&loan.State,
+		&loan.Version,
+		&loan.CreatedAt,
+		&loan.UpdatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	if agreementLetterURL.Valid {
+		loan.AgreementLetterURL = &agreementLetterURL.String
+	}
+
+	return &loan, nil
 }
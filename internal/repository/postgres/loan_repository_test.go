@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mungkiice/-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildLoanSearch(t *testing.T) {
+	borrowerID := uuid.New()
+	minRate := domain.Percent(500)
+	minFunded := domain.Percent(5000)
+	after := &domain.LoanCursor{Sort: domain.SortByPrincipal, Order: domain.SortAsc, Value: "1000000.00", ID: uuid.New()}
+
+	query, args, err := buildLoanSearch(domain.LoanQuery{
+		States:           []domain.LoanState{domain.StateApproved, domain.StateInvested},
+		BorrowerID:       &borrowerID,
+		MinRate:          &minRate,
+		MinFundedPercent: &minFunded,
+		Sort:             domain.SortByPrincipal,
+		Order:            domain.SortAsc,
+		Limit:            10,
+		After:            after,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT "+loanColumns+", (principal_amount)::text FROM loans"+
+		" WHERE state = ANY($1::text[]::loan_state[]) AND borrower_id = $2 AND rate >= $3"+
+		" AND (funded_amount * 100 / principal_amount) >= $4 AND (principal_amount, id) > ($5::text::numeric, $6)"+
+		" ORDER BY principal_amount ASC, id ASC LIMIT $7", query)
+	assert.Equal(t, []any{[]string{"approved", "invested"}, borrowerID, minRate, minFunded, after.Value, after.ID, 11}, args)
+}
+
+func TestBuildLoanSearch_WithoutFilters(t *testing.T) {
+	query, args, err := buildLoanSearch(domain.LoanQuery{Sort: domain.SortByCreatedAt, Order: domain.SortDesc, Limit: 20})
+
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT "+loanColumns+", (created_at)::text FROM loans ORDER BY created_at DESC, id DESC LIMIT $1", query)
+	assert.Equal(t, []any{21}, args)
+}
+
+func TestLoanRepository_SearchPagesThroughMatchingLoans(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	loans := NewLoanRepository(db)
+	investments := NewInvestmentRepository(db)
+
+	borrower := domain.NewBorrower(nil, "Borrower", nil, nil, nil, nil)
+	require.NoError(t, NewBorrowerRepository(db).Create(ctx, borrower))
+
+	// principals 100k..700k; loans at 300k and 500k share a principal with
+	// another loan so ties are broken by ID
+	principals := []int64{100000, 300000, 300000, 500000, 500000, 600000, 700000}
+	var approved []*domain.Loan
+	for _, principal := range principals {
+		loan := domain.NewLoan(borrower.ID, domain.NewMoney(principal, domain.CurrencyIDR), 500, 300)
+		loan.State = domain.StateApproved
+		require.NoError(t, loans.Create(ctx, loan))
+		approved = append(approved, loan)
+	}
+	proposed := domain.NewLoan(borrower.ID, domain.NewMoney(400000, domain.CurrencyIDR), 500, 300)
+	require.NoError(t, loans.Create(ctx, proposed))
+
+	// half fund the most expensive loan
+	require.NoError(t, investments.Create(ctx, &domain.Investment{
+		ID:         uuid.New(),
+		LoanID:     approved[6].ID,
+		InvestorID: uuid.New(),
+		Amount:     domain.NewMoney(350000, domain.CurrencyIDR),
+		CreatedAt:  time.Now(),
+	}))
+
+	query := domain.LoanQuery{
+		States: []domain.LoanState{domain.StateApproved},
+		Sort:   domain.SortByPrincipal,
+		Order:  domain.SortAsc,
+		Limit:  2,
+	}
+	var seen []*domain.Loan
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, len(principals), "pagination did not end")
+
+		page, err := loans.Search(ctx, query)
+		require.NoError(t, err)
+		seen = append(seen, page.Loans...)
+		if page.NextCursor == "" {
+			break
+		}
+		query.After, err = domain.DecodeLoanCursor(page.NextCursor)
+		require.NoError(t, err)
+	}
+
+	require.Len(t, seen, len(approved))
+	ids := make(map[uuid.UUID]bool)
+	for i, loan := range seen {
+		ids[loan.ID] = true
+		if i > 0 {
+			assert.LessOrEqual(t, seen[i-1].PrincipalAmount.Amount, loan.PrincipalAmount.Amount)
+		}
+	}
+	assert.Len(t, ids, len(approved), "a loan was returned twice")
+	assert.NotContains(t, ids, proposed.ID)
+
+	halfFunded := domain.Percent(5000)
+	page, err := loans.Search(ctx, domain.LoanQuery{
+		MinFundedPercent: &halfFunded,
+		Sort:             domain.SortByFundedPercent,
+		Order:            domain.SortDesc,
+		Limit:            10,
+	})
+	require.NoError(t, err)
+	require.Len(t, page.Loans, 1)
+	assert.Equal(t, approved[6].ID, page.Loans[0].ID)
+	assert.Empty(t, page.NextCursor)
+}
@@ -0,0 +1,156 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mungkiice/-loan-service/internal/notification"
+)
+
+// NotificationPreferenceRepository implements notification.PreferenceRepository using PostgreSQL
+type NotificationPreferenceRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewNotificationPreferenceRepository creates a new notification preference repository
+func NewNotificationPreferenceRepository(db *pgxpool.Pool) *NotificationPreferenceRepository {
+	return &NotificationPreferenceRepository{db: db}
+}
+
+// Get retrieves the channels a user has set
+func (r *NotificationPreferenceRepository) Get(ctx context.Context, userID uuid.UUID) (notification.Preferences, error) {
+	query := `
+		SELECT channel, enabled
+		FROM notification_preferences
+		WHERE user_id = $1
+	`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prefs := notification.Preferences{}
+	for rows.Next() {
+		var channel notification.Channel
+		var enabled bool
+		if err := rows.Scan(&channel, &enabled); err != nil {
+			return nil, err
+		}
+		prefs[channel] = enabled
+	}
+
+	return prefs, rows.Err()
+}
+
+// Save upserts the given channels, leaving the others unchanged
+func (r *NotificationPreferenceRepository) Save(ctx context.Context, userID uuid.UUID, prefs notification.Preferences) error {
+	query := `
+		INSERT INTO notification_preferences (user_id, channel, enabled, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, channel) DO UPDATE
+		SET enabled = EXCLUDED.enabled, updated_at = EXCLUDED.updated_at
+	`
+
+	now := time.Now()
+	batch := &pgx.Batch{}
+	for channel, enabled := range prefs {
+		batch.Queue(query, userID, channel, enabled, now)
+	}
+
+	return sendBatch(ctx, conn(ctx, r.db), batch)
+}
+
+const notificationColumns = `id, user_id, kind, title, body, created_at, read_at`
+
+// InAppNotificationRepository implements notification.InAppRepository using PostgreSQL
+type InAppNotificationRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewInAppNotificationRepository creates a new in-app notification repository
+func NewInAppNotificationRepository(db *pgxpool.Pool) *InAppNotificationRepository {
+	return &InAppNotificationRepository{db: db}
+}
+
+// Create inserts a notification, ignoring one that was already stored
+func (r *InAppNotificationRepository) Create(ctx context.Context, n *notification.InAppNotification) error {
+	query := `
+		INSERT INTO notifications (` + notificationColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (id) DO NOTHING
+	`
+
+	_, err := conn(ctx, r.db).Exec(ctx, query,
+		n.ID,
+		n.UserID,
+		n.Kind,
+		n.Title,
+		n.Body,
+		n.CreatedAt,
+		n.ReadAt,
+	)
+
+	return err
+}
+
+// GetByUserID retrieves a user's most recent notifications, newest first
+func (r *InAppNotificationRepository) GetByUserID(ctx context.Context, userID uuid.UUID, limit int) ([]*notification.InAppNotification, error) {
+	query := `
+		SELECT ` + notificationColumns + `
+		FROM notifications
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []*notification.InAppNotification
+	for rows.Next() {
+		var n notification.InAppNotification
+		if err := rows.Scan(
+			&n.ID,
+			&n.UserID,
+			&n.Kind,
+			&n.Title,
+			&n.Body,
+			&n.CreatedAt,
+			&n.ReadAt,
+		); err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, &n)
+	}
+
+	return notifications, rows.Err()
+}
+
+// MarkRead marks one of the user's notifications as read; reading it again
+// keeps the first read time
+func (r *InAppNotificationRepository) MarkRead(ctx context.Context, id, userID uuid.UUID, at time.Time) error {
+	query := `
+		UPDATE notifications
+		SET read_at = COALESCE(read_at, $3)
+		WHERE id = $1 AND user_id = $2
+	`
+
+	tag, err := conn(ctx, r.db).Exec(ctx, query, id, userID, at)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: %s", notification.ErrNotificationNotFound, id)
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mungkiice/-loan-service/internal/outbox"
+)
+
+const outboxColumns = `id, topic, payload, status, attempts, next_attempt_at, last_error, created_at, delivered_at`
+
+// OutboxRepository implements outbox.Repository using PostgreSQL
+type OutboxRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewOutboxRepository creates a new outbox repository
+func NewOutboxRepository(db *pgxpool.Pool) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// Create inserts messages, joining the caller's transaction when there is one
+func (r *OutboxRepository) Create(ctx context.Context, messages []*outbox.Message) error {
+	query := `
+		INSERT INTO outbox_messages (` + outboxColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	batch := &pgx.Batch{}
+	for _, m := range messages {
+		batch.Queue(query,
+			m.ID,
+			m.Topic,
+			m.Payload,
+			m.Status,
+			m.Attempts,
+			m.NextAttemptAt,
+			m.LastError,
+			m.CreatedAt,
+			m.DeliveredAt,
+		)
+	}
+
+	return sendBatch(ctx, conn(ctx, r.db), batch)
+}
+
+// ClaimDue leases due pending messages. SKIP LOCKED lets several dispatchers
+// claim disjoint batches concurrently.
+func (r *OutboxRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*outbox.Message, error) {
+	query := `
+		UPDATE outbox_messages
+		SET next_attempt_at = $2
+		WHERE id IN (
+			SELECT id
+			FROM outbox_messages
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at ASC
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + outboxColumns
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*outbox.Message
+	for rows.Next() {
+		var m outbox.Message
+		if err := rows.Scan(
+			&m.ID,
+			&m.Topic,
+			&m.Payload,
+			&m.Status,
+			&m.Attempts,
+			&m.NextAttemptAt,
+			&m.LastError,
+			&m.CreatedAt,
+			&m.DeliveredAt,
+		); err != nil {
+			return nil, err
+		}
+		messages = append(messages, &m)
+	}
+
+	return messages, rows.Err()
+}
+
+// Update records the outcome of a delivery attempt
+func (r *OutboxRepository) Update(ctx context.Context, m *outbox.Message) error {
+	query := `
+		UPDATE outbox_messages
+		SET status = $2, attempts = $3, next_attempt_at = $4, last_error = $5, delivered_at = $6
+		WHERE id = $1
+	`
+
+	_, err := conn(ctx, r.db).Exec(ctx, query,
+		m.ID,
+		m.Status,
+		m.Attempts,
+		m.NextAttemptAt,
+		m.LastError,
+		m.DeliveredAt,
+	)
+
+	return err
+}
@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mungkiice/-loan-service/internal/domain"
+)
+
+// PayoutRepository implements domain.PayoutRepository using PostgreSQL
+type PayoutRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPayoutRepository creates a new payout repository
+func NewPayoutRepository(db *pgxpool.Pool) *PayoutRepository {
+	return &PayoutRepository{db: db}
+}
+
+// CreateBatch inserts the investor payouts of a repayment
+func (r *PayoutRepository) CreateBatch(ctx context.Context, payouts []*domain.InvestorPayout) error {
+	query := `
+		INSERT INTO investor_payouts (id, loan_id, investment_id, investor_id, repayment_id, principal, interest, currency, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	batch := &pgx.Batch{}
+	for _, p := range payouts {
+		batch.Queue(query,
+			p.ID,
+			p.LoanID,
+			p.InvestmentID,
+			p.InvestorID,
+			p.RepaymentID,
+			p.Principal,
+			p.Interest,
+			p.Principal.Currency,
+			p.CreatedAt,
+		)
+	}
+
+	return sendBatch(ctx, conn(ctx, r.db), batch)
+}
+
+// GetByInvestorID retrieves every payout credited to an investor
+func (r *PayoutRepository) GetByInvestorID(ctx context.Context, investorID uuid.UUID) ([]*domain.InvestorPayout, error) {
+	query := `
+		SELECT id, loan_id, investment_id, investor_id, repayment_id, principal, interest, currency, created_at
+		FROM investor_payouts
+		WHERE investor_id = $1
+		ORDER BY created_at ASC
+	`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, investorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payouts := make([]*domain.InvestorPayout, 0)
+	for rows.Next() {
+		var p domain.InvestorPayout
+		var currency domain.Currency
+		if err := rows.Scan(
+			&p.ID,
+			&p.LoanID,
+			&p.InvestmentID,
+			&p.InvestorID,
+			&p.RepaymentID,
+			&p.Principal,
+			&p.Interest,
+			&currency,
+			&p.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		p.Principal.Currency = currency
+		p.Interest.Currency = currency
+		payouts = append(payouts, &p)
+	}
+
+	return payouts, rows.Err()
+}
@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mungkiice/-loan-service/internal/domain"
+)
+
+// RepaymentRepository implements domain.RepaymentRepository using PostgreSQL
+type RepaymentRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewRepaymentRepository creates a new repayment repository
+func NewRepaymentRepository(db *pgxpool.Pool) *RepaymentRepository {
+	return &RepaymentRepository{db: db}
+}
+
+// Create inserts a borrower repayment
+func (r *RepaymentRepository) Create(ctx context.Context, repayment *domain.Repayment) error {
+	query := `
+		INSERT INTO repayments (id, loan_id, amount, currency, paid_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err := conn(ctx, r.db).Exec(ctx, query,
+		repayment.ID,
+		repayment.LoanID,
+		repayment.Amount,
+		repayment.Amount.Currency,
+		repayment.PaidAt,
+		repayment.CreatedAt,
+	)
+
+	return err
+}
+
+// CreateAllocations inserts the installment allocations of a repayment
+func (r *RepaymentRepository) CreateAllocations(ctx context.Context, allocations []*domain.RepaymentAllocation) error {
+	query := `
+		INSERT INTO repayment_allocations (repayment_id, installment_id, principal, interest)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	batch := &pgx.Batch{}
+	for _, a := range allocations {
+		batch.Queue(query, a.RepaymentID, a.InstallmentID, a.Principal, a.Interest)
+	}
+
+	return sendBatch(ctx, conn(ctx, r.db), batch)
+}
+
+// GetByLoanID retrieves all repayments for a loan
+func (r *RepaymentRepository) GetByLoanID(ctx context.Context, loanID uuid.UUID) ([]*domain.Repayment, error) {
+	query := `
+		SELECT id, loan_id, amount, currency, paid_at, created_at
+		FROM repayments
+		WHERE loan_id = $1
+		ORDER BY paid_at ASC
+	`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, loanID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	repayments := make([]*domain.Repayment, 0)
+	for rows.Next() {
+		var repayment domain.Repayment
+		if err := rows.Scan(
+			&repayment.ID,
+			&repayment.LoanID,
+			&repayment.Amount,
+			&repayment.Amount.Currency,
+			&repayment.PaidAt,
+			&repayment.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		repayments = append(repayments, &repayment)
+	}
+
+	return repayments, rows.Err()
+}
@@ -27,7 +27,7 @@ func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
 		VALUES ($1, $2, $3, $4, $5, $6)
 	`
 
-	_, err := r.db.Exec(ctx, query,
+	_, err := conn(ctx, r.db).Exec(ctx, query,
 		user.ID,
 		user.Email,
 		user.Password,
@@ -48,7 +48,7 @@ func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Use
 	`
 
 	var user domain.User
-	err := r.db.QueryRow(ctx, query, id).Scan(
+	err := conn(ctx, r.db).QueryRow(ctx, query, id).Scan(
 		&user.ID,
 		&user.Email,
 		&user.Password,
@@ -76,7 +76,7 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.
 	`
 
 	var user domain.User
-	err := r.db.QueryRow(ctx, query, email).Scan(
+	err := conn(ctx, r.db).QueryRow(ctx, query, email).Scan(
 		&user.ID,
 		&user.Email,
 		&user.Password,
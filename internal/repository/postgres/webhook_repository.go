@@ -0,0 +1,238 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mungkiice/-loan-service/internal/domain"
+	"github.com/mungkiice/-loan-service/internal/webhook"
+)
+
+const webhookSubscriptionColumns = `id, url, secret, events, active, created_at, updated_at`
+
+// WebhookSubscriptionRepository implements webhook.SubscriptionRepository using PostgreSQL
+type WebhookSubscriptionRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewWebhookSubscriptionRepository creates a new webhook subscription repository
+func NewWebhookSubscriptionRepository(db *pgxpool.Pool) *WebhookSubscriptionRepository {
+	return &WebhookSubscriptionRepository{db: db}
+}
+
+// Create inserts a new subscription
+func (r *WebhookSubscriptionRepository) Create(ctx context.Context, s *webhook.Subscription) error {
+	query := `
+		INSERT INTO webhook_subscriptions (` + webhookSubscriptionColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := conn(ctx, r.db).Exec(ctx, query,
+		s.ID,
+		s.URL,
+		s.Secret,
+		eventNames(s.Events),
+		s.Active,
+		s.CreatedAt,
+		s.UpdatedAt,
+	)
+
+	return err
+}
+
+// GetByID retrieves a subscription by ID
+func (r *WebhookSubscriptionRepository) GetByID(ctx context.Context, id uuid.UUID) (*webhook.Subscription, error) {
+	query := `
+		SELECT ` + webhookSubscriptionColumns + `
+		FROM webhook_subscriptions
+		WHERE id = $1
+	`
+
+	subscriptions, err := r.query(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(subscriptions) == 0 {
+		return nil, fmt.Errorf("%w: %s", webhook.ErrSubscriptionNotFound, id)
+	}
+
+	return subscriptions[0], nil
+}
+
+// GetAll retrieves every subscription, oldest first
+func (r *WebhookSubscriptionRepository) GetAll(ctx context.Context) ([]*webhook.Subscription, error) {
+	query := `
+		SELECT ` + webhookSubscriptionColumns + `
+		FROM webhook_subscriptions
+		ORDER BY created_at ASC
+	`
+
+	return r.query(ctx, query)
+}
+
+// GetActiveByEvent retrieves the active subscriptions filtering on eventType
+func (r *WebhookSubscriptionRepository) GetActiveByEvent(ctx context.Context, eventType domain.EventType) ([]*webhook.Subscription, error) {
+	query := `
+		SELECT ` + webhookSubscriptionColumns + `
+		FROM webhook_subscriptions
+		WHERE active AND events @> ARRAY[$1::text]
+		ORDER BY created_at ASC
+	`
+
+	return r.query(ctx, query, string(eventType))
+}
+
+// Update saves a subscription's url, secret, events and active flag
+func (r *WebhookSubscriptionRepository) Update(ctx context.Context, s *webhook.Subscription) error {
+	query := `
+		UPDATE webhook_subscriptions
+		SET url = $2, secret = $3, events = $4, active = $5, updated_at = $6
+		WHERE id = $1
+	`
+
+	tag, err := conn(ctx, r.db).Exec(ctx, query,
+		s.ID,
+		s.URL,
+		s.Secret,
+		eventNames(s.Events),
+		s.Active,
+		s.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: %s", webhook.ErrSubscriptionNotFound, s.ID)
+	}
+
+	return nil
+}
+
+// Delete removes a subscription and its delivery attempts
+func (r *WebhookSubscriptionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tag, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: %s", webhook.ErrSubscriptionNotFound, id)
+	}
+
+	return nil
+}
+
+func (r *WebhookSubscriptionRepository) query(ctx context.Context, query string, args ...any) ([]*webhook.Subscription, error) {
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscriptions []*webhook.Subscription
+	for rows.Next() {
+		var s webhook.Subscription
+		var events []string
+		if err := rows.Scan(
+			&s.ID,
+			&s.URL,
+			&s.Secret,
+			&events,
+			&s.Active,
+			&s.CreatedAt,
+			&s.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		for _, event := range events {
+			s.Events = append(s.Events, domain.EventType(event))
+		}
+		subscriptions = append(subscriptions, &s)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+func eventNames(events []domain.EventType) []string {
+	names := make([]string, len(events))
+	for i, event := range events {
+		names[i] = string(event)
+	}
+	return names
+}
+
+const webhookAttemptColumns = `id, delivery_id, subscription_id, event, attempt, status_code, error, duration_ms, attempted_at`
+
+// WebhookAttemptRepository implements webhook.AttemptRepository using PostgreSQL
+type WebhookAttemptRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewWebhookAttemptRepository creates a new webhook attempt repository
+func NewWebhookAttemptRepository(db *pgxpool.Pool) *WebhookAttemptRepository {
+	return &WebhookAttemptRepository{db: db}
+}
+
+// Create records a delivery attempt
+func (r *WebhookAttemptRepository) Create(ctx context.Context, a *webhook.Attempt) error {
+	query := `
+		INSERT INTO webhook_attempts (` + webhookAttemptColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	_, err := conn(ctx, r.db).Exec(ctx, query,
+		a.ID,
+		a.DeliveryID,
+		a.SubscriptionID,
+		a.Event,
+		a.Attempt,
+		a.StatusCode,
+		a.Error,
+		a.Duration.Milliseconds(),
+		a.AttemptedAt,
+	)
+
+	return err
+}
+
+// GetBySubscriptionID retrieves a subscription's most recent attempts, newest first
+func (r *WebhookAttemptRepository) GetBySubscriptionID(ctx context.Context, subscriptionID uuid.UUID, limit int) ([]*webhook.Attempt, error) {
+	query := `
+		SELECT ` + webhookAttemptColumns + `
+		FROM webhook_attempts
+		WHERE subscription_id = $1
+		ORDER BY attempted_at DESC
+		LIMIT $2
+	`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, subscriptionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []*webhook.Attempt
+	for rows.Next() {
+		var a webhook.Attempt
+		var durationMS int64
+		if err := rows.Scan(
+			&a.ID,
+			&a.DeliveryID,
+			&a.SubscriptionID,
+			&a.Event,
+			&a.Attempt,
+			&a.StatusCode,
+			&a.Error,
+			&durationMS,
+			&a.AttemptedAt,
+		); err != nil {
+			return nil, err
+		}
+		a.Duration = time.Duration(durationMS) * time.Millisecond
+		attempts = append(attempts, &a)
+	}
+
+	return attempts, rows.Err()
+}
@@ -13,6 +13,7 @@ type AuthUseCase struct {
 	userRepo     domain.UserRepository
 	employeeRepo domain.EmployeeRepository
 	investorRepo domain.InvestorRepository
+	borrowerRepo domain.BorrowerRepository
 	jwtService   *jwt.JWTService
 }
 
@@ -22,12 +23,14 @@ func NewAuthUseCase(
 	userRepo domain.UserRepository,
 	employeeRepo domain.EmployeeRepository,
 	investorRepo domain.InvestorRepository,
+	borrowerRepo domain.BorrowerRepository,
 	jwtService *jwt.JWTService,
 ) *AuthUseCase {
 	return &AuthUseCase{
 		userRepo:     userRepo,
 		employeeRepo: employeeRepo,
 		investorRepo: investorRepo,
+		borrowerRepo: borrowerRepo,
 		jwtService:   jwtService,
 	}
 }
@@ -80,6 +83,17 @@ func (uc *AuthUseCase) SignIn(ctx context.Context, req SignInRequest) (*SignInRe
 			"name":  inv.Name,
 			"email": user.Email,
 		}
+	case domain.UserTypeBorrower:
+		b, err := uc.borrowerRepo.GetByUserID(ctx, user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("borrower not found")
+		}
+		data = map[string]interface{}{
+			"id":         b.ID.String(),
+			"name":       b.Name,
+			"email":      user.Email,
+			"kyc_status": string(b.KYCStatus),
+		}
 	default:
 		return nil, fmt.Errorf("unknown user type")
 	}
@@ -0,0 +1,154 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mungkiice/-loan-service/internal/domain"
+	"github.com/mungkiice/-loan-service/internal/idempotency"
+)
+
+type BorrowerUseCase struct {
+	borrowerRepo domain.BorrowerRepository
+	userRepo     domain.UserRepository
+	txManager    domain.TxManager
+	idempotency  *idempotency.Keeper
+}
+
+func NewBorrowerUseCase(
+	borrowerRepo domain.BorrowerRepository,
+	userRepo domain.UserRepository,
+	txManager domain.TxManager,
+	idempotency *idempotency.Keeper,
+) *BorrowerUseCase {
+	return &BorrowerUseCase{
+		borrowerRepo: borrowerRepo,
+		userRepo:     userRepo,
+		txManager:    txManager,
+		idempotency:  idempotency,
+	}
+}
+
+// RegisterBorrower creates a borrower profile pending KYC review. When an
+// email and password are given a borrower login is created with it. When the
+// request has an idempotency key, a retry returns the borrower registered by
+// the first request.
+func (uc *BorrowerUseCase) RegisterBorrower(ctx context.Context, req RegisterBorrowerRequest) (*domain.Borrower, error) {
+	if req.IdempotencyKey == "" {
+		return uc.registerBorrower(ctx, req)
+	}
+
+	fingerprint, err := idempotency.Fingerprint(req.Name, req.NationalID, req.Phone, req.Address, req.DateOfBirth, req.Email, req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("register-borrower:%s", req.IdempotencyKey)
+	return idempotency.Do(ctx, uc.idempotency, key, fingerprint, func() (*domain.Borrower, error) {
+		return uc.registerBorrower(ctx, req)
+	})
+}
+
+func (uc *BorrowerUseCase) registerBorrower(ctx context.Context, req RegisterBorrowerRequest) (*domain.Borrower, error) {
+	var user *domain.User
+	if req.Email != "" {
+		hashed, err := domain.HashPassword(req.Password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash password: %w", err)
+		}
+		now := time.Now()
+		user = &domain.User{
+			ID:        uuid.New(),
+			Email:     req.Email,
+			Password:  hashed,
+			UserType:  domain.UserTypeBorrower,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+	}
+
+	var userID *uuid.UUID
+	if user != nil {
+		userID = &user.ID
+	}
+	borrower := domain.NewBorrower(userID, req.Name, req.NationalID, req.Phone, req.Address, req.DateOfBirth)
+
+	if err := uc.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if user != nil {
+			if err := uc.userRepo.Create(ctx, user); err != nil {
+				return fmt.Errorf("failed to create user: %w", err)
+			}
+		}
+
+		if err := uc.borrowerRepo.Create(ctx, borrower); err != nil {
+			return fmt.Errorf("failed to create borrower: %w", err)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return borrower, nil
+}
+
+func (uc *BorrowerUseCase) GetBorrower(ctx context.Context, borrowerID uuid.UUID) (*domain.Borrower, error) {
+	return uc.borrowerRepo.GetByID(ctx, borrowerID)
+}
+
+// ReviewKYC verifies or rejects a borrower's KYC. When the request has an
+// idempotency key, a retry returns the borrower as the first review left it.
+func (uc *BorrowerUseCase) ReviewKYC(ctx context.Context, req ReviewKYCRequest) (*domain.Borrower, error) {
+	if req.IdempotencyKey == "" {
+		return uc.reviewKYC(ctx, req)
+	}
+
+	fingerprint, err := idempotency.Fingerprint(req.BorrowerID, req.EmployeeID, req.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("review-kyc:%s:%s", req.BorrowerID, req.IdempotencyKey)
+	return idempotency.Do(ctx, uc.idempotency, key, fingerprint, func() (*domain.Borrower, error) {
+		return uc.reviewKYC(ctx, req)
+	})
+}
+
+func (uc *BorrowerUseCase) reviewKYC(ctx context.Context, req ReviewKYCRequest) (*domain.Borrower, error) {
+	borrower, err := uc.borrowerRepo.GetByID(ctx, req.BorrowerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := borrower.ReviewKYC(req.Status, req.EmployeeID); err != nil {
+		return nil, err
+	}
+
+	if err := uc.borrowerRepo.Update(ctx, borrower); err != nil {
+		return nil, fmt.Errorf("failed to update borrower: %w", err)
+	}
+
+	return borrower, nil
+}
+
+type RegisterBorrowerRequest struct {
+	Name        string
+	NationalID  *string
+	Phone       *string
+	Address     *string
+	DateOfBirth *time.Time
+	Email       string
+	Password    string
+	// IdempotencyKey is optional
+	IdempotencyKey string
+}
+
+type ReviewKYCRequest struct {
+	BorrowerID uuid.UUID
+	EmployeeID uuid.UUID
+	Status     domain.KYCStatus
+	// IdempotencyKey is optional
+	IdempotencyKey string
+}
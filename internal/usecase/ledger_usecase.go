@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/mungkiice/-loan-service/internal/domain"
+	"github.com/mungkiice/-loan-service/internal/ledger"
+)
+
+type LedgerUseCase struct {
+	loanRepo       domain.LoanRepository
+	investmentRepo domain.InvestmentRepository
+	ledger         *ledger.Ledger
+}
+
+func NewLedgerUseCase(
+	loanRepo domain.LoanRepository,
+	investmentRepo domain.InvestmentRepository,
+	ledger *ledger.Ledger,
+) *LedgerUseCase {
+	return &LedgerUseCase{
+		loanRepo:       loanRepo,
+		investmentRepo: investmentRepo,
+		ledger:         ledger,
+	}
+}
+
+func (uc *LedgerUseCase) GetTrialBalance(ctx context.Context) (*ledger.TrialBalance, error) {
+	return uc.ledger.TrialBalance(ctx)
+}
+
+// CheckEscrow compares a loan's escrow balance with the investment total
+// recorded by InvestmentRepository. A mismatch wraps ledger.ErrEscrowMismatch.
+func (uc *LedgerUseCase) CheckEscrow(ctx context.Context, loanID uuid.UUID) error {
+	loan, err := uc.loanRepo.GetByID(ctx, loanID)
+	if err != nil {
+		return fmt.Errorf("failed to get loan: %w", err)
+	}
+
+	invested, err := uc.investmentRepo.GetTotalByLoanID(ctx, loanID)
+	if err != nil {
+		return fmt.Errorf("failed to get investment total: %w", err)
+	}
+
+	return uc.ledger.CheckEscrow(ctx, loan, invested)
+}
@@ -0,0 +1,38 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mungkiice/-loan-service/internal/domain"
+	"github.com/mungkiice/-loan-service/internal/ledger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckEscrow(t *testing.T) {
+	loanRepo := new(MockLoanRepository)
+	investmentRepo := new(MockInvestmentRepository)
+	ledgerRepo := new(MockLedgerRepository)
+	l := ledger.New(ledgerRepo)
+	uc := NewLedgerUseCase(loanRepo, investmentRepo, l)
+
+	loan := domain.NewLoan(uuid.New(), idr(1000000), 1200, 800)
+	loan.State = domain.StateApproved
+	investment := &domain.Investment{ID: uuid.New(), LoanID: loan.ID, InvestorID: uuid.New(), Amount: idr(400000)}
+	require.NoError(t, l.RecordInvestment(context.Background(), investment))
+
+	loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	investmentRepo.On("GetTotalByLoanID", mock.Anything, loan.ID).Return(idr(400000), nil).Once()
+	investmentRepo.On("GetTotalByLoanID", mock.Anything, loan.ID).Return(idr(500000), nil).Once()
+
+	require.NoError(t, uc.CheckEscrow(context.Background(), loan.ID))
+	assert.ErrorIs(t, uc.CheckEscrow(context.Background(), loan.ID), ledger.ErrEscrowMismatch)
+
+	tb, err := uc.GetTrialBalance(context.Background())
+	require.NoError(t, err)
+	assert.True(t, tb.IsBalanced())
+	assert.Equal(t, idr(400000), tb.TotalDebits[domain.CurrencyIDR])
+}
@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/mungkiice/-loan-service/internal/domain"
+)
+
+// Who may see, create and invest in loans:
+//   - employees create loans for any borrower and see every loan
+//   - borrowers create and see only their own loans
+//   - investors see loans open for investment and those they hold an active
+//     investment in, and create none
+//   - investors invest as themselves; admins may invest on an investor's
+//     behalf, and the investment records which admin placed it
+//   - approvals and disbursements are recorded against the signed-in
+//     employee's profile, and no one disburses a loan they approved
+//
+// A loan's repayment schedule is visible to whoever may see the loan.
+
+// loanAccess resolves the profile of the signed-in user. It is shared by the
+// use cases that check who may see a loan.
+type loanAccess struct {
+	borrowerRepo domain.BorrowerRepository
+	investorRepo domain.InvestorRepository
+	employeeRepo domain.EmployeeRepository
+}
+
+// authorizeCreate checks that req.Actor may create a loan for req.BorrowerID.
+// A borrower who leaves BorrowerID empty creates the loan for themselves.
+func (uc *LoanUseCase) authorizeCreate(ctx context.Context, req *CreateLoanRequest) error {
+	switch req.Actor.UserType {
+	case domain.UserTypeEmployee:
+		if req.BorrowerID == uuid.Nil {
+			return fmt.Errorf("%w: borrower_id is required", domain.ErrInvalidInput)
+		}
+		return nil
+	case domain.UserTypeBorrower:
+		borrower, err := uc.signedInBorrower(ctx, req.Actor)
+		if err != nil {
+			return err
+		}
+		if req.BorrowerID == uuid.Nil {
+			req.BorrowerID = borrower.ID
+		}
+		if req.BorrowerID != borrower.ID {
+			return fmt.Errorf("%w: borrowers can only create their own loans", domain.ErrForbidden)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: %s users cannot create loans", domain.ErrForbidden, req.Actor.UserType)
+	}
+}
+
+// authorizeInvest settles who req invests for, setting req.InvestorID, and
+// returns the employee placing the investment when an admin invests on an
+// investor's behalf
+func (uc *LoanUseCase) authorizeInvest(ctx context.Context, req *InvestRequest) (*uuid.UUID, error) {
+	switch req.Actor.UserType {
+	case domain.UserTypeInvestor:
+		investor, err := uc.signedInInvestor(ctx, req.Actor)
+		if err != nil {
+			return nil, err
+		}
+		if req.InvestorID != uuid.Nil && req.InvestorID != investor.ID {
+			return nil, fmt.Errorf("%w: investors can only invest as themselves", domain.ErrForbidden)
+		}
+		req.InvestorID = investor.ID
+		return nil, nil
+	case domain.UserTypeEmployee:
+		if req.Actor.Role != domain.RoleAdmin {
+			return nil, fmt.Errorf("%w: only admins may invest on an investor's behalf", domain.ErrForbidden)
+		}
+		if req.InvestorID == uuid.Nil {
+			return nil, fmt.Errorf("%w: investor_id is required to invest on an investor's behalf", domain.ErrInvalidInput)
+		}
+		if _, err := uc.investorRepo.GetByID(ctx, req.InvestorID); err != nil {
+			return nil, err
+		}
+		employee, err := uc.signedInEmployee(ctx, req.Actor)
+		if err != nil {
+			return nil, err
+		}
+		return &employee.ID, nil
+	default:
+		return nil, fmt.Errorf("%w: %s users cannot invest", domain.ErrForbidden, req.Actor.UserType)
+	}
+}
+
+// scopeLoanQuery narrows a search to the loans actor may see
+func (uc *LoanUseCase) scopeLoanQuery(ctx context.Context, actor domain.Actor, query *domain.LoanQuery) error {
+	switch actor.UserType {
+	case domain.UserTypeEmployee:
+		return nil
+	case domain.UserTypeBorrower:
+		borrower, err := uc.signedInBorrower(ctx, actor)
+		if err != nil {
+			return err
+		}
+		if query.BorrowerID != nil && *query.BorrowerID != borrower.ID {
+			return fmt.Errorf("%w: borrowers can only see their own loans", domain.ErrForbidden)
+		}
+		query.BorrowerID = &borrower.ID
+		return nil
+	case domain.UserTypeInvestor:
+		investor, err := uc.signedInInvestor(ctx, actor)
+		if err != nil {
+			return err
+		}
+		query.OpenOrInvestedBy = &investor.ID
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown user type %q", domain.ErrForbidden, actor.UserType)
+	}
+}
+
+// authorizeView checks that actor may see the loan in details
+func (uc *loanAccess) authorizeView(ctx context.Context, actor domain.Actor, details *domain.LoanDetails) error {
+	switch actor.UserType {
+	case domain.UserTypeEmployee:
+		return nil
+	case domain.UserTypeBorrower:
+		borrower, err := uc.signedInBorrower(ctx, actor)
+		if err != nil {
+			return err
+		}
+		if details.Loan.BorrowerID == borrower.ID {
+			return nil
+		}
+	case domain.UserTypeInvestor:
+		investor, err := uc.signedInInvestor(ctx, actor)
+		if err != nil {
+			return err
+		}
+		if details.Loan.State == domain.StateApproved {
+			return nil
+		}
+		for _, inv := range details.Investments {
+			if inv.InvestorID == investor.ID {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("%w: loan %s", domain.ErrForbidden, details.Loan.ID)
+}
+
+func (uc *loanAccess) signedInBorrower(ctx context.Context, actor domain.Actor) (*domain.Borrower, error) {
+	borrower, err := uc.borrowerRepo.GetByUserID(ctx, actor.UserID)
+	if errors.Is(err, domain.ErrBorrowerNotFound) {
+		return nil, fmt.Errorf("%w: user %s has no borrower profile", domain.ErrForbidden, actor.UserID)
+	}
+	return borrower, err
+}
+
+func (uc *loanAccess) signedInInvestor(ctx context.Context, actor domain.Actor) (*domain.Investor, error) {
+	investor, err := uc.investorRepo.GetByUserID(ctx, actor.UserID)
+	if errors.Is(err, domain.ErrInvestorNotFound) {
+		return nil, fmt.Errorf("%w: user %s has no investor profile", domain.ErrForbidden, actor.UserID)
+	}
+	return investor, err
+}
+
+func (uc *loanAccess) signedInEmployee(ctx context.Context, actor domain.Actor) (*domain.Employee, error) {
+	employee, err := uc.employeeRepo.GetByUserID(ctx, actor.UserID)
+	if errors.Is(err, domain.ErrEmployeeNotFound) {
+		return nil, fmt.Errorf("%w: user %s has no employee profile", domain.ErrForbidden, actor.UserID)
+	}
+	return employee, err
+}
@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mungkiice/-loan-service/internal/domain"
+	"github.com/mungkiice/-loan-service/internal/infrastructure/redis"
+)
+
+// CacheStats counts loan cache lookups since the process started
+type CacheStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	// Errors counts Redis failures and unreadable entries; each is also a miss
+	Errors int64 `json:"errors"`
+}
+
+// loanCache keeps loan details in Redis as JSON. Entries are dropped after
+// every change to the loan and expire after ttl, which also bounds how long a
+// read that raced a change can keep a stale entry around.
+type loanCache struct {
+	client redis.RedisClient
+	ttl    time.Duration
+
+	hits   atomic.Int64
+	misses atomic.Int64
+	errors atomic.Int64
+}
+
+func newLoanCache(client redis.RedisClient, ttl time.Duration) *loanCache {
+	return &loanCache{client: client, ttl: ttl}
+}
+
+func loanCacheKey(loanID uuid.UUID) string {
+	return fmt.Sprintf("loan:%s", loanID)
+}
+
+func (c *loanCache) get(ctx context.Context, loanID uuid.UUID) (*domain.LoanDetails, bool) {
+	data, err := c.client.GetCache(ctx, loanCacheKey(loanID))
+	if errors.Is(err, redis.ErrCacheMiss) {
+		c.misses.Add(1)
+		return nil, false
+	}
+	if err != nil {
+		c.fail("read", loanID, err)
+		return nil, false
+	}
+
+	var details domain.LoanDetails
+	if err := json.Unmarshal([]byte(data), &details); err != nil {
+		c.fail("decode", loanID, err)
+		c.invalidate(ctx, loanID)
+		return nil, false
+	}
+
+	c.hits.Add(1)
+	return &details, true
+}
+
+func (c *loanCache) set(ctx context.Context, details *domain.LoanDetails) {
+	data, err := json.Marshal(details)
+	if err == nil {
+		err = c.client.SetCache(ctx, loanCacheKey(details.Loan.ID), string(data), c.ttl)
+	}
+	if err != nil {
+		log.Printf("loan cache: failed to store loan %s: %v", details.Loan.ID, err)
+	}
+}
+
+// invalidate drops a loan's entry; it is called after every committed change
+// to the loan, its investments or its approval and disbursement records
+func (c *loanCache) invalidate(ctx context.Context, loanID uuid.UUID) {
+	if err := c.client.DeleteCache(ctx, loanCacheKey(loanID)); err != nil {
+		log.Printf("loan cache: failed to invalidate loan %s: %v", loanID, err)
+	}
+}
+
+func (c *loanCache) fail(op string, loanID uuid.UUID, err error) {
+	c.misses.Add(1)
+	c.errors.Add(1)
+	log.Printf("loan cache: failed to %s loan %s: %v", op, loanID, err)
+}
+
+func (c *loanCache) stats() CacheStats {
+	return CacheStats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+		Errors: c.errors.Load(),
+	}
+}
@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/mungkiice/-loan-service/internal/domain"
+	"github.com/mungkiice/-loan-service/internal/notification"
+)
+
+// notifyBorrower queues a notification to the loan's borrower. It must run
+// inside the caller's transaction.
+func (uc *LoanUseCase) notifyBorrower(ctx context.Context, kind notification.Kind, loan *domain.Loan, data notification.Data) error {
+	borrower, err := uc.borrowerRepo.GetByID(ctx, loan.BorrowerID)
+	if err != nil {
+		return fmt.Errorf("failed to get borrower %s: %w", loan.BorrowerID, err)
+	}
+
+	recipient := notification.Recipient{Name: borrower.Name}
+	if borrower.Phone != nil {
+		recipient.Phone = *borrower.Phone
+	}
+	// borrowers registered without a login are only reachable by SMS
+	if borrower.UserID != nil {
+		user, err := uc.userRepo.GetByID(ctx, *borrower.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to get borrower user %s: %w", *borrower.UserID, err)
+		}
+		recipient.UserID = user.ID
+		recipient.Email = user.Email
+	}
+
+	return uc.notify(ctx, kind, recipient, data)
+}
+
+// investorRecipients resolves and caches how to reach investors, so a loan's
+// investors are looked up once per transition
+type investorRecipients struct {
+	uc         *LoanUseCase
+	recipients map[uuid.UUID]notification.Recipient
+}
+
+func (uc *LoanUseCase) investorRecipients() *investorRecipients {
+	return &investorRecipients{uc: uc, recipients: make(map[uuid.UUID]notification.Recipient)}
+}
+
+func (r *investorRecipients) get(ctx context.Context, investorID uuid.UUID) (notification.Recipient, error) {
+	if recipient, ok := r.recipients[investorID]; ok {
+		return recipient, nil
+	}
+
+	investor, err := r.uc.investorRepo.GetByID(ctx, investorID)
+	if err != nil {
+		return notification.Recipient{}, fmt.Errorf("failed to get investor %s: %w", investorID, err)
+	}
+	user, err := r.uc.userRepo.GetByID(ctx, investor.UserID)
+	if err != nil {
+		return notification.Recipient{}, fmt.Errorf("failed to get investor user %s: %w", investor.UserID, err)
+	}
+
+	recipient := notification.Recipient{UserID: user.ID, Name: investor.Name, Email: user.Email}
+	if investor.Phone != nil {
+		recipient.Phone = *investor.Phone
+	}
+	r.recipients[investorID] = recipient
+
+	return recipient, nil
+}
+
+// notify queues a notification to an investor. It must run inside the
+// caller's transaction.
+func (r *investorRecipients) notify(ctx context.Context, kind notification.Kind, investorID uuid.UUID, data notification.Data) error {
+	recipient, err := r.get(ctx, investorID)
+	if err != nil {
+		return err
+	}
+	return r.uc.notify(ctx, kind, recipient, data)
+}
+
+func (uc *LoanUseCase) notify(ctx context.Context, kind notification.Kind, recipient notification.Recipient, data notification.Data) error {
+	if err := uc.notifier.Publish(ctx, notification.New(kind, recipient, data)); err != nil {
+		return fmt.Errorf("failed to queue %s notification: %w", kind, err)
+	}
+	return nil
+}
+
+func loanData(loan *domain.Loan) notification.Data {
+	return notification.Data{
+		LoanID:    loan.ID,
+		Principal: loan.PrincipalAmount.String(),
+	}
+}
+
+// fundingData describes how far a loan is towards its principal
+func fundingData(loan *domain.Loan, funded domain.Money) notification.Data {
+	data := loanData(loan)
+	data.Funded = funded.String()
+	data.FundedPercent = loan.FundingShare(funded).String()
+	data.Remaining = loan.PrincipalAmount.Sub(funded).String()
+	return data
+}
@@ -1,57 +1,150 @@
 package usecase
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/mungkiice/-loan-service/internal/domain"
-	"github.com/mungkiice/-loan-service/internal/infrastructure/email"
+	"github.com/mungkiice/-loan-service/internal/idempotency"
+	"github.com/mungkiice/-loan-service/internal/infrastructure/agreement"
 	"github.com/mungkiice/-loan-service/internal/infrastructure/redis"
 	"github.com/mungkiice/-loan-service/internal/infrastructure/storage"
+	"github.com/mungkiice/-loan-service/internal/ledger"
+	"github.com/mungkiice/-loan-service/internal/notification"
+	"github.com/mungkiice/-loan-service/internal/outbox"
+	"github.com/mungkiice/-loan-service/internal/webhook"
 )
 
+// MaxUploadSize is the largest picture proof or signed agreement accepted, in
+// bytes
+const MaxUploadSize = 10 << 20
+
 type LoanUseCase struct {
+	loanAccess
 	loanRepo         domain.LoanRepository
 	approvalRepo     domain.ApprovalRepository
 	investmentRepo   domain.InvestmentRepository
 	disbursementRepo domain.DisbursementRepository
+	installmentRepo  domain.InstallmentRepository
+	closureRepo      domain.ClosureRepository
 	userRepo         domain.UserRepository
+	txManager        domain.TxManager
+	ledger           *ledger.Ledger
+	outbox           *outbox.Outbox
+	notifier         *notification.Publisher
+	webhooks         *webhook.Publisher
 	redisClient      redis.RedisClient
+	idempotency      *idempotency.Keeper
+	locker           *redis.Locker
 	fileStorage      storage.FileStorage
-	emailService     email.EmailService
+	agreements       agreement.Generator
+	fundingWindow    time.Duration
+	cache            *loanCache
 }
 
 func NewLoanUseCase(
 	loanRepo domain.LoanRepository,
+	borrowerRepo domain.BorrowerRepository,
 	approvalRepo domain.ApprovalRepository,
 	investmentRepo domain.InvestmentRepository,
 	disbursementRepo domain.DisbursementRepository,
+	installmentRepo domain.InstallmentRepository,
+	closureRepo domain.ClosureRepository,
 	userRepo domain.UserRepository,
+	investorRepo domain.InvestorRepository,
+	employeeRepo domain.EmployeeRepository,
+	txManager domain.TxManager,
+	ledger *ledger.Ledger,
+	outbox *outbox.Outbox,
+	notifier *notification.Publisher,
+	webhooks *webhook.Publisher,
 	redisClient redis.RedisClient,
+	idempotency *idempotency.Keeper,
+	locker *redis.Locker,
 	fileStorage storage.FileStorage,
-	emailService email.EmailService,
+	agreements agreement.Generator,
+	fundingWindow time.Duration,
+	cacheTTL time.Duration,
 ) *LoanUseCase {
 	return &LoanUseCase{
+		loanAccess: loanAccess{
+			borrowerRepo: borrowerRepo,
+			investorRepo: investorRepo,
+			employeeRepo: employeeRepo,
+		},
 		loanRepo:         loanRepo,
 		approvalRepo:     approvalRepo,
 		investmentRepo:   investmentRepo,
 		disbursementRepo: disbursementRepo,
+		installmentRepo:  installmentRepo,
+		closureRepo:      closureRepo,
 		userRepo:         userRepo,
+		txManager:        txManager,
+		ledger:           ledger,
+		outbox:           outbox,
+		notifier:         notifier,
+		webhooks:         webhooks,
 		redisClient:      redisClient,
+		idempotency:      idempotency,
+		locker:           locker,
 		fileStorage:      fileStorage,
-		emailService:     emailService,
+		agreements:       agreements,
+		fundingWindow:    fundingWindow,
+		cache:            newLoanCache(redisClient, cacheTTL),
 	}
 }
 
+// CreateLoan proposes a loan for a borrower. When the request has an
+// idempotency key, a retry returns the loan created by the first request.
 func (uc *LoanUseCase) CreateLoan(ctx context.Context, req CreateLoanRequest) (*domain.Loan, error) {
+	if err := uc.authorizeCreate(ctx, &req); err != nil {
+		return nil, err
+	}
+
+	if req.IdempotencyKey == "" {
+		return uc.createLoan(ctx, req)
+	}
+
+	fingerprint, err := idempotency.Fingerprint(req.BorrowerID, req.PrincipalAmount, req.Rate, req.ROI, req.TenorMonths, req.RepaymentType)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("create-loan:%s:%s", req.BorrowerID, req.IdempotencyKey)
+	return idempotency.Do(ctx, uc.idempotency, key, fingerprint, func() (*domain.Loan, error) {
+		return uc.createLoan(ctx, req)
+	})
+}
+
+func (uc *LoanUseCase) createLoan(ctx context.Context, req CreateLoanRequest) (*domain.Loan, error) {
+	if _, err := uc.borrowerRepo.GetByID(ctx, req.BorrowerID); err != nil {
+		return nil, fmt.Errorf("failed to get borrower %s: %w", req.BorrowerID, err)
+	}
+
 	loan := domain.NewLoan(
 		req.BorrowerID,
 		req.PrincipalAmount,
 		req.Rate,
 		req.ROI,
 	)
+	if req.TenorMonths != 0 {
+		loan.TenorMonths = req.TenorMonths
+	}
+	if req.RepaymentType != "" {
+		loan.RepaymentType = req.RepaymentType
+	}
+
+	if err := domain.ValidateRepaymentTerms(loan.TenorMonths, loan.RepaymentType); err != nil {
+		return nil, err
+	}
 
 	if err := uc.loanRepo.Create(ctx, loan); err != nil {
 		return nil, fmt.Errorf("failed to create loan: %w", err)
@@ -60,83 +153,131 @@ func (uc *LoanUseCase) CreateLoan(ctx context.Context, req CreateLoanRequest) (*
 	return loan, nil
 }
 
-func (uc *LoanUseCase) ApproveLoan(ctx context.Context, req ApproveLoanRequest) error {
-	idempotencyKey := fmt.Sprintf("approve:%s:%s", req.LoanID, req.IdempotencyKey)
-	if exists, _ := uc.redisClient.CheckIdempotencyKey(ctx, idempotencyKey); exists {
-		return fmt.Errorf("duplicate request: idempotency key already used")
+// ApproveLoan approves a proposed loan and opens it for funding. A retry
+// with the same idempotency key returns the original approval.
+func (uc *LoanUseCase) ApproveLoan(ctx context.Context, req ApproveLoanRequest) (*domain.LoanApproval, error) {
+	employee, err := uc.signedInEmployee(ctx, req.Actor)
+	if err != nil {
+		return nil, err
 	}
 
+	proof, err := digestUpload(req.PictureProof)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read picture proof: %w", err)
+	}
+
+	fingerprint, err := idempotency.Fingerprint(req.LoanID, employee.ID, req.ApprovalDate, req.PictureProofFilename, proof)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("approve:%s:%s", req.LoanID, req.IdempotencyKey)
+	return idempotency.Do(ctx, uc.idempotency, key, fingerprint, func() (*domain.LoanApproval, error) {
+		return uc.approveLoan(ctx, req, employee.ID)
+	})
+}
+
+func (uc *LoanUseCase) approveLoan(ctx context.Context, req ApproveLoanRequest, employeeID uuid.UUID) (*domain.LoanApproval, error) {
 	loan, err := uc.loanRepo.GetByID(ctx, req.LoanID)
 	if err != nil {
-		return fmt.Errorf("loan not found: %w", err)
+		return nil, fmt.Errorf("failed to get loan: %w", err)
 	}
 
 	if err := loan.CanTransitionTo(domain.StateApproved); err != nil {
-		return err
+		return nil, err
 	}
 
 	picturePath, err := uc.fileStorage.Store(ctx, req.PictureProof, req.PictureProofFilename)
 	if err != nil {
-		return fmt.Errorf("failed to store picture proof: %w", err)
+		return nil, fmt.Errorf("failed to store picture proof: %w", err)
 	}
 
 	approval := &domain.LoanApproval{
 		LoanID:       req.LoanID,
-		EmployeeID:   req.EmployeeID,
+		EmployeeID:   employeeID,
 		PictureProof: uc.fileStorage.GetURL(picturePath),
 		ApprovalDate: req.ApprovalDate,
 		CreatedAt:    time.Now(),
 	}
 
 	if err := loan.TransitionTo(domain.StateApproved); err != nil {
-		return err
+		uc.deleteFiles(ctx, []string{picturePath})
+		return nil, err
 	}
+	loan.OpenForFunding(req.ApprovalDate, uc.fundingWindow)
 
-	if err := uc.loanRepo.Update(ctx, loan); err != nil {
-		return fmt.Errorf("failed to update loan: %w", err)
-	}
+	if err := uc.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.loanRepo.Update(ctx, loan); err != nil {
+			return fmt.Errorf("failed to update loan: %w", err)
+		}
 
-	if err := uc.approvalRepo.Create(ctx, approval); err != nil {
-		return fmt.Errorf("failed to create approval: %w", err)
-	}
+		if err := uc.approvalRepo.Create(ctx, approval); err != nil {
+			return fmt.Errorf("failed to create approval: %w", err)
+		}
 
-	_ = uc.redisClient.SetIdempotencyKey(ctx, idempotencyKey, "approved", 24*time.Hour)
+		data := loanData(loan)
+		data.Date = *loan.FundingDeadline
+		if err := uc.notifyBorrower(ctx, notification.KindLoanApproved, loan, data); err != nil {
+			return err
+		}
 
-	return nil
+		return uc.publishLoanEvent(ctx, domain.EventLoanApproved, loan)
+	}); err != nil {
+		uc.deleteFiles(ctx, []string{picturePath})
+		return nil, err
+	}
+	uc.cache.invalidate(ctx, loan.ID)
+
+	return approval, nil
 }
 
-func (uc *LoanUseCase) Invest(ctx context.Context, req InvestRequest) error {
-	lockKey := fmt.Sprintf("invest:%s", req.LoanID)
-	acquired, err := uc.redisClient.AcquireLock(ctx, lockKey, 30*time.Second)
+// Invest records an investment, moving the loan to invested once it is fully
+// funded. A retry with the same idempotency key returns the original
+// investment.
+func (uc *LoanUseCase) Invest(ctx context.Context, req InvestRequest) (*domain.Investment, error) {
+	placedBy, err := uc.authorizeInvest(ctx, &req)
 	if err != nil {
-		return fmt.Errorf("failed to acquire lock: %w", err)
+		return nil, err
 	}
-	if !acquired {
-		return fmt.Errorf("could not acquire lock, please try again")
+
+	fingerprint, err := idempotency.Fingerprint(req.LoanID, req.InvestorID, req.Amount, placedBy)
+	if err != nil {
+		return nil, err
 	}
-	defer uc.redisClient.ReleaseLock(ctx, lockKey)
 
-	idempotencyKey := fmt.Sprintf("invest:%s:%s:%s", req.LoanID, req.InvestorID, req.IdempotencyKey)
-	if exists, _ := uc.redisClient.CheckIdempotencyKey(ctx, idempotencyKey); exists {
-		return fmt.Errorf("duplicate request: idempotency key already used")
+	key := fmt.Sprintf("invest:%s:%s:%s", req.LoanID, req.InvestorID, req.IdempotencyKey)
+	return idempotency.Do(ctx, uc.idempotency, key, fingerprint, func() (*domain.Investment, error) {
+		return uc.invest(ctx, req, placedBy)
+	})
+}
+
+func (uc *LoanUseCase) invest(ctx context.Context, req InvestRequest, placedBy *uuid.UUID) (*domain.Investment, error) {
+	lock, err := uc.locker.Obtain(ctx, fmt.Sprintf("invest:%s", req.LoanID))
+	if err != nil {
+		return nil, err
 	}
+	defer releaseLock(ctx, lock)
 
 	loan, err := uc.loanRepo.GetByID(ctx, req.LoanID)
 	if err != nil {
-		return fmt.Errorf("loan not found: %w", err)
+		return nil, fmt.Errorf("failed to get loan: %w", err)
 	}
 
 	if loan.State != domain.StateApproved {
-		return fmt.Errorf("loan must be in approved state to accept investments")
+		return nil, fmt.Errorf("%w: loan is %s", domain.ErrLoanNotOpen, loan.State)
+	}
+
+	if loan.IsFundingExpired(time.Now()) {
+		return nil, fmt.Errorf("%w: funding deadline for loan %s has passed", domain.ErrLoanNotOpen, loan.ID)
 	}
 
 	currentTotal, err := uc.investmentRepo.GetTotalByLoanID(ctx, req.LoanID)
 	if err != nil {
-		return fmt.Errorf("failed to get current investment total: %w", err)
+		return nil, fmt.Errorf("failed to get current investment total: %w", err)
 	}
 
 	if err := loan.ValidateInvestmentAmount(req.Amount, currentTotal); err != nil {
-		return err
+		return nil, err
 	}
 
 	investment := &domain.Investment{
@@ -144,133 +285,716 @@ func (uc *LoanUseCase) Invest(ctx context.Context, req InvestRequest) error {
 		LoanID:     req.LoanID,
 		InvestorID: req.InvestorID,
 		Amount:     req.Amount,
+		PlacedBy:   placedBy,
 		CreatedAt:  time.Now(),
 	}
 
-	if err := uc.investmentRepo.Create(ctx, investment); err != nil {
-		return fmt.Errorf("failed to create investment: %w", err)
+	newTotal := currentTotal.Add(req.Amount)
+	fullyInvested := loan.IsFullyInvested(newTotal)
+
+	// Agreement letters are generated and stored before the transaction so
+	// the loan and investment rows only ever point at files that exist
+	var investments []*domain.Investment
+	var letters *agreementFiles
+	if fullyInvested {
+		existing, err := uc.investmentRepo.GetByLoanID(ctx, req.LoanID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get investments: %w", err)
+		}
+		investments = append(existing, investment)
+
+		letters, err = uc.storeAgreements(ctx, loan, investments)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	newTotal := currentTotal + req.Amount
-	if loan.IsFullyInvested(newTotal) {
-		if err := loan.TransitionTo(domain.StateInvested); err != nil {
+	if err := uc.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.investmentRepo.Create(ctx, investment); err != nil {
+			return fmt.Errorf("failed to create investment: %w", err)
+		}
+
+		// the loan row stays locked until commit, so this total is final; any
+		// other total means an investment got in without holding the lock and
+		// the state transition decided above may be wrong
+		funded, err := uc.investmentRepo.GetTotalByLoanID(ctx, req.LoanID)
+		if err != nil {
+			return fmt.Errorf("failed to get current investment total: %w", err)
+		}
+		if funded.Cmp(newTotal) != 0 {
+			return fmt.Errorf("%w: loan %s", domain.ErrFundingChanged, req.LoanID)
+		}
+
+		if err := uc.ledger.RecordInvestment(ctx, investment); err != nil {
+			return fmt.Errorf("failed to post investment to ledger: %w", err)
+		}
+
+		if err := uc.publishInvestmentEvent(ctx, domain.EventInvestmentCreated, investment); err != nil {
 			return err
 		}
 
-		if err := uc.loanRepo.Update(ctx, loan); err != nil {
-			return fmt.Errorf("failed to update loan state: %w", err)
+		investors := uc.investorRecipients()
+		receipt := fundingData(loan, newTotal)
+		receipt.Amount = req.Amount.String()
+		if err := investors.notify(ctx, notification.KindInvestmentReceipt, req.InvestorID, receipt); err != nil {
+			return err
+		}
+
+		if !fullyInvested {
+			return uc.notifyBorrower(ctx, notification.KindFundingProgress, loan, receipt)
+		}
+
+		for _, inv := range investments {
+			if inv == investment {
+				continue
+			}
+			if err := uc.investmentRepo.SetAgreementLetterURL(ctx, inv.ID, *inv.AgreementLetterURL); err != nil {
+				return fmt.Errorf("failed to link agreement letter to investment %s: %w", inv.ID, err)
+			}
+		}
+
+		if err := loan.TransitionTo(domain.StateInvested); err != nil {
+			return err
 		}
 
-		agreementURL := uc.fileStorage.GetURL(fmt.Sprintf("agreements/%s.pdf", req.LoanID))
+		agreementURL := uc.fileStorage.GetURL(letters.borrower)
 		loan.AgreementLetterURL = &agreementURL
 		if err := uc.loanRepo.Update(ctx, loan); err != nil {
-			return fmt.Errorf("failed to update agreement letter URL: %w", err)
+			return fmt.Errorf("failed to update loan state: %w", err)
 		}
 
-		investments, err := uc.investmentRepo.GetByLoanID(ctx, req.LoanID)
-		if err == nil {
-			for _, inv := range investments {
-				investorUser, err := uc.userRepo.GetByID(ctx, inv.InvestorID)
-				if err == nil {
-					_ = uc.emailService.SendAgreementEmail(ctx, investorUser.Email, agreementURL)
-				}
+		for _, inv := range investments {
+			data := loanData(loan)
+			data.Amount = inv.Amount.String()
+			data.AgreementURL = *inv.AgreementLetterURL
+			data.AgreementPath = letters.investments[inv.ID]
+			if err := investors.notify(ctx, notification.KindAgreementReady, inv.InvestorID, data); err != nil {
+				return err
 			}
 		}
+
+		data := loanData(loan)
+		data.AgreementURL = agreementURL
+		data.AgreementPath = letters.borrower
+		if err := uc.notifyBorrower(ctx, notification.KindAgreementReady, loan, data); err != nil {
+			return err
+		}
+
+		return uc.publishLoanEvent(ctx, domain.EventLoanInvested, loan)
+	}); err != nil {
+		uc.deleteFiles(ctx, letters.paths())
+		return nil, err
 	}
+	uc.cache.invalidate(ctx, loan.ID)
+
+	return investment, nil
+}
 
-	_ = uc.redisClient.SetIdempotencyKey(ctx, idempotencyKey, "invested", 24*time.Hour)
+// agreementFiles are the storage paths of a loan's agreement letters
+type agreementFiles struct {
+	borrower    string
+	investments map[uuid.UUID]string
+}
 
-	return nil
+func (f *agreementFiles) paths() []string {
+	if f == nil {
+		return nil
+	}
+	var paths []string
+	if f.borrower != "" {
+		paths = append(paths, f.borrower)
+	}
+	for _, path := range f.investments {
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// storeAgreements renders and stores the borrower's agreement letter and one
+// letter per investment, setting each investment's AgreementLetterURL
+func (uc *LoanUseCase) storeAgreements(ctx context.Context, loan *domain.Loan, investments []*domain.Investment) (*agreementFiles, error) {
+	borrower, err := uc.borrowerRepo.GetByID(ctx, loan.BorrowerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get borrower %s: %w", loan.BorrowerID, err)
+	}
+
+	investors := make(map[uuid.UUID]*domain.Investor, len(investments))
+	for _, inv := range investments {
+		if _, ok := investors[inv.InvestorID]; ok {
+			continue
+		}
+		investor, err := uc.investorRepo.GetByID(ctx, inv.InvestorID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get investor %s: %w", inv.InvestorID, err)
+		}
+		investors[inv.InvestorID] = investor
+	}
+
+	letter, err := domain.NewAgreement(loan, borrower, investments, investors, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to build agreement: %w", err)
+	}
+
+	files := &agreementFiles{investments: make(map[uuid.UUID]string, len(investments))}
+	files.borrower, err = uc.storeAgreement(ctx, letter, fmt.Sprintf("agreement_%s.pdf", loan.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, party := range letter.Parties {
+		path, err := uc.storeAgreement(ctx, letter.For(party), fmt.Sprintf("agreement_%s_%s.pdf", loan.ID, party.Investment.ID))
+		if err != nil {
+			uc.deleteFiles(ctx, files.paths())
+			return nil, err
+		}
+		files.investments[party.Investment.ID] = path
+
+		url := uc.fileStorage.GetURL(path)
+		party.Investment.AgreementLetterURL = &url
+	}
+
+	return files, nil
+}
+
+func (uc *LoanUseCase) storeAgreement(ctx context.Context, letter *domain.Agreement, filename string) (string, error) {
+	content, err := uc.agreements.Generate(ctx, letter)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate agreement letter: %w", err)
+	}
+
+	path, err := uc.fileStorage.Store(ctx, bytes.NewReader(content), filename)
+	if err != nil {
+		return "", fmt.Errorf("failed to store agreement letter: %w", err)
+	}
+
+	return path, nil
+}
+
+// digestUpload hashes an uploaded file for the request fingerprint without
+// holding it in memory, and rewinds it so it can be stored. Files larger than
+// MaxUploadSize are rejected.
+func digestUpload(file io.ReadSeeker) (string, error) {
+	hash := sha256.New()
+	n, err := io.Copy(hash, io.LimitReader(file, MaxUploadSize+1))
+	if err != nil {
+		return "", err
+	}
+	if n > MaxUploadSize {
+		return "", fmt.Errorf("%w: file is larger than %d bytes", domain.ErrInvalidInput, MaxUploadSize)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// deleteFiles removes stored files whose records were never committed
+func (uc *LoanUseCase) deleteFiles(ctx context.Context, paths []string) {
+	for _, path := range paths {
+		_ = uc.fileStorage.Delete(ctx, path)
+	}
 }
 
-func (uc *LoanUseCase) DisburseLoan(ctx context.Context, req DisburseLoanRequest) error {
-	idempotencyKey := fmt.Sprintf("disburse:%s:%s", req.LoanID, req.IdempotencyKey)
-	if exists, _ := uc.redisClient.CheckIdempotencyKey(ctx, idempotencyKey); exists {
-		return fmt.Errorf("duplicate request: idempotency key already used")
+// releaseLock gives up a lock at the end of a request. A lock that expired
+// before the request finished is logged, since the request was no longer
+// protected by it.
+func releaseLock(ctx context.Context, lock *redis.Lock) {
+	if err := lock.Release(ctx); err != nil {
+		log.Printf("failed to release lock: %v", err)
 	}
+}
 
+// DisburseLoan disburses an invested loan and generates its repayment
+// schedule. The employee disbursing must not be the one who approved the
+// loan. A retry with the same idempotency key returns the original
+// disbursement.
+func (uc *LoanUseCase) DisburseLoan(ctx context.Context, req DisburseLoanRequest) (*domain.Disbursement, error) {
+	employee, err := uc.signedInEmployee(ctx, req.Actor)
+	if err != nil {
+		return nil, err
+	}
+
+	signedAgreement, err := digestUpload(req.SignedAgreement)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signed agreement: %w", err)
+	}
+
+	fingerprint, err := idempotency.Fingerprint(req.LoanID, employee.ID, req.DisbursementDate, req.SignedAgreementFilename, signedAgreement)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("disburse:%s:%s", req.LoanID, req.IdempotencyKey)
+	return idempotency.Do(ctx, uc.idempotency, key, fingerprint, func() (*domain.Disbursement, error) {
+		return uc.disburseLoan(ctx, req, employee.ID)
+	})
+}
+
+func (uc *LoanUseCase) disburseLoan(ctx context.Context, req DisburseLoanRequest, employeeID uuid.UUID) (*domain.Disbursement, error) {
 	loan, err := uc.loanRepo.GetByID(ctx, req.LoanID)
 	if err != nil {
-		return fmt.Errorf("loan not found: %w", err)
+		return nil, fmt.Errorf("failed to get loan: %w", err)
 	}
 
 	if err := loan.CanTransitionTo(domain.StateDisbursed); err != nil {
-		return err
+		return nil, err
+	}
+
+	approval, err := uc.approvalRepo.GetByLoanID(ctx, loan.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approval: %w", err)
+	}
+	if approval.EmployeeID == employeeID {
+		return nil, domain.ErrApproverCannotDisburse
+	}
+
+	installments, err := domain.GenerateSchedule(loan, req.DisbursementDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate repayment schedule: %w", err)
 	}
 
 	agreementPath, err := uc.fileStorage.Store(ctx, req.SignedAgreement, req.SignedAgreementFilename)
 	if err != nil {
-		return fmt.Errorf("failed to store signed agreement: %w", err)
+		return nil, fmt.Errorf("failed to store signed agreement: %w", err)
 	}
 
 	disbursement := &domain.Disbursement{
 		LoanID:             req.LoanID,
-		EmployeeID:         req.EmployeeID,
+		EmployeeID:         employeeID,
 		SignedAgreementURL: uc.fileStorage.GetURL(agreementPath),
 		DisbursementDate:   req.DisbursementDate,
 		CreatedAt:          time.Now(),
 	}
 
 	if err := loan.TransitionTo(domain.StateDisbursed); err != nil {
-		return err
+		uc.deleteFiles(ctx, []string{agreementPath})
+		return nil, err
+	}
+
+	if err := uc.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.loanRepo.Update(ctx, loan); err != nil {
+			return fmt.Errorf("failed to update loan: %w", err)
+		}
+
+		if err := uc.disbursementRepo.Create(ctx, disbursement); err != nil {
+			return fmt.Errorf("failed to create disbursement: %w", err)
+		}
+
+		if err := uc.installmentRepo.CreateBatch(ctx, installments); err != nil {
+			return fmt.Errorf("failed to create repayment schedule: %w", err)
+		}
+
+		if err := uc.ledger.RecordDisbursement(ctx, loan); err != nil {
+			return fmt.Errorf("failed to post disbursement to ledger: %w", err)
+		}
+
+		data := loanData(loan)
+		data.Date = req.DisbursementDate
+		if err := uc.notifyBorrower(ctx, notification.KindLoanDisbursed, loan, data); err != nil {
+			return err
+		}
+
+		investments, err := uc.investmentRepo.GetByLoanID(ctx, loan.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get investments: %w", err)
+		}
+		investors := uc.investorRecipients()
+		notified := make(map[uuid.UUID]bool, len(investments))
+		for _, inv := range investments {
+			if notified[inv.InvestorID] {
+				continue
+			}
+			notified[inv.InvestorID] = true
+			if err := investors.notify(ctx, notification.KindLoanDisbursed, inv.InvestorID, data); err != nil {
+				return err
+			}
+		}
+
+		return uc.publishLoanEvent(ctx, domain.EventLoanDisbursed, loan)
+	}); err != nil {
+		uc.deleteFiles(ctx, []string{agreementPath})
+		return nil, err
+	}
+	uc.cache.invalidate(ctx, loan.ID)
+
+	return disbursement, nil
+}
+
+// RejectLoan closes a proposed loan that failed validation
+func (uc *LoanUseCase) RejectLoan(ctx context.Context, req CloseLoanRequest) (*domain.LoanClosure, error) {
+	return uc.closeOnce(ctx, "reject", req, uc.rejectLoan)
+}
+
+func (uc *LoanUseCase) rejectLoan(ctx context.Context, req CloseLoanRequest, employeeID uuid.UUID) (*domain.LoanClosure, error) {
+	loan, err := uc.loanRepo.GetByID(ctx, req.LoanID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loan: %w", err)
+	}
+
+	closure, err := loan.Close(domain.StateRejected, req.Reason, req.Note, employeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.loanRepo.Update(ctx, loan); err != nil {
+			return fmt.Errorf("failed to update loan: %w", err)
+		}
+
+		if err := uc.closureRepo.Create(ctx, closure); err != nil {
+			return fmt.Errorf("failed to create closure: %w", err)
+		}
+
+		if err := uc.notifyBorrower(ctx, notification.KindLoanRejected, loan, closureData(loan, closure)); err != nil {
+			return err
+		}
+
+		return uc.publishLoanEvent(ctx, domain.EventLoanRejected, loan)
+	}); err != nil {
+		return nil, err
+	}
+	uc.cache.invalidate(ctx, loan.ID)
+
+	return closure, nil
+}
+
+// CancelLoan closes a proposed or approved loan. Investments already made in
+// an approved loan are voided and refunded from escrow in the same
+// transaction.
+func (uc *LoanUseCase) CancelLoan(ctx context.Context, req CloseLoanRequest) (*domain.LoanClosure, error) {
+	return uc.closeOnce(ctx, "cancel", req, uc.cancelLoan)
+}
+
+func (uc *LoanUseCase) cancelLoan(ctx context.Context, req CloseLoanRequest, employeeID uuid.UUID) (*domain.LoanClosure, error) {
+	// shares the invest lock so no investment lands while the loan is closing
+	lock, err := uc.locker.Obtain(ctx, fmt.Sprintf("invest:%s", req.LoanID))
+	if err != nil {
+		return nil, err
+	}
+	defer releaseLock(ctx, lock)
+
+	loan, err := uc.loanRepo.GetByID(ctx, req.LoanID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loan: %w", err)
+	}
+
+	closure, err := loan.Close(domain.StateCancelled, req.Reason, req.Note, employeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.loanRepo.Update(ctx, loan); err != nil {
+			return fmt.Errorf("failed to update loan: %w", err)
+		}
+
+		if err := uc.closureRepo.Create(ctx, closure); err != nil {
+			return fmt.Errorf("failed to create closure: %w", err)
+		}
+
+		released, err := uc.releaseInvestments(ctx, loan, closure.ClosedAt)
+		if err != nil {
+			return err
+		}
+
+		data := closureData(loan, closure)
+		if err := uc.notifyBorrower(ctx, notification.KindLoanCancelled, loan, data); err != nil {
+			return err
+		}
+		if err := uc.notifyRefunds(ctx, released, data); err != nil {
+			return err
+		}
+
+		return uc.publishLoanEvent(ctx, domain.EventLoanCancelled, loan)
+	}); err != nil {
+		return nil, err
+	}
+	uc.cache.invalidate(ctx, loan.ID)
+
+	return closure, nil
+}
+
+// closeOnce runs closeFn at most once per idempotency key; a retry returns
+// the original closure
+func (uc *LoanUseCase) closeOnce(ctx context.Context, action string, req CloseLoanRequest, closeFn func(context.Context, CloseLoanRequest, uuid.UUID) (*domain.LoanClosure, error)) (*domain.LoanClosure, error) {
+	employee, err := uc.signedInEmployee(ctx, req.Actor)
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint, err := idempotency.Fingerprint(req.LoanID, employee.ID, req.Reason, req.Note)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("%s:%s:%s", action, req.LoanID, req.IdempotencyKey)
+	return idempotency.Do(ctx, uc.idempotency, key, fingerprint, func() (*domain.LoanClosure, error) {
+		return closeFn(ctx, req, employee.ID)
+	})
+}
+
+// ExpireOverdueLoans moves every approved loan past its funding deadline to
+// expired, releasing and refunding its investments, and tells each investor.
+// A failure on one loan does not stop the others; it returns the number of
+// loans expired.
+func (uc *LoanUseCase) ExpireOverdueLoans(ctx context.Context, now time.Time) (int, error) {
+	loans, err := uc.loanRepo.GetFundingExpired(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get loans past funding deadline: %w", err)
+	}
+
+	expired := 0
+	var errs []error
+	for _, l := range loans {
+		ok, err := uc.expireLoan(ctx, l.ID, now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to expire loan %s: %w", l.ID, err))
+			continue
+		}
+		if ok {
+			expired++
+		}
+	}
+
+	return expired, errors.Join(errs...)
+}
+
+func (uc *LoanUseCase) expireLoan(ctx context.Context, loanID uuid.UUID, now time.Time) (bool, error) {
+	// shares the invest lock so a last-minute investment cannot race the expiry
+	lock, err := uc.locker.TryObtain(ctx, fmt.Sprintf("invest:%s", loanID))
+	if errors.Is(err, redis.ErrLockNotObtained) {
+		// an investment is in flight, try again on the next run
+		return false, nil
 	}
+	if err != nil {
+		return false, err
+	}
+	defer releaseLock(ctx, lock)
 
-	if err := uc.loanRepo.Update(ctx, loan); err != nil {
-		return fmt.Errorf("failed to update loan: %w", err)
+	loan, err := uc.loanRepo.GetByID(ctx, loanID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get loan: %w", err)
+	}
+
+	if !loan.IsFundingExpired(now) {
+		return false, nil
 	}
 
-	if err := uc.disbursementRepo.Create(ctx, disbursement); err != nil {
-		return fmt.Errorf("failed to create disbursement: %w", err)
+	if err := loan.TransitionTo(domain.StateExpired); err != nil {
+		return false, err
 	}
 
-	_ = uc.redisClient.SetIdempotencyKey(ctx, idempotencyKey, "disbursed", 24*time.Hour)
+	if err := uc.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.loanRepo.Update(ctx, loan); err != nil {
+			return fmt.Errorf("failed to update loan: %w", err)
+		}
 
+		released, err := uc.releaseInvestments(ctx, loan, now)
+		if err != nil {
+			return err
+		}
+
+		funded := domain.NewMoney(0, loan.PrincipalAmount.Currency)
+		for _, inv := range released {
+			funded = funded.Add(inv.Amount)
+		}
+		if err := uc.notifyBorrower(ctx, notification.KindLoanExpired, loan, fundingData(loan, funded)); err != nil {
+			return err
+		}
+		if err := uc.notifyRefunds(ctx, released, loanData(loan)); err != nil {
+			return err
+		}
+
+		return uc.publishLoanEvent(ctx, domain.EventLoanExpired, loan)
+	}); err != nil {
+		return false, err
+	}
+	uc.cache.invalidate(ctx, loan.ID)
+
+	return true, nil
+}
+
+// notifyRefunds tells each investor their voided investment was refunded
+func (uc *LoanUseCase) notifyRefunds(ctx context.Context, released []*domain.Investment, data notification.Data) error {
+	investors := uc.investorRecipients()
+	for _, inv := range released {
+		data.Amount = inv.Amount.String()
+		if err := investors.notify(ctx, notification.KindInvestmentRefunded, inv.InvestorID, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func closureData(loan *domain.Loan, closure *domain.LoanClosure) notification.Data {
+	data := loanData(loan)
+	data.Reason = string(closure.Reason)
+	data.Note = closure.Note
+	return data
+}
+
+// publishLoanEvent queues the event for internal consumers and for webhook
+// subscribers. It must run inside the caller's transaction.
+func (uc *LoanUseCase) publishLoanEvent(ctx context.Context, eventType domain.EventType, loan *domain.Loan) error {
+	return uc.publishEvent(ctx, eventType, domain.NewLoanEvent(eventType, loan, time.Now()))
+}
+
+func (uc *LoanUseCase) publishInvestmentEvent(ctx context.Context, eventType domain.EventType, investment *domain.Investment) error {
+	return uc.publishEvent(ctx, eventType, domain.NewInvestmentEvent(eventType, investment, time.Now()))
+}
+
+func (uc *LoanUseCase) publishEvent(ctx context.Context, eventType domain.EventType, event any) error {
+	if err := uc.outbox.Publish(ctx, string(eventType), event); err != nil {
+		return fmt.Errorf("failed to queue %s event: %w", eventType, err)
+	}
+	if err := uc.webhooks.Publish(ctx, eventType, event); err != nil {
+		return fmt.Errorf("failed to queue %s webhooks: %w", eventType, err)
+	}
 	return nil
 }
 
-func (uc *LoanUseCase) GetLoan(ctx context.Context, loanID uuid.UUID) (*domain.Loan, error) {
+// releaseInvestments voids the loan's active investments and refunds them
+// from escrow. It must run inside the caller's transaction.
+func (uc *LoanUseCase) releaseInvestments(ctx context.Context, loan *domain.Loan, at time.Time) ([]*domain.Investment, error) {
+	voided, err := uc.investmentRepo.VoidByLoanID(ctx, loan.ID, at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to void investments: %w", err)
+	}
+
+	if err := uc.ledger.RecordRefund(ctx, loan.ID, voided); err != nil {
+		return nil, fmt.Errorf("failed to post refund to ledger: %w", err)
+	}
+
+	for _, inv := range voided {
+		if err := uc.publishInvestmentEvent(ctx, domain.EventInvestmentRefunded, inv); err != nil {
+			return nil, err
+		}
+	}
+
+	return voided, nil
+}
+
+// GetLoan returns a loan with its approval, investments and disbursement,
+// reading through the loan cache, if actor may see it
+func (uc *LoanUseCase) GetLoan(ctx context.Context, actor domain.Actor, loanID uuid.UUID) (*domain.LoanDetails, error) {
+	details, ok := uc.cache.get(ctx, loanID)
+	if !ok {
+		var err error
+		details, err = uc.loadLoanDetails(ctx, loanID)
+		if err != nil {
+			return nil, err
+		}
+		uc.cache.set(ctx, details)
+	}
+
+	if err := uc.authorizeView(ctx, actor, details); err != nil {
+		return nil, err
+	}
+
+	return details, nil
+}
+
+func (uc *LoanUseCase) loadLoanDetails(ctx context.Context, loanID uuid.UUID) (*domain.LoanDetails, error) {
 	loan, err := uc.loanRepo.GetByID(ctx, loanID)
 	if err != nil {
 		return nil, err
 	}
+	details := &domain.LoanDetails{Loan: loan}
+
+	details.Approval, err = uc.approvalRepo.GetByLoanID(ctx, loanID)
+	if err != nil && !errors.Is(err, domain.ErrApprovalNotFound) {
+		return nil, fmt.Errorf("failed to get approval: %w", err)
+	}
 
-	cacheKey := fmt.Sprintf("loan:%s", loanID)
-	_ = uc.redisClient.SetCache(ctx, cacheKey, loanID.String(), 5*time.Minute)
+	details.Investments, err = uc.investmentRepo.GetByLoanID(ctx, loanID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get investments: %w", err)
+	}
 
-	return loan, nil
+	details.Disbursement, err = uc.disbursementRepo.GetByLoanID(ctx, loanID)
+	if err != nil && !errors.Is(err, domain.ErrDisbursementNotFound) {
+		return nil, fmt.Errorf("failed to get disbursement: %w", err)
+	}
+
+	return details, nil
+}
+
+// CacheStats reports how GetLoan lookups fared in the loan cache
+func (uc *LoanUseCase) CacheStats() CacheStats {
+	return uc.cache.stats()
+}
+
+// SearchLoans returns a page of the loans matching query that actor may see,
+// applying the default sort and page size
+func (uc *LoanUseCase) SearchLoans(ctx context.Context, actor domain.Actor, query domain.LoanQuery) (*domain.LoanPage, error) {
+	if err := query.Normalize(); err != nil {
+		return nil, err
+	}
+	if err := uc.scopeLoanQuery(ctx, actor, &query); err != nil {
+		return nil, err
+	}
+
+	return uc.loanRepo.Search(ctx, query)
 }
 
-func (uc *LoanUseCase) GetLoansByState(ctx context.Context, state domain.LoanState) ([]*domain.Loan, error) {
-	return uc.loanRepo.GetByState(ctx, state)
+// GetBorrowerLoans returns the loans of the borrower signed in as userID
+func (uc *LoanUseCase) GetBorrowerLoans(ctx context.Context, userID uuid.UUID) ([]*domain.Loan, error) {
+	borrower, err := uc.borrowerRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.loanRepo.GetByBorrowerID(ctx, borrower.ID)
 }
 
 type CreateLoanRequest struct {
+	Actor domain.Actor
+	// BorrowerID defaults to the signed-in borrower
 	BorrowerID      uuid.UUID
-	PrincipalAmount float64
-	Rate            float64
-	ROI             float64
+	PrincipalAmount domain.Money
+	Rate            domain.Percent
+	ROI             domain.Percent
+	TenorMonths     int
+	RepaymentType   domain.RepaymentType
+	// IdempotencyKey is optional
+	IdempotencyKey string
 }
 
+// ApproveLoanRequest approves a loan as the employee signed in as Actor
 type ApproveLoanRequest struct {
+	Actor                domain.Actor
 	LoanID               uuid.UUID
-	EmployeeID           uuid.UUID
-	PictureProof         interface{ Read([]byte) (int, error) }
+	PictureProof         io.ReadSeeker
 	PictureProofFilename string
 	ApprovalDate         time.Time
 	IdempotencyKey       string
 }
 
 type InvestRequest struct {
-	LoanID         uuid.UUID
+	Actor  domain.Actor
+	LoanID uuid.UUID
+	// InvestorID is who an admin invests on behalf of. Investors always
+	// invest as themselves and may leave it empty.
 	InvestorID     uuid.UUID
-	Amount         float64
+	Amount         domain.Money
+	IdempotencyKey string
+}
+
+// CloseLoanRequest rejects or cancels a loan as the employee signed in as
+// Actor
+type CloseLoanRequest struct {
+	Actor          domain.Actor
+	LoanID         uuid.UUID
+	Reason         domain.ClosureReason
+	Note           string
 	IdempotencyKey string
 }
 
+// DisburseLoanRequest disburses a loan as the employee signed in as Actor
 type DisburseLoanRequest struct {
+	Actor                   domain.Actor
 	LoanID                  uuid.UUID
-	EmployeeID              uuid.UUID
-	SignedAgreement         interface{ Read([]byte) (int, error) }
+	SignedAgreement         io.ReadSeeker
 	SignedAgreementFilename string
 	DisbursementDate        time.Time
 	IdempotencyKey          string
@@ -3,12 +3,22 @@ package usecase
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/mungkiice/-loan-service/internal/domain"
+	"github.com/mungkiice/-loan-service/internal/idempotency"
+	"github.com/mungkiice/-loan-service/internal/infrastructure/redis"
+	"github.com/mungkiice/-loan-service/internal/ledger"
+	"github.com/mungkiice/-loan-service/internal/notification"
+	"github.com/mungkiice/-loan-service/internal/outbox"
+	"github.com/mungkiice/-loan-service/internal/webhook"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -32,8 +42,24 @@ func (m *MockLoanRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain
 	return args.Get(0).(*domain.Loan), args.Error(1)
 }
 
-func (m *MockLoanRepository) GetByState(ctx context.Context, state domain.LoanState) ([]*domain.Loan, error) {
-	args := m.Called(ctx, state)
+func (m *MockLoanRepository) Search(ctx context.Context, query domain.LoanQuery) (*domain.LoanPage, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LoanPage), args.Error(1)
+}
+
+func (m *MockLoanRepository) GetByBorrowerID(ctx context.Context, borrowerID uuid.UUID) ([]*domain.Loan, error) {
+	args := m.Called(ctx, borrowerID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Loan), args.Error(1)
+}
+
+func (m *MockLoanRepository) GetFundingExpired(ctx context.Context, now time.Time) ([]*domain.Loan, error) {
+	args := m.Called(ctx, now)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -45,6 +71,36 @@ func (m *MockLoanRepository) Update(ctx context.Context, loan *domain.Loan) erro
 	return args.Error(0)
 }
 
+type MockBorrowerRepository struct {
+	mock.Mock
+}
+
+func (m *MockBorrowerRepository) Create(ctx context.Context, borrower *domain.Borrower) error {
+	args := m.Called(ctx, borrower)
+	return args.Error(0)
+}
+
+func (m *MockBorrowerRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Borrower, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Borrower), args.Error(1)
+}
+
+func (m *MockBorrowerRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*domain.Borrower, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Borrower), args.Error(1)
+}
+
+func (m *MockBorrowerRepository) Update(ctx context.Context, borrower *domain.Borrower) error {
+	args := m.Called(ctx, borrower)
+	return args.Error(0)
+}
+
 type MockApprovalRepository struct {
 	mock.Mock
 }
@@ -79,9 +135,47 @@ func (m *MockInvestmentRepository) GetByLoanID(ctx context.Context, loanID uuid.
 	return args.Get(0).([]*domain.Investment), args.Error(1)
 }
 
-func (m *MockInvestmentRepository) GetTotalByLoanID(ctx context.Context, loanID uuid.UUID) (float64, error) {
+func (m *MockInvestmentRepository) GetByInvestorID(ctx context.Context, investorID uuid.UUID) ([]*domain.Investment, error) {
+	args := m.Called(ctx, investorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Investment), args.Error(1)
+}
+
+func (m *MockInvestmentRepository) GetTotalByLoanID(ctx context.Context, loanID uuid.UUID) (domain.Money, error) {
+	args := m.Called(ctx, loanID)
+	return args.Get(0).(domain.Money), args.Error(1)
+}
+
+func (m *MockInvestmentRepository) SetAgreementLetterURL(ctx context.Context, id uuid.UUID, url string) error {
+	args := m.Called(ctx, id, url)
+	return args.Error(0)
+}
+
+func (m *MockInvestmentRepository) VoidByLoanID(ctx context.Context, loanID uuid.UUID, voidedAt time.Time) ([]*domain.Investment, error) {
+	args := m.Called(ctx, loanID, voidedAt)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Investment), args.Error(1)
+}
+
+type MockClosureRepository struct {
+	mock.Mock
+}
+
+func (m *MockClosureRepository) Create(ctx context.Context, closure *domain.LoanClosure) error {
+	args := m.Called(ctx, closure)
+	return args.Error(0)
+}
+
+func (m *MockClosureRepository) GetByLoanID(ctx context.Context, loanID uuid.UUID) (*domain.LoanClosure, error) {
 	args := m.Called(ctx, loanID)
-	return args.Get(0).(float64), args.Error(1)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LoanClosure), args.Error(1)
 }
 
 type MockDisbursementRepository struct {
@@ -101,6 +195,67 @@ func (m *MockDisbursementRepository) GetByLoanID(ctx context.Context, loanID uui
 	return args.Get(0).(*domain.Disbursement), args.Error(1)
 }
 
+type MockInstallmentRepository struct {
+	mock.Mock
+}
+
+func (m *MockInstallmentRepository) CreateBatch(ctx context.Context, installments []*domain.Installment) error {
+	args := m.Called(ctx, installments)
+	return args.Error(0)
+}
+
+func (m *MockInstallmentRepository) GetByLoanID(ctx context.Context, loanID uuid.UUID) ([]*domain.Installment, error) {
+	args := m.Called(ctx, loanID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Installment), args.Error(1)
+}
+
+func (m *MockInstallmentRepository) Update(ctx context.Context, installment *domain.Installment) error {
+	args := m.Called(ctx, installment)
+	return args.Error(0)
+}
+
+type MockRepaymentRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepaymentRepository) Create(ctx context.Context, repayment *domain.Repayment) error {
+	args := m.Called(ctx, repayment)
+	return args.Error(0)
+}
+
+func (m *MockRepaymentRepository) CreateAllocations(ctx context.Context, allocations []*domain.RepaymentAllocation) error {
+	args := m.Called(ctx, allocations)
+	return args.Error(0)
+}
+
+func (m *MockRepaymentRepository) GetByLoanID(ctx context.Context, loanID uuid.UUID) ([]*domain.Repayment, error) {
+	args := m.Called(ctx, loanID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Repayment), args.Error(1)
+}
+
+type MockPayoutRepository struct {
+	mock.Mock
+}
+
+func (m *MockPayoutRepository) CreateBatch(ctx context.Context, payouts []*domain.InvestorPayout) error {
+	args := m.Called(ctx, payouts)
+	return args.Error(0)
+}
+
+func (m *MockPayoutRepository) GetByInvestorID(ctx context.Context, investorID uuid.UUID) ([]*domain.InvestorPayout, error) {
+	args := m.Called(ctx, investorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.InvestorPayout), args.Error(1)
+}
+
 type MockUserRepository struct {
 	mock.Mock
 }
@@ -126,39 +281,204 @@ func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*dom
 	return args.Get(0).(*domain.User), args.Error(1)
 }
 
-// MockRedisClient implements redis.RedisClient interface for testing
-type MockRedisClient struct {
+type MockInvestorRepository struct {
 	mock.Mock
 }
 
-func (m *MockRedisClient) SetIdempotencyKey(ctx context.Context, key string, value string, expiration time.Duration) error {
-	args := m.Called(ctx, key, value, expiration)
+func (m *MockInvestorRepository) Create(ctx context.Context, investor *domain.Investor) error {
+	args := m.Called(ctx, investor)
 	return args.Error(0)
 }
 
-func (m *MockRedisClient) CheckIdempotencyKey(ctx context.Context, key string) (bool, error) {
-	args := m.Called(ctx, key)
-	return args.Bool(0), args.Error(1)
+func (m *MockInvestorRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Investor, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Investor), args.Error(1)
+}
+
+func (m *MockInvestorRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*domain.Investor, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Investor), args.Error(1)
+}
+
+func (m *MockInvestorRepository) GetAll(ctx context.Context) ([]*domain.Investor, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*domain.Investor), args.Error(1)
 }
 
-func (m *MockRedisClient) AcquireLock(ctx context.Context, key string, expiration time.Duration) (bool, error) {
-	args := m.Called(ctx, key, expiration)
-	return args.Bool(0), args.Error(1)
+type MockEmployeeRepository struct {
+	mock.Mock
 }
 
-func (m *MockRedisClient) ReleaseLock(ctx context.Context, key string) error {
-	args := m.Called(ctx, key)
+func (m *MockEmployeeRepository) Create(ctx context.Context, employee *domain.Employee) error {
+	args := m.Called(ctx, employee)
 	return args.Error(0)
 }
 
+func (m *MockEmployeeRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Employee, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Employee), args.Error(1)
+}
+
+func (m *MockEmployeeRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*domain.Employee, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Employee), args.Error(1)
+}
+
+func (m *MockEmployeeRepository) GetAll(ctx context.Context) ([]*domain.Employee, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*domain.Employee), args.Error(1)
+}
+
+// MockRedisClient implements redis.RedisClient interface for testing. Cache
+// entries, locks and idempotency keys are kept in memory; locks and keys are
+// taken atomically, like SET NX. Nothing expires.
+type MockRedisClient struct {
+	mock.Mock
+
+	mu              sync.Mutex
+	idempotencyKeys map[string]*idempotency.Record
+	locks           map[string]string
+	cache           map[string]string
+}
+
+func (m *MockRedisClient) ReserveIdempotencyKey(ctx context.Context, record *idempotency.Record) (bool, *idempotency.Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, ok := m.idempotencyKeys[record.Key]; ok && existing.ExpiresAt.After(record.CreatedAt) {
+		copied := *existing
+		return false, &copied, nil
+	}
+	if m.idempotencyKeys == nil {
+		m.idempotencyKeys = make(map[string]*idempotency.Record)
+	}
+	copied := *record
+	m.idempotencyKeys[record.Key] = &copied
+	return true, nil, nil
+}
+
+func (m *MockRedisClient) RenewIdempotencyKey(ctx context.Context, key, token string, expiresAt time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record, ok := m.idempotencyKeys[key]
+	if !ok || record.Status != idempotency.StatusInProgress || record.Token != token {
+		return false, nil
+	}
+	record.ExpiresAt = expiresAt
+	return true, nil
+}
+
+func (m *MockRedisClient) CompleteIdempotencyKey(ctx context.Context, key, token string, response json.RawMessage, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if record, ok := m.idempotencyKeys[key]; ok && record.Status == idempotency.StatusInProgress && record.Token == token {
+		record.Status = idempotency.StatusCompleted
+		record.Response = response
+		record.ExpiresAt = expiresAt
+	}
+	return nil
+}
+
+func (m *MockRedisClient) ReleaseIdempotencyKey(ctx context.Context, key, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if record, ok := m.idempotencyKeys[key]; ok && record.Status == idempotency.StatusInProgress && record.Token == token {
+		delete(m.idempotencyKeys, key)
+	}
+	return nil
+}
+
+func (m *MockRedisClient) AcquireLock(ctx context.Context, key, token string, expiration time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, held := m.locks[key]; held {
+		return false, nil
+	}
+	if m.locks == nil {
+		m.locks = make(map[string]string)
+	}
+	m.locks[key] = token
+	return true, nil
+}
+
+func (m *MockRedisClient) RenewLock(ctx context.Context, key, token string, expiration time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.locks[key] == token, nil
+}
+
+func (m *MockRedisClient) ReleaseLock(ctx context.Context, key, token string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.locks[key] != token {
+		return false, nil
+	}
+	delete(m.locks, key)
+	return true, nil
+}
+
+// holdLock takes the lock on key as another request would
+func (m *MockRedisClient) holdLock(key string) {
+	acquired, _ := m.AcquireLock(context.Background(), key, "other-request", time.Minute)
+	if !acquired {
+		panic("lock " + key + " is already held")
+	}
+}
+
+func (m *MockRedisClient) lockHeld(key string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, held := m.locks[key]
+	return held
+}
+
 func (m *MockRedisClient) SetCache(ctx context.Context, key string, value string, expiration time.Duration) error {
-	args := m.Called(ctx, key, value, expiration)
-	return args.Error(0)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.cache == nil {
+		m.cache = make(map[string]string)
+	}
+	m.cache[key] = value
+	return nil
 }
 
 func (m *MockRedisClient) GetCache(ctx context.Context, key string) (string, error) {
-	args := m.Called(ctx, key)
-	return args.String(0), args.Error(1)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	value, ok := m.cache[key]
+	if !ok {
+		return "", redis.ErrCacheMiss
+	}
+	return value, nil
+}
+
+func (m *MockRedisClient) DeleteCache(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.cache, key)
+	return nil
 }
 
 func (m *MockRedisClient) Close() error {
@@ -175,6 +495,14 @@ func (m *MockFileStorage) Store(ctx context.Context, file io.Reader, filename st
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockFileStorage) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	args := m.Called(ctx, path)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(io.ReadCloser), args.Error(1)
+}
+
 func (m *MockFileStorage) GetURL(path string) string {
 	args := m.Called(path)
 	return args.String(0)
@@ -185,100 +513,1670 @@ func (m *MockFileStorage) Delete(ctx context.Context, path string) error {
 	return args.Error(0)
 }
 
-type MockEmailService struct {
+type MockAgreementGenerator struct {
 	mock.Mock
 }
 
-func (m *MockEmailService) SendAgreementEmail(ctx context.Context, investorEmail string, agreementURL string) error {
-	args := m.Called(ctx, investorEmail, agreementURL)
-	return args.Error(0)
+func (m *MockAgreementGenerator) Generate(ctx context.Context, agreement *domain.Agreement) ([]byte, error) {
+	args := m.Called(ctx, agreement)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
 }
 
-func TestCreateLoan(t *testing.T) {
-	mockLoanRepo := new(MockLoanRepository)
-	mockApprovalRepo := new(MockApprovalRepository)
-	mockInvestmentRepo := new(MockInvestmentRepository)
-	mockDisbursementRepo := new(MockDisbursementRepository)
-	mockUserRepo := new(MockUserRepository)
-	mockRedis := new(MockRedisClient)
-	mockFileStorage := new(MockFileStorage)
-	mockEmail := new(MockEmailService)
+// MockTxManager implements domain.TxManager in memory. It runs fn directly and
+// records whether the unit of work was committed or rolled back.
+type MockTxManager struct {
+	Commits   int
+	Rollbacks int
+}
 
-	uc := NewLoanUseCase(
-		mockLoanRepo,
-		mockApprovalRepo,
-		mockInvestmentRepo,
-		mockDisbursementRepo,
-		mockUserRepo,
-		mockRedis,
-		mockFileStorage,
-		mockEmail,
-	)
+func (m *MockTxManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := fn(ctx); err != nil {
+		m.Rollbacks++
+		return err
+	}
+	m.Commits++
+	return nil
+}
 
-	borrowerID := uuid.New()
-	req := CreateLoanRequest{
-		BorrowerID:      borrowerID,
-		PrincipalAmount: 10000.0,
-		Rate:            5.0,
-		ROI:             3.0,
+// MockLedgerRepository implements ledger.Repository in memory, keeping every
+// posted entry so tests can inspect balances.
+type MockLedgerRepository struct {
+	Entries []*ledger.Entry
+}
+
+func (m *MockLedgerRepository) Post(ctx context.Context, entry *ledger.Entry) error {
+	m.Entries = append(m.Entries, entry)
+	return nil
+}
+
+func (m *MockLedgerRepository) GetBalance(ctx context.Context, account ledger.Account, currency domain.Currency) (*ledger.Balance, error) {
+	balance := &ledger.Balance{
+		Account: account,
+		Debits:  domain.NewMoney(0, currency),
+		Credits: domain.NewMoney(0, currency),
+	}
+	for _, entry := range m.Entries {
+		for _, line := range entry.Lines {
+			if line.Account != account || line.Amount.Currency != currency {
+				continue
+			}
+			if line.Side == ledger.Debit {
+				balance.Debits = balance.Debits.Add(line.Amount)
+			} else {
+				balance.Credits = balance.Credits.Add(line.Amount)
+			}
+		}
 	}
+	return balance, nil
+}
 
-	mockLoanRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Loan")).Return(nil)
+func (m *MockLedgerRepository) GetBalances(ctx context.Context) ([]*ledger.Balance, error) {
+	var balances []*ledger.Balance
+	seen := make(map[ledger.Account]bool)
+	for _, entry := range m.Entries {
+		for _, line := range entry.Lines {
+			if seen[line.Account] {
+				continue
+			}
+			seen[line.Account] = true
+			balance, _ := m.GetBalance(ctx, line.Account, line.Amount.Currency)
+			balances = append(balances, balance)
+		}
+	}
+	return balances, nil
+}
 
-	loan, err := uc.CreateLoan(context.Background(), req)
+// MockOutboxRepository implements outbox.Repository in memory
+type MockOutboxRepository struct {
+	Messages []*outbox.Message
+}
 
-	require.NoError(t, err)
-	assert.NotNil(t, loan)
-	assert.Equal(t, domain.StateProposed, loan.State)
-	mockLoanRepo.AssertExpectations(t)
+func (m *MockOutboxRepository) Create(ctx context.Context, messages []*outbox.Message) error {
+	m.Messages = append(m.Messages, messages...)
+	return nil
 }
 
-func TestApproveLoan(t *testing.T) {
-	mockLoanRepo := new(MockLoanRepository)
-	mockApprovalRepo := new(MockApprovalRepository)
-	mockInvestmentRepo := new(MockInvestmentRepository)
-	mockDisbursementRepo := new(MockDisbursementRepository)
-	mockUserRepo := new(MockUserRepository)
-	mockRedis := new(MockRedisClient)
-	mockFileStorage := new(MockFileStorage)
-	mockEmail := new(MockEmailService)
+func (m *MockOutboxRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*outbox.Message, error) {
+	var due []*outbox.Message
+	for _, msg := range m.Messages {
+		if len(due) == limit {
+			break
+		}
+		if msg.Status == outbox.StatusPending && !msg.NextAttemptAt.After(now) {
+			msg.NextAttemptAt = now.Add(lease)
+			due = append(due, msg)
+		}
+	}
+	return due, nil
+}
 
-	uc := NewLoanUseCase(
-		mockLoanRepo,
-		mockApprovalRepo,
-		mockInvestmentRepo,
-		mockDisbursementRepo,
-		mockUserRepo,
-		mockRedis,
-		mockFileStorage,
-		mockEmail,
-	)
+func (m *MockOutboxRepository) Update(ctx context.Context, message *outbox.Message) error {
+	return nil
+}
 
-	loanID := uuid.New()
-	employeeID := uuid.New()
-	loan := domain.NewLoan(uuid.New(), 10000.0, 5.0, 3.0)
-	loan.ID = loanID
+// Topic returns the messages published on topic
+func (m *MockOutboxRepository) Topic(topic string) []*outbox.Message {
+	var messages []*outbox.Message
+	for _, msg := range m.Messages {
+		if msg.Topic == topic {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
 
-	mockRedis.On("CheckIdempotencyKey", mock.Anything, mock.AnythingOfType("string")).Return(false, nil)
-	mockLoanRepo.On("GetByID", mock.Anything, loanID).Return(loan, nil)
-	mockFileStorage.On("Store", mock.Anything, mock.Anything, mock.AnythingOfType("string")).Return("proof.jpg", nil)
-	mockFileStorage.On("GetURL", "proof.jpg").Return("http://example.com/proof.jpg")
-	mockLoanRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Loan")).Return(nil)
-	mockApprovalRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.LoanApproval")).Return(nil)
-	mockRedis.On("SetIdempotencyKey", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.Anything).Return(nil)
+// MockNotificationPreferenceRepository implements
+// notification.PreferenceRepository in memory
+type MockNotificationPreferenceRepository struct {
+	Preferences map[uuid.UUID]notification.Preferences
+}
 
-	req := ApproveLoanRequest{
-		LoanID:               loanID,
-		EmployeeID:           employeeID,
-		PictureProof:         bytes.NewReader([]byte("fake image")),
-		PictureProofFilename: "proof.jpg",
-		ApprovalDate:         time.Now(),
-		IdempotencyKey:       "test-key",
+func (m *MockNotificationPreferenceRepository) Get(ctx context.Context, userID uuid.UUID) (notification.Preferences, error) {
+	return m.Preferences[userID], nil
+}
+
+func (m *MockNotificationPreferenceRepository) Save(ctx context.Context, userID uuid.UUID, prefs notification.Preferences) error {
+	if m.Preferences == nil {
+		m.Preferences = make(map[uuid.UUID]notification.Preferences)
 	}
+	m.Preferences[userID] = prefs
+	return nil
+}
 
-	err := uc.ApproveLoan(context.Background(), req)
+// MockWebhookSubscriptionRepository implements
+// webhook.SubscriptionRepository in memory
+type MockWebhookSubscriptionRepository struct {
+	Subscriptions []*webhook.Subscription
+}
 
-	require.NoError(t, err)
-	mockLoanRepo.AssertExpectations(t)
-	mockApprovalRepo.AssertExpectations(t)
+func (m *MockWebhookSubscriptionRepository) Create(ctx context.Context, subscription *webhook.Subscription) error {
+	m.Subscriptions = append(m.Subscriptions, subscription)
+	return nil
+}
+
+func (m *MockWebhookSubscriptionRepository) GetByID(ctx context.Context, id uuid.UUID) (*webhook.Subscription, error) {
+	for _, s := range m.Subscriptions {
+		if s.ID == id {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", webhook.ErrSubscriptionNotFound, id)
+}
+
+func (m *MockWebhookSubscriptionRepository) GetAll(ctx context.Context) ([]*webhook.Subscription, error) {
+	return m.Subscriptions, nil
+}
+
+func (m *MockWebhookSubscriptionRepository) GetActiveByEvent(ctx context.Context, eventType domain.EventType) ([]*webhook.Subscription, error) {
+	var matching []*webhook.Subscription
+	for _, s := range m.Subscriptions {
+		if s.Matches(eventType) {
+			matching = append(matching, s)
+		}
+	}
+	return matching, nil
+}
+
+func (m *MockWebhookSubscriptionRepository) Update(ctx context.Context, subscription *webhook.Subscription) error {
+	if _, err := m.GetByID(ctx, subscription.ID); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *MockWebhookSubscriptionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	for i, s := range m.Subscriptions {
+		if s.ID == id {
+			m.Subscriptions = append(m.Subscriptions[:i], m.Subscriptions[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", webhook.ErrSubscriptionNotFound, id)
+}
+
+func newTestKeeper(client redis.RedisClient) *idempotency.Keeper {
+	return idempotency.NewKeeper(redis.NewIdempotencyStore(client), 24*time.Hour, time.Minute)
+}
+
+func newTestLocker(client redis.RedisClient) *redis.Locker {
+	return redis.NewLocker(client, redis.LockOptions{TTL: 30 * time.Second, Wait: 100 * time.Millisecond, RetryInterval: time.Millisecond})
+}
+
+const testFundingWindow = 14 * 24 * time.Hour
+
+type loanUseCaseMocks struct {
+	loanRepo         *MockLoanRepository
+	borrowerRepo     *MockBorrowerRepository
+	approvalRepo     *MockApprovalRepository
+	investmentRepo   *MockInvestmentRepository
+	disbursementRepo *MockDisbursementRepository
+	installmentRepo  *MockInstallmentRepository
+	closureRepo      *MockClosureRepository
+	userRepo         *MockUserRepository
+	investorRepo     *MockInvestorRepository
+	employeeRepo     *MockEmployeeRepository
+	txManager        *MockTxManager
+	ledgerRepo       *MockLedgerRepository
+	redis            *MockRedisClient
+	fileStorage      *MockFileStorage
+	agreements       *MockAgreementGenerator
+	outboxRepo       *MockOutboxRepository
+	prefsRepo        *MockNotificationPreferenceRepository
+	webhookRepo      *MockWebhookSubscriptionRepository
+}
+
+func newTestLoanUseCase() (*LoanUseCase, *loanUseCaseMocks) {
+	m := &loanUseCaseMocks{
+		loanRepo:         new(MockLoanRepository),
+		borrowerRepo:     new(MockBorrowerRepository),
+		approvalRepo:     new(MockApprovalRepository),
+		investmentRepo:   new(MockInvestmentRepository),
+		disbursementRepo: new(MockDisbursementRepository),
+		installmentRepo:  new(MockInstallmentRepository),
+		closureRepo:      new(MockClosureRepository),
+		userRepo:         new(MockUserRepository),
+		txManager:        new(MockTxManager),
+		ledgerRepo:       new(MockLedgerRepository),
+		redis:            new(MockRedisClient),
+		investorRepo:     new(MockInvestorRepository),
+		employeeRepo:     new(MockEmployeeRepository),
+		fileStorage:      new(MockFileStorage),
+		agreements:       new(MockAgreementGenerator),
+		outboxRepo:       new(MockOutboxRepository),
+		prefsRepo:        new(MockNotificationPreferenceRepository),
+		webhookRepo:      new(MockWebhookSubscriptionRepository),
+	}
+
+	uc := NewLoanUseCase(
+		m.loanRepo,
+		m.borrowerRepo,
+		m.approvalRepo,
+		m.investmentRepo,
+		m.disbursementRepo,
+		m.installmentRepo,
+		m.closureRepo,
+		m.userRepo,
+		m.investorRepo,
+		m.employeeRepo,
+		m.txManager,
+		ledger.New(m.ledgerRepo),
+		outbox.New(m.outboxRepo),
+		notification.NewPublisher(m.prefsRepo, outbox.New(m.outboxRepo)),
+		webhook.NewPublisher(m.webhookRepo, outbox.New(m.outboxRepo)),
+		m.redis,
+		newTestKeeper(m.redis),
+		newTestLocker(m.redis),
+		m.fileStorage,
+		m.agreements,
+		testFundingWindow,
+		time.Minute,
+	)
+
+	return uc, m
+}
+
+// expectFunding sets up a loan's total investment before and after an
+// investment of amount
+func (m *loanUseCaseMocks) expectFunding(loanID uuid.UUID, before, amount domain.Money) {
+	m.investmentRepo.On("GetTotalByLoanID", mock.Anything, loanID).Return(before, nil).Once()
+	m.investmentRepo.On("GetTotalByLoanID", mock.Anything, loanID).Return(before.Add(amount), nil).Once()
+}
+
+// expectBorrower sets up a borrower with a login for loan
+func (m *loanUseCaseMocks) expectBorrower(loan *domain.Loan, email string) *domain.Borrower {
+	user := &domain.User{ID: uuid.New(), Email: email, UserType: domain.UserTypeBorrower}
+	borrower := &domain.Borrower{ID: loan.BorrowerID, UserID: &user.ID, Name: "Borrower"}
+	m.borrowerRepo.On("GetByID", mock.Anything, loan.BorrowerID).Return(borrower, nil)
+	m.userRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	return borrower
+}
+
+// expectInvestor sets up an investor and their login
+func (m *loanUseCaseMocks) expectInvestor(investorID uuid.UUID, name, email string) *domain.Investor {
+	user := &domain.User{ID: uuid.New(), Email: email, UserType: domain.UserTypeInvestor}
+	investor := &domain.Investor{ID: investorID, UserID: user.ID, Name: name}
+	m.investorRepo.On("GetByID", mock.Anything, investorID).Return(investor, nil)
+	m.userRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	return investor
+}
+
+// employee is a signed-in employee with role
+func employee(role domain.EmployeeRole) domain.Actor {
+	return domain.Actor{UserID: uuid.New(), UserType: domain.UserTypeEmployee, Role: role}
+}
+
+// signedInBorrower sets up the borrower profile of a signed-in borrower
+func (m *loanUseCaseMocks) signedInBorrower() (domain.Actor, *domain.Borrower) {
+	actor := domain.Actor{UserID: uuid.New(), UserType: domain.UserTypeBorrower}
+	borrower := &domain.Borrower{ID: uuid.New(), UserID: &actor.UserID, Name: "Borrower"}
+	m.borrowerRepo.On("GetByUserID", mock.Anything, actor.UserID).Return(borrower, nil)
+	return actor, borrower
+}
+
+// investorActor signs in as the investor with investorID
+func (m *loanUseCaseMocks) investorActor(investorID uuid.UUID) domain.Actor {
+	actor := domain.Actor{UserID: uuid.New(), UserType: domain.UserTypeInvestor}
+	m.investorRepo.On("GetByUserID", mock.Anything, actor.UserID).Return(&domain.Investor{ID: investorID, UserID: actor.UserID}, nil)
+	return actor
+}
+
+// signedInEmployee sets up the employee profile of a signed-in employee
+func (m *loanUseCaseMocks) signedInEmployee(role domain.EmployeeRole) (domain.Actor, *domain.Employee) {
+	actor := employee(role)
+	emp := &domain.Employee{ID: uuid.New(), UserID: actor.UserID, Name: "Employee", Role: role}
+	m.employeeRepo.On("GetByUserID", mock.Anything, actor.UserID).Return(emp, nil)
+	return actor, emp
+}
+
+// expectApproval sets up the approval of loanID by the employee approverID
+func (m *loanUseCaseMocks) expectApproval(loanID, approverID uuid.UUID) {
+	m.approvalRepo.On("GetByLoanID", mock.Anything, loanID).Return(&domain.LoanApproval{LoanID: loanID, EmployeeID: approverID}, nil)
+}
+
+// signedInInvestor sets up the investor profile of a signed-in investor
+func (m *loanUseCaseMocks) signedInInvestor() (domain.Actor, *domain.Investor) {
+	actor := domain.Actor{UserID: uuid.New(), UserType: domain.UserTypeInvestor}
+	investor := &domain.Investor{ID: uuid.New(), UserID: actor.UserID, Name: "Investor"}
+	m.investorRepo.On("GetByUserID", mock.Anything, actor.UserID).Return(investor, nil)
+	return actor, investor
+}
+
+// emails returns the notifications queued for delivery by email
+func (m *loanUseCaseMocks) emails(t *testing.T) []notification.Notification {
+	var notifications []notification.Notification
+	for _, msg := range m.outboxRepo.Topic(notification.Topic(notification.ChannelEmail)) {
+		var n notification.Notification
+		require.NoError(t, msg.Decode(&n))
+		notifications = append(notifications, n)
+	}
+	return notifications
+}
+
+// subscribe registers an active webhook subscription filtering on events
+func (m *loanUseCaseMocks) subscribe(t *testing.T, events ...domain.EventType) *webhook.Subscription {
+	subscription, err := webhook.NewSubscription("https://partner.example.com/hooks", "", events)
+	require.NoError(t, err)
+	require.NoError(t, m.webhookRepo.Create(context.Background(), subscription))
+	return subscription
+}
+
+// webhooks returns the webhook deliveries queued by the use case
+func (m *loanUseCaseMocks) webhooks(t *testing.T) []webhook.Delivery {
+	var deliveries []webhook.Delivery
+	for _, msg := range m.outboxRepo.Topic(webhook.TopicDelivery) {
+		var d webhook.Delivery
+		require.NoError(t, msg.Decode(&d))
+		deliveries = append(deliveries, d)
+	}
+	return deliveries
+}
+
+// emailKinds returns "<kind> <email>" for each queued email notification
+func (m *loanUseCaseMocks) emailKinds(t *testing.T) []string {
+	var kinds []string
+	for _, n := range m.emails(t) {
+		kinds = append(kinds, string(n.Kind)+" "+n.Recipient.Email)
+	}
+	return kinds
+}
+
+func TestCreateLoan(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	borrowerID := uuid.New()
+	req := CreateLoanRequest{
+		Actor:           employee(domain.RoleFieldOfficer),
+		BorrowerID:      borrowerID,
+		PrincipalAmount: domain.NewMoney(1000000, domain.CurrencyIDR),
+		Rate:            500,
+		ROI:             300,
+	}
+
+	m.borrowerRepo.On("GetByID", mock.Anything, borrowerID).Return(&domain.Borrower{ID: borrowerID}, nil)
+	m.loanRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Loan")).Return(nil)
+
+	loan, err := uc.CreateLoan(context.Background(), req)
+
+	require.NoError(t, err)
+	assert.NotNil(t, loan)
+	assert.Equal(t, domain.StateProposed, loan.State)
+	m.loanRepo.AssertExpectations(t)
+}
+
+func TestCreateLoan_RejectsUnknownBorrower(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	borrowerID := uuid.New()
+	m.borrowerRepo.On("GetByID", mock.Anything, borrowerID).Return(nil, domain.ErrBorrowerNotFound)
+
+	_, err := uc.CreateLoan(context.Background(), CreateLoanRequest{
+		Actor:           employee(domain.RoleFieldOfficer),
+		BorrowerID:      borrowerID,
+		PrincipalAmount: domain.NewMoney(1000000, domain.CurrencyIDR),
+		Rate:            500,
+		ROI:             300,
+	})
+
+	assert.ErrorIs(t, err, domain.ErrBorrowerNotFound)
+	m.loanRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCreateLoan_BorrowerCreatesOwnLoan(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	actor, borrower := m.signedInBorrower()
+	m.borrowerRepo.On("GetByID", mock.Anything, borrower.ID).Return(borrower, nil)
+	m.loanRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Loan")).Return(nil)
+
+	loan, err := uc.CreateLoan(context.Background(), CreateLoanRequest{
+		Actor:           actor,
+		PrincipalAmount: domain.NewMoney(1000000, domain.CurrencyIDR),
+		Rate:            500,
+		ROI:             300,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, borrower.ID, loan.BorrowerID)
+}
+
+func TestCreateLoan_RejectsBorrowerCreatingForAnother(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	actor, _ := m.signedInBorrower()
+
+	_, err := uc.CreateLoan(context.Background(), CreateLoanRequest{
+		Actor:           actor,
+		BorrowerID:      uuid.New(),
+		PrincipalAmount: domain.NewMoney(1000000, domain.CurrencyIDR),
+		Rate:            500,
+		ROI:             300,
+	})
+
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+	m.loanRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCreateLoan_RejectsInvestor(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	_, err := uc.CreateLoan(context.Background(), CreateLoanRequest{
+		Actor:           domain.Actor{UserID: uuid.New(), UserType: domain.UserTypeInvestor},
+		BorrowerID:      uuid.New(),
+		PrincipalAmount: domain.NewMoney(1000000, domain.CurrencyIDR),
+		Rate:            500,
+		ROI:             300,
+	})
+
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+	m.loanRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestGetBorrowerLoans(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	userID := uuid.New()
+	borrower := &domain.Borrower{ID: uuid.New(), UserID: &userID}
+	loans := []*domain.Loan{domain.NewLoan(borrower.ID, domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)}
+	m.borrowerRepo.On("GetByUserID", mock.Anything, userID).Return(borrower, nil)
+	m.loanRepo.On("GetByBorrowerID", mock.Anything, borrower.ID).Return(loans, nil)
+
+	got, err := uc.GetBorrowerLoans(context.Background(), userID)
+
+	require.NoError(t, err)
+	assert.Equal(t, loans, got)
+}
+
+func TestApproveLoan(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	loanID := uuid.New()
+	validator, profile := m.signedInEmployee(domain.RoleFieldValidator)
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+	loan.ID = loanID
+
+	m.loanRepo.On("GetByID", mock.Anything, loanID).Return(loan, nil)
+	m.fileStorage.On("Store", mock.Anything, mock.Anything, mock.AnythingOfType("string")).Return("proof.jpg", nil)
+	m.fileStorage.On("GetURL", "proof.jpg").Return("http://example.com/proof.jpg")
+	m.loanRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Loan")).Return(nil)
+	m.approvalRepo.On("Create", mock.Anything, mock.MatchedBy(func(approval *domain.LoanApproval) bool {
+		return approval.EmployeeID == profile.ID
+	})).Return(nil)
+	m.expectBorrower(loan, "borrower@example.com")
+
+	approvalDate := time.Now()
+	req := ApproveLoanRequest{
+		Actor:                validator,
+		LoanID:               loanID,
+		PictureProof:         bytes.NewReader([]byte("fake image")),
+		PictureProofFilename: "proof.jpg",
+		ApprovalDate:         approvalDate,
+		IdempotencyKey:       "test-key",
+	}
+
+	_, err := uc.ApproveLoan(context.Background(), req)
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.StateApproved, loan.State)
+	require.NotNil(t, loan.FundingDeadline)
+	assert.Equal(t, approvalDate.Add(testFundingWindow), *loan.FundingDeadline)
+	assert.Equal(t, 1, m.txManager.Commits)
+	m.loanRepo.AssertExpectations(t)
+	m.approvalRepo.AssertExpectations(t)
+
+	emails := m.emails(t)
+	require.Len(t, emails, 1)
+	assert.Equal(t, notification.KindLoanApproved, emails[0].Kind)
+	assert.Equal(t, "borrower@example.com", emails[0].Recipient.Email)
+	assert.True(t, loan.FundingDeadline.Equal(emails[0].Data.Date))
+	assert.Len(t, m.outboxRepo.Topic(notification.Topic(notification.ChannelInApp)), 1)
+	assert.Len(t, m.outboxRepo.Topic(string(domain.EventLoanApproved)), 1)
+}
+
+func TestApproveLoan_QueuesWebhookForSubscribers(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+	subscription := m.subscribe(t, domain.EventLoanApproved, domain.EventLoanDisbursed)
+	m.subscribe(t, domain.EventLoanInvested)
+
+	m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	m.fileStorage.On("Store", mock.Anything, mock.Anything, mock.AnythingOfType("string")).Return("proof.jpg", nil)
+	m.fileStorage.On("GetURL", "proof.jpg").Return("http://example.com/proof.jpg")
+	m.loanRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Loan")).Return(nil)
+	m.approvalRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.LoanApproval")).Return(nil)
+	m.expectBorrower(loan, "borrower@example.com")
+
+	validator, _ := m.signedInEmployee(domain.RoleFieldValidator)
+	_, err := uc.ApproveLoan(context.Background(), ApproveLoanRequest{
+		Actor:                validator,
+		LoanID:               loan.ID,
+		PictureProof:         bytes.NewReader([]byte("fake image")),
+		PictureProofFilename: "proof.jpg",
+		ApprovalDate:         time.Now(),
+		IdempotencyKey:       "test-key",
+	})
+
+	require.NoError(t, err)
+	deliveries := m.webhooks(t)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, subscription.ID, deliveries[0].SubscriptionID)
+	assert.Equal(t, domain.EventLoanApproved, deliveries[0].Event)
+
+	var event struct {
+		Type domain.EventType `json:"type"`
+		Data domain.LoanEvent `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(deliveries[0].Body, &event))
+	assert.Equal(t, domain.EventLoanApproved, event.Type)
+	assert.Equal(t, loan.ID, event.Data.LoanID)
+}
+
+func TestApproveLoan_RollsBackWhenApprovalFails(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+
+	m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	m.fileStorage.On("Store", mock.Anything, mock.Anything, mock.AnythingOfType("string")).Return("proof.jpg", nil)
+	m.fileStorage.On("GetURL", "proof.jpg").Return("http://example.com/proof.jpg")
+	m.loanRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Loan")).Return(nil)
+	m.approvalRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.LoanApproval")).Return(errors.New("insert failed"))
+	m.fileStorage.On("Delete", mock.Anything, "proof.jpg").Return(nil)
+
+	validator, _ := m.signedInEmployee(domain.RoleFieldValidator)
+	_, err := uc.ApproveLoan(context.Background(), ApproveLoanRequest{
+		Actor:                validator,
+		LoanID:               loan.ID,
+		PictureProof:         bytes.NewReader([]byte("fake image")),
+		PictureProofFilename: "proof.jpg",
+		ApprovalDate:         time.Now(),
+		IdempotencyKey:       "test-key",
+	})
+
+	require.Error(t, err)
+	assert.Equal(t, 1, m.txManager.Rollbacks)
+	assert.Equal(t, 0, m.txManager.Commits)
+	m.fileStorage.AssertCalled(t, "Delete", mock.Anything, "proof.jpg")
+	// the key is released so the client can retry
+	assert.Empty(t, m.redis.idempotencyKeys)
+}
+
+func TestApproveLoan_ConflictsWhenLoanChangedMeanwhile(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+
+	m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	m.fileStorage.On("Store", mock.Anything, mock.Anything, mock.AnythingOfType("string")).Return("proof.jpg", nil)
+	m.fileStorage.On("GetURL", "proof.jpg").Return("http://example.com/proof.jpg")
+	m.loanRepo.On("Update", mock.Anything, loan).
+		Return(fmt.Errorf("%w: loan %s at version 1", domain.ErrLoanVersionConflict, loan.ID))
+	m.fileStorage.On("Delete", mock.Anything, "proof.jpg").Return(nil)
+
+	validator, _ := m.signedInEmployee(domain.RoleFieldValidator)
+	_, err := uc.ApproveLoan(context.Background(), ApproveLoanRequest{
+		Actor:                validator,
+		LoanID:               loan.ID,
+		PictureProof:         bytes.NewReader([]byte("fake image")),
+		PictureProofFilename: "proof.jpg",
+		ApprovalDate:         time.Now(),
+		IdempotencyKey:       "test-key",
+	})
+
+	assert.ErrorIs(t, err, domain.ErrLoanVersionConflict)
+	m.approvalRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	assert.Equal(t, 1, m.txManager.Rollbacks)
+	m.fileStorage.AssertCalled(t, "Delete", mock.Anything, "proof.jpg")
+	assert.Empty(t, m.outboxRepo.Topic(string(domain.EventLoanApproved)))
+	assert.Empty(t, m.redis.idempotencyKeys)
+}
+
+func TestApproveLoan_RejectsOversizedPictureProof(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	validator, _ := m.signedInEmployee(domain.RoleFieldValidator)
+	_, err := uc.ApproveLoan(context.Background(), ApproveLoanRequest{
+		Actor:                validator,
+		LoanID:               uuid.New(),
+		PictureProof:         bytes.NewReader(make([]byte, MaxUploadSize+1)),
+		PictureProofFilename: "proof.jpg",
+		ApprovalDate:         time.Now(),
+		IdempotencyKey:       "test-key",
+	})
+
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	m.fileStorage.AssertNotCalled(t, "Store", mock.Anything, mock.Anything, mock.Anything)
+	assert.Empty(t, m.redis.idempotencyKeys)
+}
+
+func TestApproveLoan_ReplaysOriginalApproval(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+	m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	m.fileStorage.On("Store", mock.Anything, mock.Anything, mock.AnythingOfType("string")).Return("proof.jpg", nil)
+	m.fileStorage.On("GetURL", "proof.jpg").Return("http://example.com/proof.jpg")
+	m.loanRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Loan")).Return(nil)
+	m.approvalRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.LoanApproval")).Return(nil).Once()
+	m.expectBorrower(loan, "borrower@example.com")
+
+	validator, _ := m.signedInEmployee(domain.RoleFieldValidator)
+	approvalDate := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	request := func(proof string) ApproveLoanRequest {
+		return ApproveLoanRequest{
+			Actor:                validator,
+			LoanID:               loan.ID,
+			PictureProof:         bytes.NewReader([]byte(proof)),
+			PictureProofFilename: "proof.jpg",
+			ApprovalDate:         approvalDate,
+			IdempotencyKey:       "approve-key",
+		}
+	}
+
+	first, err := uc.ApproveLoan(context.Background(), request("fake image"))
+	require.NoError(t, err)
+
+	// a client retrying after a timeout gets the original approval back
+	replayed, err := uc.ApproveLoan(context.Background(), request("fake image"))
+	require.NoError(t, err)
+	assert.Equal(t, first.PictureProof, replayed.PictureProof)
+	assert.True(t, first.ApprovalDate.Equal(replayed.ApprovalDate))
+	assert.Equal(t, 1, m.txManager.Commits)
+	m.approvalRepo.AssertExpectations(t)
+
+	_, err = uc.ApproveLoan(context.Background(), request("another image"))
+	assert.ErrorIs(t, err, idempotency.ErrKeyReused)
+}
+
+func TestApproveLoan_ConcurrentRequestsWithSameKeyApproveOnce(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+	release := make(chan struct{})
+
+	m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	m.fileStorage.On("Store", mock.Anything, mock.Anything, mock.AnythingOfType("string")).
+		Run(func(mock.Arguments) { <-release }).
+		Return("proof.jpg", nil).Once()
+	m.fileStorage.On("GetURL", "proof.jpg").Return("http://example.com/proof.jpg")
+	m.loanRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Loan")).Return(nil)
+	m.approvalRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.LoanApproval")).Return(nil).Once()
+	m.expectBorrower(loan, "borrower@example.com")
+
+	validator, _ := m.signedInEmployee(domain.RoleFieldValidator)
+	req := ApproveLoanRequest{
+		Actor:                validator,
+		LoanID:               loan.ID,
+		PictureProofFilename: "proof.jpg",
+		ApprovalDate:         time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC),
+		IdempotencyKey:       "approve-key",
+	}
+	approve := func() error {
+		r := req
+		r.PictureProof = bytes.NewReader([]byte("fake image"))
+		_, err := uc.ApproveLoan(context.Background(), r)
+		return err
+	}
+
+	errs := raceRequests(t, 10, release, approve)
+
+	assertOneWinner(t, errs)
+	assert.Equal(t, 1, m.txManager.Commits)
+	m.approvalRepo.AssertExpectations(t)
+
+	// once the winner has finished, a retry gets its result
+	require.NoError(t, approve())
+	assert.Equal(t, 1, m.txManager.Commits)
+}
+
+func TestInvest_CompletesWhenTotalMatchesPrincipalToTheCent(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+	loan.State = domain.StateApproved
+	investorID := uuid.New()
+
+	m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	m.expectFunding(loan.ID, domain.NewMoney(666667, domain.CurrencyIDR), domain.NewMoney(333333, domain.CurrencyIDR))
+	m.investmentRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Investment")).Return(nil)
+	m.investmentRepo.On("GetByLoanID", mock.Anything, loan.ID).Return([]*domain.Investment{}, nil)
+	m.expectBorrower(loan, "borrower@example.com")
+	m.expectInvestor(investorID, "Investor", "investor@example.com")
+	m.agreements.On("Generate", mock.Anything, mock.AnythingOfType("*domain.Agreement")).Return([]byte("%PDF"), nil)
+	m.fileStorage.On("Store", mock.Anything, mock.Anything, mock.AnythingOfType("string")).Return("agreement.pdf", nil)
+	m.fileStorage.On("GetURL", "agreement.pdf").Return("http://example.com/agreement.pdf")
+	m.loanRepo.On("Update", mock.Anything, loan).Return(nil)
+
+	_, err := uc.Invest(context.Background(), InvestRequest{
+		Actor:          m.investorActor(investorID),
+		LoanID:         loan.ID,
+		Amount:         domain.NewMoney(333333, domain.CurrencyIDR),
+		IdempotencyKey: "invest-key",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.StateInvested, loan.State)
+	assert.Equal(t, 1, m.txManager.Commits)
+	m.investmentRepo.AssertExpectations(t)
+	m.loanRepo.AssertExpectations(t)
+
+	require.Len(t, m.ledgerRepo.Entries, 1)
+	escrow, err := m.ledgerRepo.GetBalance(context.Background(), ledger.LoanEscrow(loan.ID), domain.CurrencyIDR)
+	require.NoError(t, err)
+	assert.Equal(t, domain.NewMoney(333333, domain.CurrencyIDR), escrow.Net())
+}
+
+func TestInvest_RollsBackWhenFundingChangedMeanwhile(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+	loan.State = domain.StateApproved
+	investorID := uuid.New()
+	m.expectInvestor(investorID, "Investor", "investor@example.com")
+	m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	m.investmentRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Investment")).Return(nil)
+	// another investment of 250000 got in between the check and the insert,
+	// so this one no longer leaves the loan short of its principal
+	m.expectFunding(loan.ID, domain.NewMoney(500000, domain.CurrencyIDR), domain.NewMoney(500000, domain.CurrencyIDR))
+
+	_, err := uc.Invest(context.Background(), InvestRequest{
+		Actor:          m.investorActor(investorID),
+		LoanID:         loan.ID,
+		Amount:         domain.NewMoney(250000, domain.CurrencyIDR),
+		IdempotencyKey: "invest-key",
+	})
+
+	assert.ErrorIs(t, err, domain.ErrFundingChanged)
+	assert.Equal(t, domain.StateApproved, loan.State)
+	assert.Equal(t, 0, m.txManager.Commits)
+	assert.Empty(t, m.ledgerRepo.Entries)
+}
+
+func TestInvest_NotifiesFundingProgress(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+	loan.State = domain.StateApproved
+	investorID := uuid.New()
+	borrower := m.expectBorrower(loan, "borrower@example.com")
+	m.expectInvestor(investorID, "Investor", "investor@example.com")
+	m.prefsRepo.Save(context.Background(), *borrower.UserID, notification.Preferences{notification.ChannelEmail: false})
+
+	m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	m.expectFunding(loan.ID, domain.NewMoney(500000, domain.CurrencyIDR), domain.NewMoney(250000, domain.CurrencyIDR))
+	m.investmentRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Investment")).Return(nil)
+
+	_, err := uc.Invest(context.Background(), InvestRequest{
+		Actor:          m.investorActor(investorID),
+		LoanID:         loan.ID,
+		Amount:         domain.NewMoney(250000, domain.CurrencyIDR),
+		IdempotencyKey: "invest-key",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.StateApproved, loan.State)
+
+	// the borrower turned email off, so their progress update is in-app only
+	assert.Equal(t, []string{"investment_receipt investor@example.com"}, m.emailKinds(t))
+	inApp := m.outboxRepo.Topic(notification.Topic(notification.ChannelInApp))
+	require.Len(t, inApp, 2)
+	var progress notification.Notification
+	require.NoError(t, inApp[1].Decode(&progress))
+	assert.Equal(t, notification.KindFundingProgress, progress.Kind)
+	assert.Equal(t, *borrower.UserID, progress.Recipient.UserID)
+	assert.Equal(t, "7500.00", progress.Data.Funded)
+	assert.Equal(t, "75.00", progress.Data.FundedPercent)
+	assert.Equal(t, "2500.00", progress.Data.Remaining)
+	assert.Empty(t, m.outboxRepo.Topic(string(domain.EventLoanInvested)))
+	assert.Len(t, m.outboxRepo.Topic(string(domain.EventInvestmentCreated)), 1)
+}
+
+func TestInvest_AdminInvestsOnInvestorsBehalf(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+	loan.State = domain.StateApproved
+	investorID := uuid.New()
+	actor, admin := m.signedInEmployee(domain.RoleAdmin)
+	m.expectBorrower(loan, "borrower@example.com")
+	m.expectInvestor(investorID, "Investor", "investor@example.com")
+
+	m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	m.expectFunding(loan.ID, domain.NewMoney(500000, domain.CurrencyIDR), domain.NewMoney(250000, domain.CurrencyIDR))
+	m.investmentRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Investment")).Return(nil)
+
+	investment, err := uc.Invest(context.Background(), InvestRequest{
+		Actor:          actor,
+		LoanID:         loan.ID,
+		InvestorID:     investorID,
+		Amount:         domain.NewMoney(250000, domain.CurrencyIDR),
+		IdempotencyKey: "invest-key",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, investorID, investment.InvestorID)
+	require.NotNil(t, investment.PlacedBy)
+	assert.Equal(t, admin.ID, *investment.PlacedBy)
+
+	created := m.outboxRepo.Topic(string(domain.EventInvestmentCreated))
+	require.Len(t, created, 1)
+	var event domain.InvestmentEvent
+	require.NoError(t, created[0].Decode(&event))
+	require.NotNil(t, event.PlacedBy)
+	assert.Equal(t, admin.ID, *event.PlacedBy)
+}
+
+func TestInvest_RejectsInvestingAsAnotherInvestor(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	_, err := uc.Invest(context.Background(), InvestRequest{
+		Actor:          m.investorActor(uuid.New()),
+		LoanID:         uuid.New(),
+		InvestorID:     uuid.New(),
+		Amount:         domain.NewMoney(250000, domain.CurrencyIDR),
+		IdempotencyKey: "invest-key",
+	})
+
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+	m.investmentRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestInvest_RejectsOnBehalfInvestingByNonAdmin(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	_, err := uc.Invest(context.Background(), InvestRequest{
+		Actor:          employee(domain.RoleFieldOfficer),
+		LoanID:         uuid.New(),
+		InvestorID:     uuid.New(),
+		Amount:         domain.NewMoney(250000, domain.CurrencyIDR),
+		IdempotencyKey: "invest-key",
+	})
+
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+	m.investmentRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestInvest_RequiresInvestorForOnBehalfInvesting(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+	actor, _ := m.signedInEmployee(domain.RoleAdmin)
+
+	_, err := uc.Invest(context.Background(), InvestRequest{
+		Actor:          actor,
+		LoanID:         uuid.New(),
+		Amount:         domain.NewMoney(250000, domain.CurrencyIDR),
+		IdempotencyKey: "invest-key",
+	})
+
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, domain.ErrForbidden)
+	m.investmentRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestInvest_WaitsForLockHeldByAnotherRequest(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+	loan.State = domain.StateApproved
+	investorID := uuid.New()
+	m.expectBorrower(loan, "borrower@example.com")
+	m.expectInvestor(investorID, "Investor", "investor@example.com")
+	m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	m.expectFunding(loan.ID, domain.NewMoney(0, domain.CurrencyIDR), domain.NewMoney(250000, domain.CurrencyIDR))
+	m.investmentRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Investment")).Return(nil)
+
+	lockKey := fmt.Sprintf("invest:%s", loan.ID)
+	m.redis.holdLock(lockKey)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		m.redis.ReleaseLock(context.Background(), lockKey, "other-request")
+	}()
+
+	_, err := uc.Invest(context.Background(), InvestRequest{
+		Actor:          m.investorActor(investorID),
+		LoanID:         loan.ID,
+		Amount:         domain.NewMoney(250000, domain.CurrencyIDR),
+		IdempotencyKey: "invest-key",
+	})
+
+	require.NoError(t, err)
+	m.investmentRepo.AssertNumberOfCalls(t, "Create", 1)
+	assert.False(t, m.redis.lockHeld(lockKey))
+}
+
+func TestInvest_GivesUpWhileLoanStaysLocked(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+	loan.State = domain.StateApproved
+	lockKey := fmt.Sprintf("invest:%s", loan.ID)
+	m.redis.holdLock(lockKey)
+
+	_, err := uc.Invest(context.Background(), InvestRequest{
+		Actor:          m.investorActor(uuid.New()),
+		LoanID:         loan.ID,
+		Amount:         domain.NewMoney(250000, domain.CurrencyIDR),
+		IdempotencyKey: "invest-key",
+	})
+
+	assert.ErrorIs(t, err, redis.ErrLockNotObtained)
+	m.loanRepo.AssertNotCalled(t, "GetByID", mock.Anything, loan.ID)
+	// the lock still belongs to the request holding it
+	assert.True(t, m.redis.lockHeld(lockKey))
+}
+
+func TestInvest_IssuesAgreementLetterPerInvestment(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+	loan.State = domain.StateApproved
+	earlier := &domain.Investment{ID: uuid.New(), LoanID: loan.ID, InvestorID: uuid.New(), Amount: domain.NewMoney(600000, domain.CurrencyIDR)}
+	investorID := uuid.New()
+
+	var letters []*domain.Agreement
+	m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	m.expectFunding(loan.ID, earlier.Amount, domain.NewMoney(400000, domain.CurrencyIDR))
+	m.investmentRepo.On("GetByLoanID", mock.Anything, loan.ID).Return([]*domain.Investment{earlier}, nil)
+	m.expectBorrower(loan, "borrower@example.com")
+	m.expectInvestor(earlier.InvestorID, "Earlier", "earlier@example.com")
+	m.expectInvestor(investorID, "Later", "later@example.com")
+	m.agreements.On("Generate", mock.Anything, mock.AnythingOfType("*domain.Agreement")).
+		Run(func(args mock.Arguments) { letters = append(letters, args.Get(1).(*domain.Agreement)) }).
+		Return([]byte("%PDF"), nil)
+	m.fileStorage.On("Store", mock.Anything, mock.Anything, fmt.Sprintf("agreement_%s.pdf", loan.ID)).Return("borrower.pdf", nil)
+	m.fileStorage.On("Store", mock.Anything, mock.Anything, fmt.Sprintf("agreement_%s_%s.pdf", loan.ID, earlier.ID)).Return("earlier.pdf", nil)
+	m.fileStorage.On("Store", mock.Anything, mock.Anything, mock.AnythingOfType("string")).Return("later.pdf", nil)
+	m.fileStorage.On("GetURL", "borrower.pdf").Return("http://example.com/borrower.pdf")
+	m.fileStorage.On("GetURL", "earlier.pdf").Return("http://example.com/earlier.pdf")
+	m.fileStorage.On("GetURL", "later.pdf").Return("http://example.com/later.pdf")
+	m.investmentRepo.On("Create", mock.Anything, mock.MatchedBy(func(inv *domain.Investment) bool {
+		return inv.AgreementLetterURL != nil && *inv.AgreementLetterURL == "http://example.com/later.pdf"
+	})).Return(nil)
+	m.investmentRepo.On("SetAgreementLetterURL", mock.Anything, earlier.ID, "http://example.com/earlier.pdf").Return(nil)
+	m.loanRepo.On("Update", mock.Anything, loan).Return(nil)
+
+	_, err := uc.Invest(context.Background(), InvestRequest{
+		Actor:          m.investorActor(investorID),
+		LoanID:         loan.ID,
+		Amount:         domain.NewMoney(400000, domain.CurrencyIDR),
+		IdempotencyKey: "invest-key",
+	})
+
+	require.NoError(t, err)
+	require.NotNil(t, loan.AgreementLetterURL)
+	assert.Equal(t, "http://example.com/borrower.pdf", *loan.AgreementLetterURL)
+	m.investmentRepo.AssertExpectations(t)
+
+	assert.Equal(t, []string{
+		"investment_receipt later@example.com",
+		"agreement_ready earlier@example.com",
+		"agreement_ready later@example.com",
+		"agreement_ready borrower@example.com",
+	}, m.emailKinds(t))
+	emails := m.emails(t)
+	assert.Equal(t, "4000.00", emails[0].Data.Amount)
+	assert.Equal(t, "100.00", emails[0].Data.FundedPercent)
+	assert.Equal(t, "http://example.com/earlier.pdf", emails[1].Data.AgreementURL)
+	assert.Equal(t, "earlier.pdf", emails[1].Data.AgreementPath)
+	assert.Equal(t, "later.pdf", emails[2].Data.AgreementPath)
+	assert.Equal(t, "borrower.pdf", emails[3].Data.AgreementPath)
+	assert.Len(t, m.outboxRepo.Topic(string(domain.EventLoanInvested)), 1)
+
+	// the borrower's copy lists both investors, each investor's copy only their own terms
+	require.Len(t, letters, 3)
+	assert.Nil(t, letters[0].Recipient)
+	assert.Len(t, letters[0].Parties, 2)
+	assert.Equal(t, earlier, letters[1].Recipient.Investment)
+	assert.Equal(t, domain.Percent(6000), letters[1].Recipient.Share)
+	assert.Equal(t, "Later", letters[2].Recipient.Investor.Name)
+	assert.Equal(t, domain.Percent(4000), letters[2].Recipient.Share)
+}
+
+func TestInvest_DeletesAgreementWhenTransactionFails(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+	loan.State = domain.StateApproved
+	investorID := uuid.New()
+
+	m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	m.investmentRepo.On("GetTotalByLoanID", mock.Anything, loan.ID).Return(domain.NewMoney(0, domain.CurrencyIDR), nil)
+	m.investmentRepo.On("GetByLoanID", mock.Anything, loan.ID).Return([]*domain.Investment{}, nil)
+	m.borrowerRepo.On("GetByID", mock.Anything, loan.BorrowerID).Return(&domain.Borrower{ID: loan.BorrowerID, Name: "Borrower"}, nil)
+	m.investorRepo.On("GetByID", mock.Anything, investorID).Return(&domain.Investor{ID: investorID, Name: "Investor"}, nil)
+	m.agreements.On("Generate", mock.Anything, mock.AnythingOfType("*domain.Agreement")).Return([]byte("%PDF"), nil)
+	m.fileStorage.On("Store", mock.Anything, mock.Anything, mock.AnythingOfType("string")).Return("agreement.pdf", nil)
+	m.fileStorage.On("GetURL", "agreement.pdf").Return("http://example.com/agreement.pdf")
+	m.fileStorage.On("Delete", mock.Anything, "agreement.pdf").Return(nil)
+	m.investmentRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Investment")).Return(errors.New("db down"))
+
+	_, err := uc.Invest(context.Background(), InvestRequest{
+		Actor:          m.investorActor(investorID),
+		LoanID:         loan.ID,
+		Amount:         domain.NewMoney(1000000, domain.CurrencyIDR),
+		IdempotencyKey: "invest-key",
+	})
+
+	require.Error(t, err)
+	assert.Equal(t, 1, m.txManager.Rollbacks)
+	m.fileStorage.AssertCalled(t, "Delete", mock.Anything, "agreement.pdf")
+	assert.Empty(t, m.outboxRepo.Messages)
+}
+
+func TestDisburseLoan_GeneratesRepaymentSchedule(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 1200, 800)
+	loan.State = domain.StateInvested
+	loan.TenorMonths = 6
+	disbursedAt := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	m.fileStorage.On("Store", mock.Anything, mock.Anything, mock.AnythingOfType("string")).Return("signed.pdf", nil)
+	m.fileStorage.On("GetURL", "signed.pdf").Return("http://example.com/signed.pdf")
+	m.loanRepo.On("Update", mock.Anything, loan).Return(nil)
+	m.disbursementRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Disbursement")).Return(nil)
+	m.installmentRepo.On("CreateBatch", mock.Anything, mock.MatchedBy(func(installments []*domain.Installment) bool {
+		return len(installments) == 6 && installments[0].DueDate.Equal(disbursedAt.AddDate(0, 1, 0))
+	})).Return(nil)
+	investorID := uuid.New()
+	m.investmentRepo.On("GetByLoanID", mock.Anything, loan.ID).Return([]*domain.Investment{
+		{ID: uuid.New(), LoanID: loan.ID, InvestorID: investorID, Amount: domain.NewMoney(600000, domain.CurrencyIDR)},
+		{ID: uuid.New(), LoanID: loan.ID, InvestorID: investorID, Amount: domain.NewMoney(400000, domain.CurrencyIDR)},
+	}, nil)
+	m.expectBorrower(loan, "borrower@example.com")
+	m.expectInvestor(investorID, "Investor", "investor@example.com")
+	m.expectApproval(loan.ID, uuid.New())
+	officer, profile := m.signedInEmployee(domain.RoleFieldOfficer)
+
+	disbursement, err := uc.DisburseLoan(context.Background(), DisburseLoanRequest{
+		Actor:                   officer,
+		LoanID:                  loan.ID,
+		SignedAgreement:         bytes.NewReader([]byte("signed")),
+		SignedAgreementFilename: "signed.pdf",
+		DisbursementDate:        disbursedAt,
+		IdempotencyKey:          "disburse-key",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, profile.ID, disbursement.EmployeeID)
+	assert.Equal(t, domain.StateDisbursed, loan.State)
+	assert.Equal(t, 1, m.txManager.Commits)
+	m.installmentRepo.AssertExpectations(t)
+
+	require.Len(t, m.ledgerRepo.Entries, 1)
+	borrower, err := m.ledgerRepo.GetBalance(context.Background(), ledger.Borrower(loan.BorrowerID), domain.CurrencyIDR)
+	require.NoError(t, err)
+	assert.Equal(t, loan.PrincipalAmount, borrower.Net())
+
+	// an investor with two investments is told once
+	assert.Equal(t, []string{"loan_disbursed borrower@example.com", "loan_disbursed investor@example.com"}, m.emailKinds(t))
+	assert.Len(t, m.outboxRepo.Topic(string(domain.EventLoanDisbursed)), 1)
+}
+
+func TestDisburseLoan_ConcurrentRequestsWithSameKeyDisburseOnce(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 1200, 800)
+	loan.State = domain.StateInvested
+	release := make(chan struct{})
+
+	m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	m.fileStorage.On("Store", mock.Anything, mock.Anything, mock.AnythingOfType("string")).
+		Run(func(mock.Arguments) { <-release }).
+		Return("signed.pdf", nil).Once()
+	m.fileStorage.On("GetURL", "signed.pdf").Return("http://example.com/signed.pdf")
+	m.loanRepo.On("Update", mock.Anything, loan).Return(nil)
+	m.disbursementRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Disbursement")).Return(nil).Once()
+	m.installmentRepo.On("CreateBatch", mock.Anything, mock.Anything).Return(nil).Once()
+	m.investmentRepo.On("GetByLoanID", mock.Anything, loan.ID).Return([]*domain.Investment{}, nil)
+	m.expectBorrower(loan, "borrower@example.com")
+
+	m.expectApproval(loan.ID, uuid.New())
+
+	officer, _ := m.signedInEmployee(domain.RoleFieldOfficer)
+	disbursedAt := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	errs := raceRequests(t, 10, release, func() error {
+		_, err := uc.DisburseLoan(context.Background(), DisburseLoanRequest{
+			Actor:                   officer,
+			LoanID:                  loan.ID,
+			SignedAgreement:         bytes.NewReader([]byte("signed")),
+			SignedAgreementFilename: "signed.pdf",
+			DisbursementDate:        disbursedAt,
+			IdempotencyKey:          "disburse-key",
+		})
+		return err
+	})
+
+	assertOneWinner(t, errs)
+	assert.Equal(t, 1, m.txManager.Commits)
+	m.disbursementRepo.AssertExpectations(t)
+	require.Len(t, m.ledgerRepo.Entries, 1)
+}
+
+func TestDisburseLoan_DeletesSignedAgreementWhenLoanChangedMeanwhile(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 1200, 800)
+	loan.State = domain.StateInvested
+
+	m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	m.fileStorage.On("Store", mock.Anything, mock.Anything, mock.AnythingOfType("string")).Return("signed.pdf", nil)
+	m.fileStorage.On("GetURL", "signed.pdf").Return("http://example.com/signed.pdf")
+	m.fileStorage.On("Delete", mock.Anything, "signed.pdf").Return(nil)
+	m.loanRepo.On("Update", mock.Anything, loan).
+		Return(fmt.Errorf("%w: loan %s at version 1", domain.ErrLoanVersionConflict, loan.ID))
+	m.expectApproval(loan.ID, uuid.New())
+	officer, _ := m.signedInEmployee(domain.RoleFieldOfficer)
+
+	_, err := uc.DisburseLoan(context.Background(), DisburseLoanRequest{
+		Actor:                   officer,
+		LoanID:                  loan.ID,
+		SignedAgreement:         bytes.NewReader([]byte("signed")),
+		SignedAgreementFilename: "signed.pdf",
+		DisbursementDate:        time.Now(),
+		IdempotencyKey:          "disburse-key",
+	})
+
+	assert.ErrorIs(t, err, domain.ErrLoanVersionConflict)
+	assert.Equal(t, 1, m.txManager.Rollbacks)
+	m.fileStorage.AssertCalled(t, "Delete", mock.Anything, "signed.pdf")
+	m.disbursementRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestDisburseLoan_RejectsApproverDisbursing(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 1200, 800)
+	loan.State = domain.StateInvested
+	actor, approver := m.signedInEmployee(domain.RoleFieldOfficer)
+	m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	m.expectApproval(loan.ID, approver.ID)
+
+	_, err := uc.DisburseLoan(context.Background(), DisburseLoanRequest{
+		Actor:                   actor,
+		LoanID:                  loan.ID,
+		SignedAgreement:         bytes.NewReader([]byte("signed")),
+		SignedAgreementFilename: "signed.pdf",
+		DisbursementDate:        time.Now(),
+		IdempotencyKey:          "disburse-key",
+	})
+
+	assert.ErrorIs(t, err, domain.ErrApproverCannotDisburse)
+	assert.Equal(t, domain.StateInvested, loan.State)
+	m.fileStorage.AssertNotCalled(t, "Store", mock.Anything, mock.Anything, mock.Anything)
+	m.disbursementRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestRejectLoan_RejectsUserWithoutEmployeeProfile(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	actor := employee(domain.RoleFieldValidator)
+	m.employeeRepo.On("GetByUserID", mock.Anything, actor.UserID).
+		Return(nil, fmt.Errorf("%w: no rows", domain.ErrEmployeeNotFound))
+
+	_, err := uc.RejectLoan(context.Background(), CloseLoanRequest{
+		Actor:          actor,
+		LoanID:         uuid.New(),
+		Reason:         domain.ReasonCreditRisk,
+		IdempotencyKey: "reject-key",
+	})
+
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+	m.closureRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestDisburseLoan_RejectsUserWithoutEmployeeProfile(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	actor := employee(domain.RoleFieldOfficer)
+	m.employeeRepo.On("GetByUserID", mock.Anything, actor.UserID).
+		Return(nil, fmt.Errorf("%w: no rows", domain.ErrEmployeeNotFound))
+
+	_, err := uc.DisburseLoan(context.Background(), DisburseLoanRequest{
+		Actor:                   actor,
+		LoanID:                  uuid.New(),
+		SignedAgreement:         bytes.NewReader([]byte("signed")),
+		SignedAgreementFilename: "signed.pdf",
+		DisbursementDate:        time.Now(),
+		IdempotencyKey:          "disburse-key",
+	})
+
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+	m.loanRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+}
+
+// raceRequests runs n copies of request at once. The request that reserves
+// the idempotency key blocks on release, which is closed once every other
+// request has returned, so all of them overlap with it.
+func raceRequests(t *testing.T, n int, release chan struct{}, request func() error) []error {
+	t.Helper()
+
+	results := make(chan error, n)
+	for i := 0; i < n; i++ {
+		go func() { results <- request() }()
+	}
+
+	var errs []error
+	for len(errs) < n {
+		select {
+		case err := <-results:
+			errs = append(errs, err)
+			if len(errs) == n-1 {
+				close(release)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("only %d of %d requests returned; more than one is running", len(errs), n)
+		}
+	}
+
+	return errs
+}
+
+// assertOneWinner checks that exactly one request succeeded and every other
+// one was turned away while it was in progress
+func assertOneWinner(t *testing.T, errs []error) {
+	t.Helper()
+
+	succeeded := 0
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assert.ErrorIs(t, err, idempotency.ErrInProgress)
+	}
+	assert.Equal(t, 1, succeeded)
+}
+
+func TestRejectLoan(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+	validator, profile := m.signedInEmployee(domain.RoleFieldValidator)
+
+	m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	m.loanRepo.On("Update", mock.Anything, loan).Return(nil)
+	m.closureRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.LoanClosure")).Return(nil)
+	m.expectBorrower(loan, "borrower@example.com")
+
+	closure, err := uc.RejectLoan(context.Background(), CloseLoanRequest{
+		Actor:          validator,
+		LoanID:         loan.ID,
+		Reason:         domain.ReasonIncompleteDocuments,
+		IdempotencyKey: "reject-key",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.StateRejected, loan.State)
+	assert.Equal(t, domain.ReasonIncompleteDocuments, closure.Reason)
+	assert.Equal(t, profile.ID, closure.EmployeeID)
+	assert.Equal(t, 1, m.txManager.Commits)
+
+	emails := m.emails(t)
+	require.Len(t, emails, 1)
+	assert.Equal(t, notification.KindLoanRejected, emails[0].Kind)
+	assert.Equal(t, string(domain.ReasonIncompleteDocuments), emails[0].Data.Reason)
+	assert.Len(t, m.outboxRepo.Topic(string(domain.EventLoanRejected)), 1)
+}
+
+func TestRejectLoan_RejectsApprovedLoan(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+	loan.State = domain.StateApproved
+
+	m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+
+	validator, _ := m.signedInEmployee(domain.RoleFieldValidator)
+	_, err := uc.RejectLoan(context.Background(), CloseLoanRequest{
+		Actor:          validator,
+		LoanID:         loan.ID,
+		Reason:         domain.ReasonCreditRisk,
+		IdempotencyKey: "reject-key",
+	})
+
+	var transitionErr *domain.StateTransitionError
+	assert.ErrorAs(t, err, &transitionErr)
+	m.closureRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCancelLoan_VoidsAndRefundsInvestments(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+	loan.State = domain.StateApproved
+	investments := []*domain.Investment{
+		{ID: uuid.New(), LoanID: loan.ID, InvestorID: uuid.New(), Amount: domain.NewMoney(300000, domain.CurrencyIDR)},
+		{ID: uuid.New(), LoanID: loan.ID, InvestorID: uuid.New(), Amount: domain.NewMoney(200000, domain.CurrencyIDR)},
+	}
+	l := ledger.New(m.ledgerRepo)
+	for _, inv := range investments {
+		require.NoError(t, l.RecordInvestment(context.Background(), inv))
+	}
+
+	m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	m.loanRepo.On("Update", mock.Anything, loan).Return(nil)
+	m.closureRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.LoanClosure")).Return(nil)
+	m.investmentRepo.On("VoidByLoanID", mock.Anything, loan.ID, mock.AnythingOfType("time.Time")).Return(investments, nil)
+	m.expectBorrower(loan, "borrower@example.com")
+	m.expectInvestor(investments[0].InvestorID, "First", "first@example.com")
+	m.expectInvestor(investments[1].InvestorID, "Second", "second@example.com")
+
+	admin, _ := m.signedInEmployee(domain.RoleAdmin)
+	_, err := uc.CancelLoan(context.Background(), CloseLoanRequest{
+		Actor:          admin,
+		LoanID:         loan.ID,
+		Reason:         domain.ReasonFundingShortfall,
+		IdempotencyKey: "cancel-key",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.StateCancelled, loan.State)
+	m.investmentRepo.AssertExpectations(t)
+
+	escrow, err := m.ledgerRepo.GetBalance(context.Background(), ledger.LoanEscrow(loan.ID), domain.CurrencyIDR)
+	require.NoError(t, err)
+	assert.True(t, escrow.Net().IsZero())
+	wallet, err := m.ledgerRepo.GetBalance(context.Background(), ledger.InvestorWallet(investments[0].InvestorID), domain.CurrencyIDR)
+	require.NoError(t, err)
+	assert.True(t, wallet.Net().IsZero())
+
+	assert.Equal(t, []string{
+		"loan_cancelled borrower@example.com",
+		"investment_refunded first@example.com",
+		"investment_refunded second@example.com",
+	}, m.emailKinds(t))
+	emails := m.emails(t)
+	assert.Equal(t, "2000.00", emails[2].Data.Amount)
+	assert.Equal(t, string(domain.ReasonFundingShortfall), emails[2].Data.Reason)
+	assert.Len(t, m.outboxRepo.Topic(string(domain.EventLoanCancelled)), 1)
+	assert.Len(t, m.outboxRepo.Topic(string(domain.EventInvestmentRefunded)), 2)
+}
+
+func TestCancelLoan_RejectsInvestedLoan(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+	loan.State = domain.StateInvested
+
+	m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+
+	admin, _ := m.signedInEmployee(domain.RoleAdmin)
+	_, err := uc.CancelLoan(context.Background(), CloseLoanRequest{
+		Actor:          admin,
+		LoanID:         loan.ID,
+		Reason:         domain.ReasonBorrowerWithdrew,
+		IdempotencyKey: "cancel-key",
+	})
+
+	require.Error(t, err)
+	m.investmentRepo.AssertNotCalled(t, "VoidByLoanID", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestInvest_RejectsAfterFundingDeadline(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+	loan.State = domain.StateApproved
+	loan.OpenForFunding(time.Now().Add(-2*testFundingWindow), testFundingWindow)
+
+	m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+
+	_, err := uc.Invest(context.Background(), InvestRequest{
+		Actor:          m.investorActor(uuid.New()),
+		LoanID:         loan.ID,
+		Amount:         domain.NewMoney(100000, domain.CurrencyIDR),
+		IdempotencyKey: "invest-key",
+	})
+
+	require.Error(t, err)
+	m.investmentRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestExpireOverdueLoans(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	now := time.Now()
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+	loan.State = domain.StateApproved
+	loan.OpenForFunding(now.Add(-testFundingWindow-time.Hour), testFundingWindow)
+	investment := &domain.Investment{ID: uuid.New(), LoanID: loan.ID, InvestorID: uuid.New(), Amount: domain.NewMoney(400000, domain.CurrencyIDR)}
+	require.NoError(t, ledger.New(m.ledgerRepo).RecordInvestment(context.Background(), investment))
+
+	m.loanRepo.On("GetFundingExpired", mock.Anything, now).Return([]*domain.Loan{loan}, nil)
+	m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	m.loanRepo.On("Update", mock.Anything, loan).Return(nil)
+	m.investmentRepo.On("VoidByLoanID", mock.Anything, loan.ID, now).Return([]*domain.Investment{investment}, nil)
+	m.expectBorrower(loan, "borrower@example.com")
+	m.expectInvestor(investment.InvestorID, "Investor", "investor@example.com")
+
+	expired, err := uc.ExpireOverdueLoans(context.Background(), now)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+	assert.Equal(t, domain.StateExpired, loan.State)
+	assert.Equal(t, 1, m.txManager.Commits)
+
+	assert.Equal(t, []string{"loan_expired borrower@example.com", "investment_refunded investor@example.com"}, m.emailKinds(t))
+	emails := m.emails(t)
+	assert.Equal(t, "4000.00", emails[0].Data.Funded)
+	assert.Equal(t, "6000.00", emails[0].Data.Remaining)
+	assert.Equal(t, "4000.00", emails[1].Data.Amount)
+	assert.Empty(t, emails[1].Data.Reason)
+	assert.Len(t, m.outboxRepo.Topic(string(domain.EventLoanExpired)), 1)
+
+	escrow, err := m.ledgerRepo.GetBalance(context.Background(), ledger.LoanEscrow(loan.ID), domain.CurrencyIDR)
+	require.NoError(t, err)
+	assert.True(t, escrow.Net().IsZero())
+}
+
+func TestExpireOverdueLoans_SkipsLoanFundedMeanwhile(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	now := time.Now()
+	stale := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+	stale.State = domain.StateApproved
+	stale.OpenForFunding(now.Add(-testFundingWindow-time.Hour), testFundingWindow)
+	current := *stale
+	current.State = domain.StateInvested
+
+	m.loanRepo.On("GetFundingExpired", mock.Anything, now).Return([]*domain.Loan{stale}, nil)
+	m.loanRepo.On("GetByID", mock.Anything, stale.ID).Return(&current, nil)
+
+	expired, err := uc.ExpireOverdueLoans(context.Background(), now)
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, expired)
+	m.loanRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestGetLoan_ReadsThroughCache(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+	loan.State = domain.StateApproved
+	approval := &domain.LoanApproval{LoanID: loan.ID, EmployeeID: uuid.New()}
+	investment := &domain.Investment{ID: uuid.New(), LoanID: loan.ID, InvestorID: uuid.New(), Amount: domain.NewMoney(400000, domain.CurrencyIDR)}
+
+	m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil).Once()
+	m.approvalRepo.On("GetByLoanID", mock.Anything, loan.ID).Return(approval, nil).Once()
+	m.investmentRepo.On("GetByLoanID", mock.Anything, loan.ID).Return([]*domain.Investment{investment}, nil).Once()
+	m.disbursementRepo.On("GetByLoanID", mock.Anything, loan.ID).Return(nil, domain.ErrDisbursementNotFound).Once()
+
+	first, err := uc.GetLoan(context.Background(), employee(domain.RoleAdmin), loan.ID)
+	require.NoError(t, err)
+	second, err := uc.GetLoan(context.Background(), employee(domain.RoleAdmin), loan.ID)
+	require.NoError(t, err)
+
+	assert.Same(t, loan, first.Loan)
+	assert.Equal(t, loan.ID, second.Loan.ID)
+	assert.Equal(t, domain.StateApproved, second.Loan.State)
+	assert.Equal(t, approval.EmployeeID, second.Approval.EmployeeID)
+	require.Len(t, second.Investments, 1)
+	assert.Equal(t, investment.Amount, second.Investments[0].Amount)
+	assert.Nil(t, second.Disbursement)
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 1}, uc.CacheStats())
+	m.loanRepo.AssertExpectations(t)
+}
+
+func TestGetLoan_ReloadsAfterTransition(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+
+	m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	m.loanRepo.On("Update", mock.Anything, loan).Return(nil)
+	m.closureRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.LoanClosure")).Return(nil)
+	m.approvalRepo.On("GetByLoanID", mock.Anything, loan.ID).Return(nil, domain.ErrApprovalNotFound)
+	m.investmentRepo.On("GetByLoanID", mock.Anything, loan.ID).Return([]*domain.Investment{}, nil)
+	m.disbursementRepo.On("GetByLoanID", mock.Anything, loan.ID).Return(nil, domain.ErrDisbursementNotFound)
+	m.expectBorrower(loan, "borrower@example.com")
+
+	_, err := uc.GetLoan(context.Background(), employee(domain.RoleAdmin), loan.ID)
+	require.NoError(t, err)
+	assert.Contains(t, m.redis.cache, loanCacheKey(loan.ID))
+
+	validator, _ := m.signedInEmployee(domain.RoleFieldValidator)
+	_, err = uc.RejectLoan(context.Background(), CloseLoanRequest{
+		Actor:          validator,
+		LoanID:         loan.ID,
+		Reason:         domain.ReasonIncompleteDocuments,
+		IdempotencyKey: "reject-key",
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, m.redis.cache, loanCacheKey(loan.ID))
+
+	details, err := uc.GetLoan(context.Background(), employee(domain.RoleAdmin), loan.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.StateRejected, details.Loan.State)
+	assert.Equal(t, CacheStats{Misses: 2}, uc.CacheStats())
+}
+
+func TestSearchLoans_AppliesDefaultSortAndPageSize(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	page := &domain.LoanPage{Loans: []*domain.Loan{}}
+	m.loanRepo.On("Search", mock.Anything, domain.LoanQuery{
+		States: []domain.LoanState{domain.StateApproved},
+		Sort:   domain.SortByCreatedAt,
+		Order:  domain.SortDesc,
+		Limit:  domain.DefaultLoanPageSize,
+	}).Return(page, nil)
+
+	res, err := uc.SearchLoans(context.Background(), employee(domain.RoleAdmin), domain.LoanQuery{States: []domain.LoanState{domain.StateApproved}})
+
+	require.NoError(t, err)
+	assert.Same(t, page, res)
+}
+
+func TestSearchLoans_RejectsInvalidQuery(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	_, err := uc.SearchLoans(context.Background(), employee(domain.RoleAdmin), domain.LoanQuery{Limit: domain.MaxLoanPageSize + 1})
+
+	assert.ErrorIs(t, err, domain.ErrInvalidLoanQuery)
+	m.loanRepo.AssertNotCalled(t, "Search", mock.Anything, mock.Anything)
+}
+
+func TestSearchLoans_BorrowerSeesOnlyOwnLoans(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	actor, borrower := m.signedInBorrower()
+	m.loanRepo.On("Search", mock.Anything, mock.MatchedBy(func(q domain.LoanQuery) bool {
+		return q.BorrowerID != nil && *q.BorrowerID == borrower.ID && q.OpenOrInvestedBy == nil
+	})).Return(&domain.LoanPage{}, nil)
+
+	_, err := uc.SearchLoans(context.Background(), actor, domain.LoanQuery{})
+	require.NoError(t, err)
+
+	other := uuid.New()
+	_, err = uc.SearchLoans(context.Background(), actor, domain.LoanQuery{BorrowerID: &other})
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+	m.loanRepo.AssertNumberOfCalls(t, "Search", 1)
+}
+
+func TestSearchLoans_InvestorSeesOpenAndInvestedLoans(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	actor, investor := m.signedInInvestor()
+	m.loanRepo.On("Search", mock.Anything, mock.MatchedBy(func(q domain.LoanQuery) bool {
+		return q.OpenOrInvestedBy != nil && *q.OpenOrInvestedBy == investor.ID
+	})).Return(&domain.LoanPage{}, nil)
+
+	_, err := uc.SearchLoans(context.Background(), actor, domain.LoanQuery{})
+
+	require.NoError(t, err)
+	m.loanRepo.AssertExpectations(t)
+}
+
+func TestSearchLoans_RejectsUserWithoutProfile(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	actor := domain.Actor{UserID: uuid.New(), UserType: domain.UserTypeBorrower}
+	m.borrowerRepo.On("GetByUserID", mock.Anything, actor.UserID).Return(nil, domain.ErrBorrowerNotFound)
+
+	_, err := uc.SearchLoans(context.Background(), actor, domain.LoanQuery{})
+
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+	m.loanRepo.AssertNotCalled(t, "Search", mock.Anything, mock.Anything)
+}
+
+func TestGetLoan_Permissions(t *testing.T) {
+	uc, m := newTestLoanUseCase()
+
+	borrowerActor, borrower := m.signedInBorrower()
+	investorActor, investor := m.signedInInvestor()
+	otherInvestorActor, _ := m.signedInInvestor()
+
+	expectLoan := func(borrowerID uuid.UUID, state domain.LoanState, investments ...*domain.Investment) *domain.Loan {
+		loan := domain.NewLoan(borrowerID, domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+		loan.State = state
+		for _, inv := range investments {
+			inv.LoanID = loan.ID
+		}
+		m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+		m.approvalRepo.On("GetByLoanID", mock.Anything, loan.ID).Return(nil, domain.ErrApprovalNotFound)
+		m.investmentRepo.On("GetByLoanID", mock.Anything, loan.ID).Return(investments, nil)
+		m.disbursementRepo.On("GetByLoanID", mock.Anything, loan.ID).Return(nil, domain.ErrDisbursementNotFound)
+		return loan
+	}
+
+	ownProposed := expectLoan(borrower.ID, domain.StateProposed)
+	othersProposed := expectLoan(uuid.New(), domain.StateProposed)
+	othersApproved := expectLoan(uuid.New(), domain.StateApproved)
+	othersInvested := expectLoan(uuid.New(), domain.StateInvested, &domain.Investment{
+		ID:         uuid.New(),
+		InvestorID: investor.ID,
+		Amount:     domain.NewMoney(1000000, domain.CurrencyIDR),
+	})
+
+	tests := []struct {
+		name    string
+		actor   domain.Actor
+		loan    *domain.Loan
+		allowed bool
+	}{
+		{"employee sees any loan", employee(domain.RoleFieldValidator), othersProposed, true},
+		{"borrower sees own loan", borrowerActor, ownProposed, true},
+		{"borrower cannot see another's loan", borrowerActor, othersApproved, false},
+		{"investor sees approved loan", investorActor, othersApproved, true},
+		{"investor cannot see proposed loan", investorActor, othersProposed, false},
+		{"investor sees loan they invested in", investorActor, othersInvested, true},
+		{"investor cannot see loan others funded", otherInvestorActor, othersInvested, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details, err := uc.GetLoan(context.Background(), tt.actor, tt.loan.ID)
+			if tt.allowed {
+				require.NoError(t, err)
+				assert.Equal(t, tt.loan.ID, details.Loan.ID)
+			} else {
+				assert.ErrorIs(t, err, domain.ErrForbidden)
+			}
+		})
+	}
 }
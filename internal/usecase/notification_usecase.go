@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mungkiice/-loan-service/internal/notification"
+)
+
+// inboxLimit caps how many in-app notifications are listed
+const inboxLimit = 50
+
+type NotificationUseCase struct {
+	prefsRepo notification.PreferenceRepository
+	inAppRepo notification.InAppRepository
+}
+
+func NewNotificationUseCase(prefsRepo notification.PreferenceRepository, inAppRepo notification.InAppRepository) *NotificationUseCase {
+	return &NotificationUseCase{
+		prefsRepo: prefsRepo,
+		inAppRepo: inAppRepo,
+	}
+}
+
+// GetNotifications returns the user's most recent in-app notifications
+func (uc *NotificationUseCase) GetNotifications(ctx context.Context, userID uuid.UUID) ([]*notification.InAppNotification, error) {
+	return uc.inAppRepo.GetByUserID(ctx, userID, inboxLimit)
+}
+
+func (uc *NotificationUseCase) MarkRead(ctx context.Context, userID, notificationID uuid.UUID) error {
+	return uc.inAppRepo.MarkRead(ctx, notificationID, userID, time.Now())
+}
+
+// GetPreferences returns the user's setting for every channel
+func (uc *NotificationUseCase) GetPreferences(ctx context.Context, userID uuid.UUID) (notification.Preferences, error) {
+	prefs, err := uc.prefsRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return prefs.Merge(), nil
+}
+
+// UpdatePreferences sets the given channels and returns the user's setting for
+// every channel
+func (uc *NotificationUseCase) UpdatePreferences(ctx context.Context, userID uuid.UUID, prefs notification.Preferences) (notification.Preferences, error) {
+	if err := prefs.Validate(); err != nil {
+		return nil, err
+	}
+	if err := uc.prefsRepo.Save(ctx, userID, prefs); err != nil {
+		return nil, err
+	}
+	return uc.GetPreferences(ctx, userID)
+}
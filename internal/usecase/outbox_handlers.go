@@ -0,0 +1,40 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/mungkiice/-loan-service/internal/domain"
+	"github.com/mungkiice/-loan-service/internal/outbox"
+)
+
+// RegisterOutboxHandlers wires the loan and investment events published by
+// the use cases to their consumers. Notifications and webhooks are registered
+// by their own packages.
+func RegisterOutboxHandlers(d *outbox.Dispatcher) {
+	for _, eventType := range domain.EventTypes {
+		d.Handle(string(eventType), logLoanEvent)
+	}
+	for _, eventType := range domain.InvestmentEventTypes {
+		d.Handle(string(eventType), logInvestmentEvent)
+	}
+}
+
+func logLoanEvent(ctx context.Context, m *outbox.Message) error {
+	var event domain.LoanEvent
+	if err := m.Decode(&event); err != nil {
+		return err
+	}
+	log.Printf("loan event: %s for loan %s at %s", event.Type, event.LoanID, event.OccurredAt.Format(time.RFC3339))
+	return nil
+}
+
+func logInvestmentEvent(ctx context.Context, m *outbox.Message) error {
+	var event domain.InvestmentEvent
+	if err := m.Decode(&event); err != nil {
+		return err
+	}
+	log.Printf("investment event: %s for investment %s in loan %s at %s", event.Type, event.InvestmentID, event.LoanID, event.OccurredAt.Format(time.RFC3339))
+	return nil
+}
@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mungkiice/-loan-service/internal/domain"
+	"github.com/mungkiice/-loan-service/internal/outbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDispatcher(repo outbox.Repository) *outbox.Dispatcher {
+	return outbox.NewDispatcher(repo, outbox.Config{
+		BatchSize:   10,
+		MaxAttempts: 3,
+		BaseBackoff: time.Second,
+		MaxBackoff:  time.Minute,
+		Lease:       time.Minute,
+	})
+}
+
+func TestOutboxHandlers_DeliverEveryLoanEvent(t *testing.T) {
+	repo := new(MockOutboxRepository)
+	dispatcher := newTestDispatcher(repo)
+	RegisterOutboxHandlers(dispatcher)
+
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+	o := outbox.New(repo)
+	for _, eventType := range domain.EventTypes {
+		require.NoError(t, o.Publish(context.Background(), string(eventType), domain.NewLoanEvent(eventType, loan, time.Now())))
+	}
+
+	delivered, err := dispatcher.DispatchDue(context.Background(), time.Now())
+
+	require.NoError(t, err)
+	assert.Equal(t, len(domain.EventTypes), delivered)
+}
+
+func TestOutboxHandlers_DeadLetterUnreadablePayload(t *testing.T) {
+	repo := new(MockOutboxRepository)
+	dispatcher := newTestDispatcher(repo)
+	RegisterOutboxHandlers(dispatcher)
+
+	message, err := outbox.NewMessage(string(domain.EventLoanExpired), "not an object")
+	require.NoError(t, err)
+	require.NoError(t, repo.Create(context.Background(), []*outbox.Message{message}))
+
+	_, err = dispatcher.DispatchDue(context.Background(), time.Now())
+
+	require.NoError(t, err)
+	assert.Equal(t, outbox.StatusDead, message.Status)
+	assert.Equal(t, 1, message.Attempts)
+}
@@ -0,0 +1,299 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mungkiice/-loan-service/internal/domain"
+	"github.com/mungkiice/-loan-service/internal/idempotency"
+	"github.com/mungkiice/-loan-service/internal/infrastructure/redis"
+	"github.com/mungkiice/-loan-service/internal/ledger"
+)
+
+type RepaymentUseCase struct {
+	loanAccess
+	loanRepo        domain.LoanRepository
+	investmentRepo  domain.InvestmentRepository
+	installmentRepo domain.InstallmentRepository
+	repaymentRepo   domain.RepaymentRepository
+	payoutRepo      domain.PayoutRepository
+	txManager       domain.TxManager
+	ledger          *ledger.Ledger
+	redisClient     redis.RedisClient
+	idempotency     *idempotency.Keeper
+	locker          *redis.Locker
+}
+
+func NewRepaymentUseCase(
+	loanRepo domain.LoanRepository,
+	investmentRepo domain.InvestmentRepository,
+	installmentRepo domain.InstallmentRepository,
+	repaymentRepo domain.RepaymentRepository,
+	payoutRepo domain.PayoutRepository,
+	borrowerRepo domain.BorrowerRepository,
+	investorRepo domain.InvestorRepository,
+	employeeRepo domain.EmployeeRepository,
+	txManager domain.TxManager,
+	ledger *ledger.Ledger,
+	redisClient redis.RedisClient,
+	idempotency *idempotency.Keeper,
+	locker *redis.Locker,
+) *RepaymentUseCase {
+	return &RepaymentUseCase{
+		loanAccess: loanAccess{
+			borrowerRepo: borrowerRepo,
+			investorRepo: investorRepo,
+			employeeRepo: employeeRepo,
+		},
+		loanRepo:        loanRepo,
+		investmentRepo:  investmentRepo,
+		installmentRepo: installmentRepo,
+		repaymentRepo:   repaymentRepo,
+		payoutRepo:      payoutRepo,
+		txManager:       txManager,
+		ledger:          ledger,
+		redisClient:     redisClient,
+		idempotency:     idempotency,
+		locker:          locker,
+	}
+}
+
+// GetSchedule returns a disbursed loan's installments, if actor may see the
+// loan
+func (uc *RepaymentUseCase) GetSchedule(ctx context.Context, actor domain.Actor, loanID uuid.UUID) ([]*domain.Installment, error) {
+	loan, err := uc.loanRepo.GetByID(ctx, loanID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loan: %w", err)
+	}
+
+	investments, err := uc.investmentRepo.GetByLoanID(ctx, loanID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get investments: %w", err)
+	}
+	if err := uc.authorizeView(ctx, actor, &domain.LoanDetails{Loan: loan, Investments: investments}); err != nil {
+		return nil, err
+	}
+
+	if loan.State != domain.StateDisbursed {
+		return nil, fmt.Errorf("%w: repayment schedule is generated once the loan is disbursed", domain.ErrLoanNotDisbursed)
+	}
+
+	return uc.installmentRepo.GetByLoanID(ctx, loanID)
+}
+
+// RecordRepayment applies a borrower payment to the schedule and pays out
+// investors. A retry with the same idempotency key returns the original
+// repayment.
+func (uc *RepaymentUseCase) RecordRepayment(ctx context.Context, req RecordRepaymentRequest) (*domain.Repayment, error) {
+	fingerprint, err := idempotency.Fingerprint(req.LoanID, req.Amount, req.PaidAt)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("repay:%s:%s", req.LoanID, req.IdempotencyKey)
+	return idempotency.Do(ctx, uc.idempotency, key, fingerprint, func() (*domain.Repayment, error) {
+		return uc.recordRepayment(ctx, req)
+	})
+}
+
+func (uc *RepaymentUseCase) recordRepayment(ctx context.Context, req RecordRepaymentRequest) (*domain.Repayment, error) {
+	lock, err := uc.locker.Obtain(ctx, fmt.Sprintf("repay:%s", req.LoanID))
+	if err != nil {
+		return nil, err
+	}
+	defer releaseLock(ctx, lock)
+
+	loan, err := uc.loanRepo.GetByID(ctx, req.LoanID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loan: %w", err)
+	}
+
+	if loan.State != domain.StateDisbursed {
+		return nil, fmt.Errorf("%w: loan is %s and cannot accept repayments", domain.ErrLoanNotDisbursed, loan.State)
+	}
+
+	installments, err := uc.installmentRepo.GetByLoanID(ctx, req.LoanID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repayment schedule: %w", err)
+	}
+
+	repayment := &domain.Repayment{
+		ID:        uuid.New(),
+		LoanID:    req.LoanID,
+		Amount:    req.Amount,
+		PaidAt:    req.PaidAt,
+		CreatedAt: time.Now(),
+	}
+
+	allocations, err := domain.ApplyRepayment(installments, repayment)
+	if err != nil {
+		return nil, err
+	}
+
+	investments, err := uc.investmentRepo.GetByLoanID(ctx, req.LoanID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get investments: %w", err)
+	}
+
+	payouts, err := loan.DistributeRepayment(investments, repayment.ID, allocations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to distribute repayment: %w", err)
+	}
+
+	byID := make(map[uuid.UUID]*domain.Installment, len(installments))
+	for _, inst := range installments {
+		byID[inst.ID] = inst
+	}
+
+	if err := uc.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.repaymentRepo.Create(ctx, repayment); err != nil {
+			return fmt.Errorf("failed to create repayment: %w", err)
+		}
+
+		if err := uc.repaymentRepo.CreateAllocations(ctx, allocations); err != nil {
+			return fmt.Errorf("failed to create repayment allocations: %w", err)
+		}
+
+		for _, a := range allocations {
+			if err := uc.installmentRepo.Update(ctx, byID[a.InstallmentID]); err != nil {
+				return fmt.Errorf("failed to update installment: %w", err)
+			}
+		}
+
+		if err := uc.payoutRepo.CreateBatch(ctx, payouts); err != nil {
+			return fmt.Errorf("failed to create investor payouts: %w", err)
+		}
+
+		if err := uc.ledger.RecordRepayment(ctx, loan, repayment, payouts); err != nil {
+			return fmt.Errorf("failed to post repayment to ledger: %w", err)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return repayment, nil
+}
+
+// GetInvestorReturns summarises, per loan, what the investor signed in as
+// actor put in, what the schedule says they should get back and what has
+// been paid out so far
+func (uc *RepaymentUseCase) GetInvestorReturns(ctx context.Context, actor domain.Actor) ([]*InvestorReturn, error) {
+	investor, err := uc.signedInInvestor(ctx, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	investments, err := uc.investmentRepo.GetByInvestorID(ctx, investor.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get investments: %w", err)
+	}
+
+	payouts, err := uc.payoutRepo.GetByInvestorID(ctx, investor.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payouts: %w", err)
+	}
+
+	returns := make([]*InvestorReturn, 0)
+	byLoan := make(map[uuid.UUID]*InvestorReturn)
+	for _, inv := range investments {
+		if _, ok := byLoan[inv.LoanID]; ok {
+			continue
+		}
+
+		ret, err := uc.expectedReturn(ctx, inv.LoanID, investor.ID)
+		if err != nil {
+			return nil, err
+		}
+		byLoan[inv.LoanID] = ret
+		returns = append(returns, ret)
+	}
+
+	for _, p := range payouts {
+		ret, ok := byLoan[p.LoanID]
+		if !ok {
+			continue
+		}
+		ret.ReceivedPrincipal = ret.ReceivedPrincipal.Add(p.Principal)
+		ret.ReceivedInterest = ret.ReceivedInterest.Add(p.Interest)
+	}
+
+	return returns, nil
+}
+
+func (uc *RepaymentUseCase) expectedReturn(ctx context.Context, loanID, investorID uuid.UUID) (*InvestorReturn, error) {
+	loan, err := uc.loanRepo.GetByID(ctx, loanID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loan: %w", err)
+	}
+
+	loanInvestments, err := uc.investmentRepo.GetByLoanID(ctx, loanID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get investments: %w", err)
+	}
+
+	installments, err := uc.installmentRepo.GetByLoanID(ctx, loanID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repayment schedule: %w", err)
+	}
+	if len(installments) == 0 {
+		// not disbursed yet, project the schedule from today
+		installments, err = domain.GenerateSchedule(loan, time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("failed to project repayment schedule: %w", err)
+		}
+	}
+
+	expectedInterest, err := loan.ExpectedInvestorInterest(loanInvestments, installments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute expected interest: %w", err)
+	}
+
+	zero := domain.NewMoney(0, loan.PrincipalAmount.Currency)
+	ret := &InvestorReturn{
+		LoanID:            loan.ID,
+		LoanState:         loan.State,
+		ROI:               loan.ROI,
+		Invested:          zero,
+		ExpectedInterest:  zero,
+		ReceivedPrincipal: zero,
+		ReceivedInterest:  zero,
+	}
+	for _, inv := range loanInvestments {
+		if inv.InvestorID != investorID {
+			continue
+		}
+		ret.Invested = ret.Invested.Add(inv.Amount)
+		ret.ExpectedInterest = ret.ExpectedInterest.Add(expectedInterest[inv.ID])
+	}
+
+	return ret, nil
+}
+
+type InvestorReturn struct {
+	LoanID            uuid.UUID
+	LoanState         domain.LoanState
+	ROI               domain.Percent
+	Invested          domain.Money
+	ExpectedInterest  domain.Money
+	ReceivedPrincipal domain.Money
+	ReceivedInterest  domain.Money
+}
+
+func (r *InvestorReturn) ExpectedTotal() domain.Money {
+	return r.Invested.Add(r.ExpectedInterest)
+}
+
+func (r *InvestorReturn) ReceivedTotal() domain.Money {
+	return r.ReceivedPrincipal.Add(r.ReceivedInterest)
+}
+
+type RecordRepaymentRequest struct {
+	LoanID         uuid.UUID
+	Amount         domain.Money
+	PaidAt         time.Time
+	IdempotencyKey string
+}
@@ -0,0 +1,233 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mungkiice/-loan-service/internal/domain"
+	"github.com/mungkiice/-loan-service/internal/ledger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type repaymentUseCaseMocks struct {
+	loanRepo        *MockLoanRepository
+	investmentRepo  *MockInvestmentRepository
+	installmentRepo *MockInstallmentRepository
+	repaymentRepo   *MockRepaymentRepository
+	payoutRepo      *MockPayoutRepository
+	borrowerRepo    *MockBorrowerRepository
+	investorRepo    *MockInvestorRepository
+	employeeRepo    *MockEmployeeRepository
+	txManager       *MockTxManager
+	ledgerRepo      *MockLedgerRepository
+	redis           *MockRedisClient
+}
+
+func newTestRepaymentUseCase() (*RepaymentUseCase, *repaymentUseCaseMocks) {
+	m := &repaymentUseCaseMocks{
+		loanRepo:        new(MockLoanRepository),
+		investmentRepo:  new(MockInvestmentRepository),
+		installmentRepo: new(MockInstallmentRepository),
+		repaymentRepo:   new(MockRepaymentRepository),
+		payoutRepo:      new(MockPayoutRepository),
+		borrowerRepo:    new(MockBorrowerRepository),
+		investorRepo:    new(MockInvestorRepository),
+		employeeRepo:    new(MockEmployeeRepository),
+		txManager:       new(MockTxManager),
+		ledgerRepo:      new(MockLedgerRepository),
+		redis:           new(MockRedisClient),
+	}
+
+	uc := NewRepaymentUseCase(
+		m.loanRepo,
+		m.investmentRepo,
+		m.installmentRepo,
+		m.repaymentRepo,
+		m.payoutRepo,
+		m.borrowerRepo,
+		m.investorRepo,
+		m.employeeRepo,
+		m.txManager,
+		ledger.New(m.ledgerRepo),
+		m.redis,
+		newTestKeeper(m.redis),
+		newTestLocker(m.redis),
+	)
+
+	return uc, m
+}
+
+func idr(minorUnits int64) domain.Money {
+	return domain.NewMoney(minorUnits, domain.CurrencyIDR)
+}
+
+func disbursedLoanWithSchedule(t *testing.T) (*domain.Loan, []*domain.Installment, []*domain.Investment) {
+	loan := domain.NewLoan(uuid.New(), idr(1000000), 1200, 800)
+	loan.State = domain.StateDisbursed
+	loan.TenorMonths = 3
+
+	installments, err := domain.GenerateSchedule(loan, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	investments := []*domain.Investment{
+		{ID: uuid.New(), LoanID: loan.ID, InvestorID: uuid.New(), Amount: idr(750000)},
+		{ID: uuid.New(), LoanID: loan.ID, InvestorID: uuid.New(), Amount: idr(250000)},
+	}
+	return loan, installments, investments
+}
+
+// borrowerActor signs in as the borrower with borrowerID
+func (m *repaymentUseCaseMocks) borrowerActor(borrowerID uuid.UUID) domain.Actor {
+	actor := domain.Actor{UserID: uuid.New(), UserType: domain.UserTypeBorrower}
+	m.borrowerRepo.On("GetByUserID", mock.Anything, actor.UserID).Return(&domain.Borrower{ID: borrowerID, UserID: &actor.UserID}, nil)
+	return actor
+}
+
+// investorActor signs in as the investor with investorID
+func (m *repaymentUseCaseMocks) investorActor(investorID uuid.UUID) domain.Actor {
+	actor := domain.Actor{UserID: uuid.New(), UserType: domain.UserTypeInvestor}
+	m.investorRepo.On("GetByUserID", mock.Anything, actor.UserID).Return(&domain.Investor{ID: investorID, UserID: actor.UserID}, nil)
+	return actor
+}
+
+func TestGetSchedule_ShownToLoanBorrowerAndInvestors(t *testing.T) {
+	uc, m := newTestRepaymentUseCase()
+	loan, installments, investments := disbursedLoanWithSchedule(t)
+	m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	m.investmentRepo.On("GetByLoanID", mock.Anything, loan.ID).Return(investments, nil)
+	m.installmentRepo.On("GetByLoanID", mock.Anything, loan.ID).Return(installments, nil)
+
+	for name, actor := range map[string]domain.Actor{
+		"borrower": m.borrowerActor(loan.BorrowerID),
+		"investor": m.investorActor(investments[1].InvestorID),
+		"employee": {UserID: uuid.New(), UserType: domain.UserTypeEmployee, Role: domain.RoleFieldOfficer},
+	} {
+		schedule, err := uc.GetSchedule(context.Background(), actor, loan.ID)
+
+		require.NoError(t, err, name)
+		assert.Equal(t, installments, schedule, name)
+	}
+}
+
+func TestGetSchedule_HiddenFromOtherBorrowersAndInvestors(t *testing.T) {
+	uc, m := newTestRepaymentUseCase()
+	loan, installments, investments := disbursedLoanWithSchedule(t)
+	m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	m.investmentRepo.On("GetByLoanID", mock.Anything, loan.ID).Return(investments, nil)
+	m.installmentRepo.On("GetByLoanID", mock.Anything, loan.ID).Return(installments, nil)
+
+	for name, actor := range map[string]domain.Actor{
+		"borrower": m.borrowerActor(uuid.New()),
+		"investor": m.investorActor(uuid.New()),
+	} {
+		_, err := uc.GetSchedule(context.Background(), actor, loan.ID)
+
+		assert.ErrorIs(t, err, domain.ErrForbidden, name)
+	}
+	m.installmentRepo.AssertNotCalled(t, "GetByLoanID", mock.Anything, mock.Anything)
+}
+
+func TestGetSchedule_RejectsLoanNotDisbursed(t *testing.T) {
+	uc, m := newTestRepaymentUseCase()
+	loan := domain.NewLoan(uuid.New(), idr(1000000), 1200, 800)
+	loan.State = domain.StateInvested
+	m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	m.investmentRepo.On("GetByLoanID", mock.Anything, loan.ID).Return([]*domain.Investment{}, nil)
+
+	actor := domain.Actor{UserID: uuid.New(), UserType: domain.UserTypeEmployee, Role: domain.RoleFieldOfficer}
+	_, err := uc.GetSchedule(context.Background(), actor, loan.ID)
+
+	assert.ErrorIs(t, err, domain.ErrLoanNotDisbursed)
+	m.installmentRepo.AssertNotCalled(t, "GetByLoanID", mock.Anything, mock.Anything)
+}
+
+func TestRecordRepayment(t *testing.T) {
+	uc, m := newTestRepaymentUseCase()
+	loan, installments, investments := disbursedLoanWithSchedule(t)
+
+	var payouts []*domain.InvestorPayout
+	m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	m.installmentRepo.On("GetByLoanID", mock.Anything, loan.ID).Return(installments, nil)
+	m.installmentRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Installment")).Return(nil)
+	m.investmentRepo.On("GetByLoanID", mock.Anything, loan.ID).Return(investments, nil)
+	m.repaymentRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Repayment")).Return(nil)
+	m.repaymentRepo.On("CreateAllocations", mock.Anything, mock.AnythingOfType("[]*domain.RepaymentAllocation")).Return(nil)
+	m.payoutRepo.On("CreateBatch", mock.Anything, mock.AnythingOfType("[]*domain.InvestorPayout")).
+		Run(func(args mock.Arguments) { payouts = args.Get(1).([]*domain.InvestorPayout) }).
+		Return(nil)
+
+	amount := installments[0].AmountDue()
+	repayment, err := uc.RecordRepayment(context.Background(), RecordRepaymentRequest{
+		LoanID:         loan.ID,
+		Amount:         amount,
+		PaidAt:         time.Now(),
+		IdempotencyKey: "repay-1",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, amount, repayment.Amount)
+	assert.Equal(t, domain.InstallmentPaid, installments[0].Status)
+	assert.Equal(t, domain.InstallmentPending, installments[1].Status)
+	assert.Equal(t, 1, m.txManager.Commits)
+	m.installmentRepo.AssertNumberOfCalls(t, "Update", 1)
+
+	// 100.00 interest at 12% with 8% ROI leaves 66.67 for investors, split 3:1
+	require.Len(t, payouts, 2)
+	assert.Equal(t, investments[0].InvestorID, payouts[0].InvestorID)
+	assert.Equal(t, idr(5000), payouts[0].Interest)
+	assert.Equal(t, idr(1667), payouts[1].Interest)
+	assert.Equal(t, installments[0].PrincipalDue, payouts[0].Principal.Add(payouts[1].Principal))
+
+	// the 33.33 of interest not passed on to investors is the platform's fee
+	require.Len(t, m.ledgerRepo.Entries, 1)
+	fees, err := m.ledgerRepo.GetBalance(context.Background(), ledger.PlatformFees(), domain.CurrencyIDR)
+	require.NoError(t, err)
+	assert.Equal(t, idr(3333), fees.Net())
+}
+
+func TestRecordRepayment_RejectsNonDisbursedLoan(t *testing.T) {
+	uc, m := newTestRepaymentUseCase()
+	loan := domain.NewLoan(uuid.New(), idr(1000000), 1200, 800)
+	loan.State = domain.StateInvested
+
+	m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+
+	_, err := uc.RecordRepayment(context.Background(), RecordRepaymentRequest{
+		LoanID:         loan.ID,
+		Amount:         idr(10000),
+		PaidAt:         time.Now(),
+		IdempotencyKey: "repay-1",
+	})
+
+	assert.ErrorIs(t, err, domain.ErrLoanNotDisbursed)
+	m.repaymentRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestGetInvestorReturns(t *testing.T) {
+	uc, m := newTestRepaymentUseCase()
+	loan, installments, investments := disbursedLoanWithSchedule(t)
+	investorID := investments[1].InvestorID
+	// the investor profile has its own ID, not the user's
+	actor := m.investorActor(investorID)
+	require.NotEqual(t, actor.UserID, investorID)
+
+	m.investmentRepo.On("GetByInvestorID", mock.Anything, investorID).Return([]*domain.Investment{investments[1]}, nil)
+	m.investmentRepo.On("GetByLoanID", mock.Anything, loan.ID).Return(investments, nil)
+	m.loanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	m.installmentRepo.On("GetByLoanID", mock.Anything, loan.ID).Return(installments, nil)
+	m.payoutRepo.On("GetByInvestorID", mock.Anything, investorID).Return([]*domain.InvestorPayout{
+		{LoanID: loan.ID, InvestorID: investorID, Principal: idr(83333), Interest: idr(1667)},
+	}, nil)
+
+	returns, err := uc.GetInvestorReturns(context.Background(), actor)
+
+	require.NoError(t, err)
+	require.Len(t, returns, 1)
+	assert.Equal(t, idr(250000), returns[0].Invested)
+	assert.True(t, returns[0].ExpectedInterest.IsPositive())
+	assert.Equal(t, idr(85000), returns[0].ReceivedTotal())
+}
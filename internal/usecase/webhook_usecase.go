@@ -0,0 +1,103 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mungkiice/-loan-service/internal/domain"
+	"github.com/mungkiice/-loan-service/internal/webhook"
+)
+
+// attemptLimit caps how many delivery attempts are listed per subscription
+const attemptLimit = 100
+
+type WebhookUseCase struct {
+	subscriptionRepo webhook.SubscriptionRepository
+	attemptRepo      webhook.AttemptRepository
+}
+
+func NewWebhookUseCase(subscriptionRepo webhook.SubscriptionRepository, attemptRepo webhook.AttemptRepository) *WebhookUseCase {
+	return &WebhookUseCase{
+		subscriptionRepo: subscriptionRepo,
+		attemptRepo:      attemptRepo,
+	}
+}
+
+// CreateSubscription registers an endpoint for the given events. A signing
+// secret is generated when none is given.
+func (uc *WebhookUseCase) CreateSubscription(ctx context.Context, req CreateWebhookRequest) (*webhook.Subscription, error) {
+	subscription, err := webhook.NewSubscription(req.URL, req.Secret, req.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.subscriptionRepo.Create(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+
+	return subscription, nil
+}
+
+func (uc *WebhookUseCase) GetSubscriptions(ctx context.Context) ([]*webhook.Subscription, error) {
+	return uc.subscriptionRepo.GetAll(ctx)
+}
+
+func (uc *WebhookUseCase) GetSubscription(ctx context.Context, id uuid.UUID) (*webhook.Subscription, error) {
+	return uc.subscriptionRepo.GetByID(ctx, id)
+}
+
+// UpdateSubscription changes the fields that are set. Deactivated
+// subscriptions stop receiving events, including deliveries already queued.
+func (uc *WebhookUseCase) UpdateSubscription(ctx context.Context, req UpdateWebhookRequest) (*webhook.Subscription, error) {
+	subscription, err := uc.subscriptionRepo.GetByID(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		subscription.URL = *req.URL
+	}
+	if req.Events != nil {
+		subscription.Events = req.Events
+	}
+	if req.Active != nil {
+		subscription.Active = *req.Active
+	}
+	if err := subscription.Validate(); err != nil {
+		return nil, err
+	}
+	subscription.UpdatedAt = time.Now()
+
+	if err := uc.subscriptionRepo.Update(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+func (uc *WebhookUseCase) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	return uc.subscriptionRepo.Delete(ctx, id)
+}
+
+// GetAttempts returns the subscription's most recent delivery attempts
+func (uc *WebhookUseCase) GetAttempts(ctx context.Context, subscriptionID uuid.UUID) ([]*webhook.Attempt, error) {
+	if _, err := uc.subscriptionRepo.GetByID(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+	return uc.attemptRepo.GetBySubscriptionID(ctx, subscriptionID, attemptLimit)
+}
+
+type CreateWebhookRequest struct {
+	URL    string
+	Secret string
+	Events []domain.EventType
+}
+
+type UpdateWebhookRequest struct {
+	ID     uuid.UUID
+	URL    *string
+	Events []domain.EventType
+	Active *bool
+}
@@ -0,0 +1,64 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mungkiice/-loan-service/internal/domain"
+	"github.com/mungkiice/-loan-service/internal/outbox"
+)
+
+// TopicDelivery is the outbox topic webhook deliveries are published on
+const TopicDelivery = "webhook.delivery"
+
+// Publisher queues an event for every active subscription filtering on it,
+// one outbox message per subscription so each is retried on its own.
+// Publishing joins the caller's transaction, like outbox.Outbox.
+type Publisher struct {
+	subscriptions SubscriptionRepository
+	outbox        *outbox.Outbox
+}
+
+func NewPublisher(subscriptions SubscriptionRepository, outbox *outbox.Outbox) *Publisher {
+	return &Publisher{subscriptions: subscriptions, outbox: outbox}
+}
+
+func (p *Publisher) Publish(ctx context.Context, eventType domain.EventType, data any) error {
+	subscriptions, err := p.subscriptions.GetActiveByEvent(ctx, eventType)
+	if err != nil {
+		return fmt.Errorf("failed to get webhook subscriptions: %w", err)
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s webhook data: %w", eventType, err)
+	}
+	body, err := json.Marshal(Event{
+		ID:        uuid.New(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      payload,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode %s webhook: %w", eventType, err)
+	}
+
+	for _, subscription := range subscriptions {
+		if err := p.outbox.Publish(ctx, TopicDelivery, Delivery{
+			ID:             uuid.New(),
+			SubscriptionID: subscription.ID,
+			Event:          eventType,
+			Body:           body,
+		}); err != nil {
+			return fmt.Errorf("failed to queue %s webhook: %w", eventType, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mungkiice/-loan-service/internal/outbox"
+)
+
+// Sender posts queued deliveries to their subscription's URL and records
+// every attempt. Failed requests are retried by the outbox dispatcher.
+type Sender struct {
+	subscriptions SubscriptionRepository
+	attempts      AttemptRepository
+	client        *http.Client
+}
+
+func NewSender(subscriptions SubscriptionRepository, attempts AttemptRepository, timeout time.Duration) *Sender {
+	return &Sender{
+		subscriptions: subscriptions,
+		attempts:      attempts,
+		client:        &http.Client{Timeout: timeout},
+	}
+}
+
+// Register handles TopicDelivery messages on d
+func (s *Sender) Register(d *outbox.Dispatcher) {
+	d.Handle(TopicDelivery, s.Deliver)
+}
+
+// Deliver sends one delivery. Deliveries to subscriptions that were removed or
+// deactivated after the event was queued are dropped.
+func (s *Sender) Deliver(ctx context.Context, m *outbox.Message) error {
+	var delivery Delivery
+	if err := m.Decode(&delivery); err != nil {
+		return err
+	}
+
+	subscription, err := s.subscriptions.GetByID(ctx, delivery.SubscriptionID)
+	if errors.Is(err, ErrSubscriptionNotFound) {
+		log.Printf("webhook: dropping delivery %s, subscription %s was removed", delivery.ID, delivery.SubscriptionID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get webhook subscription %s: %w", delivery.SubscriptionID, err)
+	}
+	if !subscription.Active {
+		return nil
+	}
+
+	attempt := &Attempt{
+		ID:             uuid.New(),
+		DeliveryID:     delivery.ID,
+		SubscriptionID: subscription.ID,
+		Event:          delivery.Event,
+		Attempt:        m.Attempts + 1,
+		AttemptedAt:    time.Now(),
+	}
+	err = s.post(ctx, subscription, &delivery, attempt)
+	attempt.Duration = time.Since(attempt.AttemptedAt)
+	if err != nil {
+		msg := err.Error()
+		attempt.Error = &msg
+	}
+
+	// the request has been made either way; a lost attempt record must not
+	// cause the event to be sent again
+	if rerr := s.attempts.Create(ctx, attempt); rerr != nil {
+		log.Printf("webhook: failed to record attempt %d of delivery %s: %v", attempt.Attempt, delivery.ID, rerr)
+	}
+
+	return err
+}
+
+func (s *Sender) post(ctx context.Context, subscription *Subscription, delivery *Delivery, attempt *Attempt) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Body))
+	if err != nil {
+		return outbox.Permanent(fmt.Errorf("failed to build webhook request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "loan-service-webhooks/1.0")
+	req.Header.Set(HeaderEvent, string(delivery.Event))
+	req.Header.Set(HeaderDelivery, delivery.ID.String())
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(attempt.AttemptedAt.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(subscription.Secret, attempt.AttemptedAt, delivery.Body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request to %s failed: %w", subscription.URL, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	attempt.StatusCode = &resp.StatusCode
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	err = fmt.Errorf("webhook endpoint %s responded %d", subscription.URL, resp.StatusCode)
+	if retryable(resp.StatusCode) {
+		return err
+	}
+	return outbox.Permanent(err)
+}
+
+// retryable reports whether a response status may succeed if sent again
+func retryable(status int) bool {
+	return status >= 500 || status == http.StatusRequestTimeout || status == http.StatusTooManyRequests
+}
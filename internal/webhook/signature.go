@@ -0,0 +1,49 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers set on every webhook request
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Sign returns "sha256=" followed by the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with secret. Including the timestamp lets
+// receivers reject replayed requests.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a request's timestamp and signature headers against body, as
+// a receiver would. Requests older than tolerance are rejected.
+func Verify(secret, timestampHeader, signatureHeader string, body []byte, now time.Time, tolerance time.Duration) error {
+	unix, err := strconv.ParseInt(timestampHeader, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid webhook timestamp %q", timestampHeader)
+	}
+	timestamp := time.Unix(unix, 0)
+	if now.Sub(timestamp) > tolerance || timestamp.Sub(now) > tolerance {
+		return fmt.Errorf("webhook timestamp %s outside tolerance", timestamp.Format(time.RFC3339))
+	}
+
+	expected := Sign(secret, timestamp, body)
+	if !strings.HasPrefix(signatureHeader, "sha256=") || !hmac.Equal([]byte(expected), []byte(signatureHeader)) {
+		return fmt.Errorf("webhook signature mismatch")
+	}
+	return nil
+}
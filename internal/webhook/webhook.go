@@ -0,0 +1,164 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mungkiice/-loan-service/internal/domain"
+)
+
+var (
+	ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+	ErrInvalidSubscription  = errors.New("invalid webhook subscription")
+)
+
+// Events lists the event types a subscription can filter on
+var Events = append(append([]domain.EventType{}, domain.EventTypes...), domain.InvestmentEventTypes...)
+
+// Subscription is a partner endpoint that receives the events it filters on.
+// Every request is signed with its Secret.
+type Subscription struct {
+	ID        uuid.UUID
+	URL       string
+	Secret    string
+	Events    []domain.EventType
+	Active    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// NewSubscription creates an active subscription, generating a secret when
+// none is given
+func NewSubscription(endpoint, secret string, events []domain.EventType) (*Subscription, error) {
+	if secret == "" {
+		var err error
+		if secret, err = NewSecret(); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	s := &Subscription{
+		ID:        uuid.New(),
+		URL:       endpoint,
+		Secret:    secret,
+		Events:    events,
+		Active:    true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// NewSecret returns a random 32-byte signing secret, hex encoded
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+func (s *Subscription) Validate() error {
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidSubscription)
+	}
+	if len(s.Secret) < 16 {
+		return fmt.Errorf("%w: secret must be at least 16 characters", ErrInvalidSubscription)
+	}
+	if len(s.Events) == 0 {
+		return fmt.Errorf("%w: at least one event is required", ErrInvalidSubscription)
+	}
+	for _, event := range s.Events {
+		if !isEvent(event) {
+			return fmt.Errorf("%w: unknown event %q", ErrInvalidSubscription, event)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the subscription wants events of eventType
+func (s *Subscription) Matches(eventType domain.EventType) bool {
+	if !s.Active {
+		return false
+	}
+	for _, event := range s.Events {
+		if event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func isEvent(eventType domain.EventType) bool {
+	for _, event := range Events {
+		if event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Event is the JSON body posted to subscribers. ID is the same for every
+// subscription the event is delivered to.
+type Event struct {
+	ID        uuid.UUID        `json:"id"`
+	Type      domain.EventType `json:"type"`
+	CreatedAt time.Time        `json:"created_at"`
+	Data      json.RawMessage  `json:"data"`
+}
+
+// Delivery is the outbox payload for sending one event to one subscription
+type Delivery struct {
+	ID             uuid.UUID        `json:"id"`
+	SubscriptionID uuid.UUID        `json:"subscription_id"`
+	Event          domain.EventType `json:"event"`
+	Body           json.RawMessage  `json:"body"`
+}
+
+// Attempt records one HTTP request made to deliver an event
+type Attempt struct {
+	ID             uuid.UUID
+	DeliveryID     uuid.UUID
+	SubscriptionID uuid.UUID
+	Event          domain.EventType
+	Attempt        int
+	StatusCode     *int
+	Error          *string
+	Duration       time.Duration
+	AttemptedAt    time.Time
+}
+
+// Succeeded reports whether the subscriber acknowledged the event
+func (a *Attempt) Succeeded() bool {
+	return a.Error == nil
+}
+
+type SubscriptionRepository interface {
+	Create(ctx context.Context, subscription *Subscription) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Subscription, error)
+	GetAll(ctx context.Context) ([]*Subscription, error)
+	// GetActiveByEvent returns the active subscriptions filtering on eventType
+	GetActiveByEvent(ctx context.Context, eventType domain.EventType) ([]*Subscription, error)
+	Update(ctx context.Context, subscription *Subscription) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type AttemptRepository interface {
+	Create(ctx context.Context, attempt *Attempt) error
+	// GetBySubscriptionID returns the subscription's most recent attempts,
+	// newest first
+	GetBySubscriptionID(ctx context.Context, subscriptionID uuid.UUID, limit int) ([]*Attempt, error)
+}
@@ -0,0 +1,303 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mungkiice/-loan-service/internal/domain"
+	"github.com/mungkiice/-loan-service/internal/outbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memorySubscriptions map[uuid.UUID]*Subscription
+
+func (m memorySubscriptions) Create(ctx context.Context, s *Subscription) error {
+	m[s.ID] = s
+	return nil
+}
+
+func (m memorySubscriptions) GetByID(ctx context.Context, id uuid.UUID) (*Subscription, error) {
+	s, ok := m[id]
+	if !ok {
+		return nil, ErrSubscriptionNotFound
+	}
+	return s, nil
+}
+
+func (m memorySubscriptions) GetAll(ctx context.Context) ([]*Subscription, error) {
+	var all []*Subscription
+	for _, s := range m {
+		all = append(all, s)
+	}
+	return all, nil
+}
+
+func (m memorySubscriptions) GetActiveByEvent(ctx context.Context, eventType domain.EventType) ([]*Subscription, error) {
+	var matching []*Subscription
+	for _, s := range m {
+		if s.Matches(eventType) {
+			matching = append(matching, s)
+		}
+	}
+	return matching, nil
+}
+
+func (m memorySubscriptions) Update(ctx context.Context, s *Subscription) error {
+	m[s.ID] = s
+	return nil
+}
+
+func (m memorySubscriptions) Delete(ctx context.Context, id uuid.UUID) error {
+	delete(m, id)
+	return nil
+}
+
+type memoryAttempts struct {
+	attempts []*Attempt
+}
+
+func (m *memoryAttempts) Create(ctx context.Context, a *Attempt) error {
+	m.attempts = append(m.attempts, a)
+	return nil
+}
+
+func (m *memoryAttempts) GetBySubscriptionID(ctx context.Context, subscriptionID uuid.UUID, limit int) ([]*Attempt, error) {
+	return m.attempts, nil
+}
+
+type memoryOutbox struct {
+	messages []*outbox.Message
+}
+
+func (m *memoryOutbox) Create(ctx context.Context, messages []*outbox.Message) error {
+	m.messages = append(m.messages, messages...)
+	return nil
+}
+
+func (m *memoryOutbox) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*outbox.Message, error) {
+	var due []*outbox.Message
+	for _, msg := range m.messages {
+		if msg.Status == outbox.StatusPending && !msg.NextAttemptAt.After(now) && len(due) < limit {
+			msg.NextAttemptAt = now.Add(lease)
+			due = append(due, msg)
+		}
+	}
+	return due, nil
+}
+
+func (m *memoryOutbox) Update(ctx context.Context, message *outbox.Message) error {
+	return nil
+}
+
+// receiver is an httptest partner endpoint that verifies signatures and
+// answers with the queued status codes, then 200
+type receiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	received []*http.Request
+	bodies   [][]byte
+	errs     []error
+}
+
+func newReceiver(t *testing.T, secret string, statuses ...int) *receiver {
+	r := &receiver{statuses: statuses}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.received = append(r.received, req)
+		r.bodies = append(r.bodies, body)
+		r.errs = append(r.errs, Verify(secret, req.Header.Get(HeaderTimestamp), req.Header.Get(HeaderSignature), body, time.Now(), 5*time.Minute))
+
+		status := http.StatusOK
+		if len(r.statuses) > 0 {
+			status, r.statuses = r.statuses[0], r.statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+type webhookFixture struct {
+	subscriptions memorySubscriptions
+	attempts      *memoryAttempts
+	outboxRepo    *memoryOutbox
+	publisher     *Publisher
+	dispatcher    *outbox.Dispatcher
+}
+
+func newWebhookFixture() *webhookFixture {
+	f := &webhookFixture{
+		subscriptions: memorySubscriptions{},
+		attempts:      new(memoryAttempts),
+		outboxRepo:    new(memoryOutbox),
+	}
+	f.publisher = NewPublisher(f.subscriptions, outbox.New(f.outboxRepo))
+	f.dispatcher = outbox.NewDispatcher(f.outboxRepo, outbox.Config{
+		BatchSize:   10,
+		MaxAttempts: 3,
+		BaseBackoff: time.Second,
+		MaxBackoff:  time.Minute,
+		Lease:       time.Minute,
+	})
+	NewSender(f.subscriptions, f.attempts, 5*time.Second).Register(f.dispatcher)
+	return f
+}
+
+func (f *webhookFixture) subscribe(t *testing.T, url string, events ...domain.EventType) *Subscription {
+	s, err := NewSubscription(url, "test-secret-0123456789", events)
+	require.NoError(t, err)
+	require.NoError(t, f.subscriptions.Create(context.Background(), s))
+	return s
+}
+
+func TestWebhook_DeliversSignedEvent(t *testing.T) {
+	f := newWebhookFixture()
+	r := newReceiver(t, "test-secret-0123456789")
+	f.subscribe(t, r.URL, domain.EventLoanApproved)
+
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+	loan.State = domain.StateApproved
+	require.NoError(t, f.publisher.Publish(context.Background(), domain.EventLoanApproved, domain.NewLoanEvent(domain.EventLoanApproved, loan, time.Now())))
+
+	delivered, err := f.dispatcher.DispatchDue(context.Background(), time.Now())
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	require.Len(t, r.received, 1)
+	assert.NoError(t, r.errs[0])
+	assert.Equal(t, "loan.approved", r.received[0].Header.Get(HeaderEvent))
+	assert.Equal(t, "application/json", r.received[0].Header.Get("Content-Type"))
+
+	var event struct {
+		Type domain.EventType `json:"type"`
+		Data domain.LoanEvent `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(r.bodies[0], &event))
+	assert.Equal(t, domain.EventLoanApproved, event.Type)
+	assert.Equal(t, loan.ID, event.Data.LoanID)
+	assert.Equal(t, domain.StateApproved, event.Data.State)
+
+	require.Len(t, f.attempts.attempts, 1)
+	assert.True(t, f.attempts.attempts[0].Succeeded())
+	assert.Equal(t, http.StatusOK, *f.attempts.attempts[0].StatusCode)
+}
+
+func TestWebhook_OnlyMatchingActiveSubscriptions(t *testing.T) {
+	f := newWebhookFixture()
+	f.subscribe(t, "http://example.com/a", domain.EventLoanApproved, domain.EventInvestmentCreated)
+	f.subscribe(t, "http://example.com/b", domain.EventLoanDisbursed)
+	inactive := f.subscribe(t, "http://example.com/c", domain.EventInvestmentCreated)
+	inactive.Active = false
+
+	investment := &domain.Investment{ID: uuid.New(), LoanID: uuid.New(), InvestorID: uuid.New(), Amount: domain.NewMoney(50000, domain.CurrencyIDR)}
+	require.NoError(t, f.publisher.Publish(context.Background(), domain.EventInvestmentCreated, domain.NewInvestmentEvent(domain.EventInvestmentCreated, investment, time.Now())))
+
+	require.Len(t, f.outboxRepo.messages, 1)
+	var delivery Delivery
+	require.NoError(t, f.outboxRepo.messages[0].Decode(&delivery))
+	assert.Equal(t, domain.EventInvestmentCreated, delivery.Event)
+	assert.Contains(t, string(delivery.Body), `"amount":{"amount":500.00,"currency":"IDR"}`)
+}
+
+func TestWebhook_RetriesServerErrorsUntilDelivered(t *testing.T) {
+	f := newWebhookFixture()
+	r := newReceiver(t, "test-secret-0123456789", http.StatusServiceUnavailable)
+	f.subscribe(t, r.URL, domain.EventLoanDisbursed)
+
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+	require.NoError(t, f.publisher.Publish(context.Background(), domain.EventLoanDisbursed, domain.NewLoanEvent(domain.EventLoanDisbursed, loan, time.Now())))
+
+	delivered, err := f.dispatcher.DispatchDue(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 0, delivered)
+	message := f.outboxRepo.messages[0]
+	assert.Equal(t, outbox.StatusPending, message.Status)
+
+	delivered, err = f.dispatcher.DispatchDue(context.Background(), message.NextAttemptAt)
+	require.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+
+	// both requests carry the same delivery and event ids
+	require.Len(t, r.received, 2)
+	assert.Equal(t, r.received[0].Header.Get(HeaderDelivery), r.received[1].Header.Get(HeaderDelivery))
+	assert.JSONEq(t, string(r.bodies[0]), string(r.bodies[1]))
+
+	require.Len(t, f.attempts.attempts, 2)
+	assert.Equal(t, 1, f.attempts.attempts[0].Attempt)
+	assert.Equal(t, http.StatusServiceUnavailable, *f.attempts.attempts[0].StatusCode)
+	assert.False(t, f.attempts.attempts[0].Succeeded())
+	assert.Equal(t, 2, f.attempts.attempts[1].Attempt)
+	assert.True(t, f.attempts.attempts[1].Succeeded())
+}
+
+func TestWebhook_DeadLettersClientErrors(t *testing.T) {
+	f := newWebhookFixture()
+	r := newReceiver(t, "test-secret-0123456789", http.StatusGone)
+	f.subscribe(t, r.URL, domain.EventLoanInvested)
+
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+	require.NoError(t, f.publisher.Publish(context.Background(), domain.EventLoanInvested, domain.NewLoanEvent(domain.EventLoanInvested, loan, time.Now())))
+
+	_, err := f.dispatcher.DispatchDue(context.Background(), time.Now())
+
+	require.NoError(t, err)
+	assert.Equal(t, outbox.StatusDead, f.outboxRepo.messages[0].Status)
+	require.Len(t, f.attempts.attempts, 1)
+}
+
+func TestWebhook_DropsDeliveryForRemovedSubscription(t *testing.T) {
+	f := newWebhookFixture()
+	r := newReceiver(t, "test-secret-0123456789")
+	s := f.subscribe(t, r.URL, domain.EventLoanApproved)
+
+	loan := domain.NewLoan(uuid.New(), domain.NewMoney(1000000, domain.CurrencyIDR), 500, 300)
+	require.NoError(t, f.publisher.Publish(context.Background(), domain.EventLoanApproved, domain.NewLoanEvent(domain.EventLoanApproved, loan, time.Now())))
+	require.NoError(t, f.subscriptions.Delete(context.Background(), s.ID))
+
+	delivered, err := f.dispatcher.DispatchDue(context.Background(), time.Now())
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	assert.Empty(t, r.received)
+	assert.Empty(t, f.attempts.attempts)
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Now()
+	body := []byte(`{"id":"1"}`)
+	signature := Sign("secret", now, body)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	assert.NoError(t, Verify("secret", timestamp, signature, body, now, time.Minute))
+	assert.Error(t, Verify("other", timestamp, signature, body, now, time.Minute))
+	assert.Error(t, Verify("secret", timestamp, signature, []byte(`{"id":"2"}`), now, time.Minute))
+	assert.Error(t, Verify("secret", timestamp, signature, body, now.Add(time.Hour), time.Minute))
+}
+
+func TestNewSubscription_Validates(t *testing.T) {
+	s, err := NewSubscription("https://partner.example.com/hooks", "", []domain.EventType{domain.EventLoanApproved})
+	require.NoError(t, err)
+	assert.True(t, s.Active)
+	assert.Len(t, s.Secret, len("whsec_")+64)
+
+	_, err = NewSubscription("ftp://partner.example.com", "", []domain.EventType{domain.EventLoanApproved})
+	assert.ErrorIs(t, err, ErrInvalidSubscription)
+	_, err = NewSubscription("https://partner.example.com", "", nil)
+	assert.ErrorIs(t, err, ErrInvalidSubscription)
+	_, err = NewSubscription("https://partner.example.com", "", []domain.EventType{"loan.exploded"})
+	assert.ErrorIs(t, err, ErrInvalidSubscription)
+	_, err = NewSubscription("https://partner.example.com", "short", []domain.EventType{domain.EventLoanApproved})
+	assert.ErrorIs(t, err, ErrInvalidSubscription)
+}
@@ -0,0 +1,2 @@
+ALTER TABLE investments DROP COLUMN IF EXISTS currency;
+ALTER TABLE loans DROP COLUMN IF EXISTS currency;
@@ -0,0 +1,3 @@
+-- Store the currency alongside every exact DECIMAL amount
+ALTER TABLE loans ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'IDR';
+ALTER TABLE investments ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'IDR';
@@ -0,0 +1,12 @@
+DROP TRIGGER IF EXISTS update_installments_updated_at ON installments;
+
+DROP TABLE IF EXISTS repayment_allocations;
+DROP TABLE IF EXISTS repayments;
+DROP TABLE IF EXISTS installments;
+
+ALTER TABLE loans
+    DROP COLUMN IF EXISTS repayment_type,
+    DROP COLUMN IF EXISTS tenor_months;
+
+DROP TYPE IF EXISTS installment_status;
+DROP TYPE IF EXISTS repayment_type;
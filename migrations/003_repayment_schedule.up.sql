@@ -0,0 +1,55 @@
+-- Create enum types for repayment schedules
+CREATE TYPE repayment_type AS ENUM ('flat', 'annuity', 'interest_only');
+CREATE TYPE installment_status AS ENUM ('pending', 'partial', 'paid');
+
+-- Repayment terms chosen when the loan is proposed
+ALTER TABLE loans
+    ADD COLUMN tenor_months INT NOT NULL DEFAULT 12 CHECK (tenor_months > 0 AND tenor_months <= 360),
+    ADD COLUMN repayment_type repayment_type NOT NULL DEFAULT 'annuity';
+
+-- Create installments table (generated on disbursement)
+CREATE TABLE installments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    loan_id UUID NOT NULL REFERENCES loans(id) ON DELETE CASCADE,
+    sequence INT NOT NULL CHECK (sequence > 0),
+    due_date TIMESTAMP NOT NULL,
+    principal_due DECIMAL(15, 2) NOT NULL CHECK (principal_due >= 0),
+    interest_due DECIMAL(15, 2) NOT NULL CHECK (interest_due >= 0),
+    principal_paid DECIMAL(15, 2) NOT NULL DEFAULT 0 CHECK (principal_paid >= 0 AND principal_paid <= principal_due),
+    interest_paid DECIMAL(15, 2) NOT NULL DEFAULT 0 CHECK (interest_paid >= 0 AND interest_paid <= interest_due),
+    currency CHAR(3) NOT NULL DEFAULT 'IDR',
+    status installment_status NOT NULL DEFAULT 'pending',
+    paid_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (loan_id, sequence)
+);
+
+CREATE INDEX idx_installments_loan_id ON installments(loan_id);
+CREATE INDEX idx_installments_due_date ON installments(due_date) WHERE status <> 'paid';
+
+CREATE TRIGGER update_installments_updated_at BEFORE UPDATE ON installments
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Create repayments table (payments received from the borrower)
+CREATE TABLE repayments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    loan_id UUID NOT NULL REFERENCES loans(id) ON DELETE CASCADE,
+    amount DECIMAL(15, 2) NOT NULL CHECK (amount > 0),
+    currency CHAR(3) NOT NULL DEFAULT 'IDR',
+    paid_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_repayments_loan_id ON repayments(loan_id);
+
+-- Create repayment_allocations table (how each repayment settled installments)
+CREATE TABLE repayment_allocations (
+    repayment_id UUID NOT NULL REFERENCES repayments(id) ON DELETE CASCADE,
+    installment_id UUID NOT NULL REFERENCES installments(id) ON DELETE CASCADE,
+    principal DECIMAL(15, 2) NOT NULL CHECK (principal >= 0),
+    interest DECIMAL(15, 2) NOT NULL CHECK (interest >= 0),
+    PRIMARY KEY (repayment_id, installment_id)
+);
+
+CREATE INDEX idx_repayment_allocations_installment_id ON repayment_allocations(installment_id);
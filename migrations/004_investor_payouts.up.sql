@@ -0,0 +1,16 @@
+-- Create investor_payouts table (each investor's share of a borrower repayment)
+CREATE TABLE investor_payouts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    loan_id UUID NOT NULL REFERENCES loans(id) ON DELETE CASCADE,
+    investment_id UUID NOT NULL REFERENCES investments(id) ON DELETE CASCADE,
+    investor_id UUID NOT NULL,
+    repayment_id UUID NOT NULL REFERENCES repayments(id) ON DELETE CASCADE,
+    principal DECIMAL(15, 2) NOT NULL CHECK (principal >= 0),
+    interest DECIMAL(15, 2) NOT NULL CHECK (interest >= 0),
+    currency CHAR(3) NOT NULL DEFAULT 'IDR',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (repayment_id, investment_id)
+);
+
+CREATE INDEX idx_investor_payouts_investor_id ON investor_payouts(investor_id);
+CREATE INDEX idx_investor_payouts_loan_id ON investor_payouts(loan_id);
@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS journal_lines_balanced ON journal_lines;
+DROP FUNCTION IF EXISTS check_journal_entry_balanced();
+
+DROP TABLE IF EXISTS journal_lines;
+DROP TABLE IF EXISTS journal_entries;
+
+DROP TYPE IF EXISTS ledger_side;
+DROP TYPE IF EXISTS ledger_account_type;
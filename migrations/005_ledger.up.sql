@@ -0,0 +1,48 @@
+-- Create enum types for the double-entry ledger
+CREATE TYPE ledger_account_type AS ENUM ('investor_wallet', 'loan_escrow', 'borrower', 'platform_fees');
+CREATE TYPE ledger_side AS ENUM ('debit', 'credit');
+
+-- Create journal_entries table (one row per business event)
+CREATE TABLE journal_entries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    reference VARCHAR(255) NOT NULL UNIQUE,
+    description TEXT NOT NULL,
+    posted_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create journal_lines table (the debits and credits of each entry)
+-- owner_id is the nil UUID for the platform fee account
+CREATE TABLE journal_lines (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    entry_id UUID NOT NULL REFERENCES journal_entries(id) ON DELETE RESTRICT,
+    account_type ledger_account_type NOT NULL,
+    owner_id UUID NOT NULL,
+    side ledger_side NOT NULL,
+    amount DECIMAL(15, 2) NOT NULL CHECK (amount > 0),
+    currency CHAR(3) NOT NULL DEFAULT 'IDR'
+);
+
+CREATE INDEX idx_journal_lines_entry_id ON journal_lines(entry_id);
+CREATE INDEX idx_journal_lines_account ON journal_lines(account_type, owner_id, currency);
+
+-- Reject unbalanced entries when the transaction commits
+CREATE OR REPLACE FUNCTION check_journal_entry_balanced()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1
+        FROM journal_lines
+        WHERE entry_id = NEW.entry_id
+        GROUP BY currency
+        HAVING SUM(CASE WHEN side = 'debit' THEN amount ELSE -amount END) <> 0
+    ) THEN
+        RAISE EXCEPTION 'journal entry % is not balanced', NEW.entry_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
+
+CREATE CONSTRAINT TRIGGER journal_lines_balanced
+    AFTER INSERT ON journal_lines
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION check_journal_entry_balanced();
@@ -0,0 +1,8 @@
+ALTER TABLE loans DROP CONSTRAINT IF EXISTS fk_loans_borrower;
+
+DROP TRIGGER IF EXISTS update_borrowers_updated_at ON borrowers;
+DROP TABLE IF EXISTS borrowers;
+DROP TYPE IF EXISTS kyc_status;
+
+-- Enum values cannot be dropped; remove the borrower logins instead
+DELETE FROM users WHERE user_type = 'borrower';
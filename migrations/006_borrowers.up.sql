@@ -0,0 +1,46 @@
+-- Borrowers can sign in to see their own loans
+ALTER TYPE user_type ADD VALUE IF NOT EXISTS 'borrower';
+
+-- Create enum type for KYC review status
+CREATE TYPE kyc_status AS ENUM ('pending', 'verified', 'rejected');
+
+-- Create borrowers table; user_id is set once the borrower has a login
+CREATE TABLE borrowers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID UNIQUE REFERENCES users(id) ON DELETE SET NULL,
+    name VARCHAR(255) NOT NULL,
+    national_id VARCHAR(32) UNIQUE,
+    phone VARCHAR(20),
+    address TEXT,
+    date_of_birth DATE,
+    kyc_status kyc_status NOT NULL DEFAULT 'pending',
+    kyc_reviewed_by UUID REFERENCES employees(id),
+    kyc_reviewed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_borrowers_kyc_status ON borrowers(kyc_status);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_borrowers_updated_at BEFORE UPDATE ON borrowers
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Backfill borrowers referenced by existing loans so the foreign key holds
+INSERT INTO borrowers (id, name)
+SELECT DISTINCT borrower_id, 'Unregistered borrower'
+FROM loans
+ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE loans
+    ADD CONSTRAINT fk_loans_borrower FOREIGN KEY (borrower_id) REFERENCES borrowers(id) ON DELETE RESTRICT;
+
+-- Seed sample borrowers
+-- Password for all: "password123" (hashed with bcrypt)
+INSERT INTO users (id, email, password, user_type) VALUES
+('550e8400-e29b-41d4-a716-446655440020', 'borrower1@.com', '$2a$10$gopcD59oMAj.03TuQbLshO7eHp.yqEqfzn1TbEsFLrAWfCxE.mTEO', 'borrower'),
+('550e8400-e29b-41d4-a716-446655440021', 'borrower2@.com', '$2a$10$gopcD59oMAj.03TuQbLshO7eHp.yqEqfzn1TbEsFLrAWfCxE.mTEO', 'borrower');
+
+INSERT INTO borrowers (id, user_id, name, national_id, phone, address, kyc_status, kyc_reviewed_by, kyc_reviewed_at) VALUES
+('550e8400-e29b-41d4-a716-446655440020', '550e8400-e29b-41d4-a716-446655440020', 'Borrower 1', '3171000000000001', '+6281234567900', 'Jakarta, Indonesia', 'verified', '550e8400-e29b-41d4-a716-446655440001', NOW()),
+('550e8400-e29b-41d4-a716-446655440021', '550e8400-e29b-41d4-a716-446655440021', 'Borrower 2', NULL, '+6281234567901', 'Bogor, Indonesia', 'pending', NULL, NULL);
@@ -0,0 +1,13 @@
+-- Enum values cannot be dropped; move closed loans back to their last open state
+UPDATE loans l
+SET state = CASE
+    WHEN EXISTS (SELECT 1 FROM loan_approvals a WHERE a.loan_id = l.id) THEN 'approved'::loan_state
+    ELSE 'proposed'::loan_state
+END
+WHERE state IN ('rejected', 'cancelled');
+
+DROP INDEX IF EXISTS idx_investments_active_loan_id;
+ALTER TABLE investments DROP COLUMN IF EXISTS voided_at;
+
+DROP TABLE IF EXISTS loan_closures;
+DROP TYPE IF EXISTS loan_closure_reason;
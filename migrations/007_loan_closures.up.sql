@@ -0,0 +1,34 @@
+-- Add terminal states for rejected proposals and cancelled loans
+ALTER TYPE loan_state ADD VALUE IF NOT EXISTS 'rejected';
+ALTER TYPE loan_state ADD VALUE IF NOT EXISTS 'cancelled';
+
+-- Create enum type for closure reason codes
+CREATE TYPE loan_closure_reason AS ENUM (
+    'incomplete_documents',
+    'failed_verification',
+    'credit_risk',
+    'duplicate',
+    'borrower_withdrew',
+    'funding_shortfall',
+    'fraud_suspected',
+    'other'
+);
+
+-- Create loan_closures table (who rejected or cancelled a loan and why)
+CREATE TABLE loan_closures (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    loan_id UUID NOT NULL UNIQUE REFERENCES loans(id) ON DELETE CASCADE,
+    state loan_state NOT NULL CHECK (state IN ('rejected', 'cancelled')),
+    reason loan_closure_reason NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    employee_id UUID NOT NULL,
+    closed_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_loan_closures_reason ON loan_closures(reason);
+
+-- Investments in a cancelled loan are voided and refunded
+ALTER TABLE investments ADD COLUMN voided_at TIMESTAMP;
+
+CREATE INDEX idx_investments_active_loan_id ON investments(loan_id) WHERE voided_at IS NULL;
@@ -0,0 +1,5 @@
+-- Enum values cannot be dropped; reopen expired loans
+UPDATE loans SET state = 'approved' WHERE state = 'expired';
+
+DROP INDEX IF EXISTS idx_loans_funding_deadline;
+ALTER TABLE loans DROP COLUMN IF EXISTS funding_deadline;
@@ -0,0 +1,12 @@
+-- Approved loans that miss their funding deadline expire
+ALTER TYPE loan_state ADD VALUE IF NOT EXISTS 'expired';
+
+ALTER TABLE loans ADD COLUMN funding_deadline TIMESTAMP;
+
+-- Give loans already open for funding the default 30 day window
+UPDATE loans l
+SET funding_deadline = a.approval_date + INTERVAL '30 days'
+FROM loan_approvals a
+WHERE a.loan_id = l.id AND l.state = 'approved';
+
+CREATE INDEX idx_loans_funding_deadline ON loans(funding_deadline) WHERE state = 'approved';
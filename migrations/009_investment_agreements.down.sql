@@ -0,0 +1 @@
+ALTER TABLE investments DROP COLUMN IF EXISTS agreement_letter_url;
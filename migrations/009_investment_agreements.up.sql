@@ -0,0 +1,2 @@
+-- Each investment gets its own agreement letter with the investor's terms
+ALTER TABLE investments ADD COLUMN agreement_letter_url VARCHAR(500);
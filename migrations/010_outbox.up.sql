@@ -0,0 +1,18 @@
+-- Transactional outbox: messages are inserted in the same transaction as the
+-- change that caused them and delivered by a background dispatcher
+CREATE TYPE outbox_status AS ENUM ('pending', 'delivered', 'dead');
+
+CREATE TABLE outbox_messages (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    topic VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    status outbox_status NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMP
+);
+
+CREATE INDEX idx_outbox_messages_due ON outbox_messages(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_outbox_messages_dead ON outbox_messages(created_at) WHERE status = 'dead';
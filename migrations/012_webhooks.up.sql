@@ -0,0 +1,29 @@
+-- Partner endpoints receiving signed loan and investment events
+CREATE TABLE webhook_subscriptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    url TEXT NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    events TEXT[] NOT NULL CHECK (cardinality(events) > 0),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhook_subscriptions_events ON webhook_subscriptions USING GIN (events) WHERE active;
+
+-- Every HTTP request made to deliver an event; retries are scheduled through
+-- outbox_messages
+CREATE TABLE webhook_attempts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    delivery_id UUID NOT NULL,
+    subscription_id UUID NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    event VARCHAR(100) NOT NULL,
+    attempt INTEGER NOT NULL,
+    status_code INTEGER,
+    error TEXT,
+    duration_ms BIGINT NOT NULL,
+    attempted_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhook_attempts_subscription_id ON webhook_attempts(subscription_id, attempted_at DESC);
+CREATE INDEX idx_webhook_attempts_delivery_id ON webhook_attempts(delivery_id);
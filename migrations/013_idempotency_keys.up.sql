@@ -0,0 +1,14 @@
+-- Idempotency keys with the fingerprint of the request that first used them
+-- and, once it succeeded, its response. expires_at ends the lease of an
+-- in-progress key or the retention of a completed one.
+CREATE TABLE idempotency_keys (
+    key VARCHAR(255) PRIMARY KEY,
+    fingerprint VARCHAR(64) NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('in_progress', 'completed')),
+    response JSONB,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (status = 'in_progress' OR response IS NOT NULL)
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
@@ -0,0 +1,2 @@
+ALTER TABLE loans DROP CONSTRAINT IF EXISTS chk_loans_funded_amount;
+ALTER TABLE loans DROP COLUMN IF EXISTS funded_amount;
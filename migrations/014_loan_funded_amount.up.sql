@@ -0,0 +1,16 @@
+-- Keep each loan's total active investment on the loan row, so the database
+-- itself rejects investments past the principal
+ALTER TABLE loans ADD COLUMN funded_amount DECIMAL(15, 2) NOT NULL DEFAULT 0;
+
+UPDATE loans l
+SET funded_amount = i.total
+FROM (
+    SELECT loan_id, SUM(amount) AS total
+    FROM investments
+    WHERE voided_at IS NULL
+    GROUP BY loan_id
+) i
+WHERE i.loan_id = l.id;
+
+ALTER TABLE loans
+    ADD CONSTRAINT chk_loans_funded_amount CHECK (funded_amount >= 0 AND funded_amount <= principal_amount);
@@ -0,0 +1,2 @@
+-- Count loan updates so an update made from a stale copy can be rejected
+ALTER TABLE loans ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_loans_rate_id;
+DROP INDEX IF EXISTS idx_loans_principal_amount_id;
+DROP INDEX IF EXISTS idx_loans_updated_at_id;
+DROP INDEX IF EXISTS idx_loans_created_at_id;
+CREATE INDEX idx_loans_created_at ON loans(created_at);
@@ -0,0 +1,6 @@
+-- Keyset pagination orders loans by the sort column and then by id
+DROP INDEX IF EXISTS idx_loans_created_at;
+CREATE INDEX idx_loans_created_at_id ON loans(created_at, id);
+CREATE INDEX idx_loans_updated_at_id ON loans(updated_at, id);
+CREATE INDEX idx_loans_principal_amount_id ON loans(principal_amount, id);
+CREATE INDEX idx_loans_rate_id ON loans(rate, id);
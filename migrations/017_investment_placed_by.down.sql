@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_investments_placed_by;
+ALTER TABLE investments DROP COLUMN IF EXISTS placed_by;
@@ -0,0 +1,4 @@
+-- Admins may invest on an investor's behalf; record which employee did
+ALTER TABLE investments ADD COLUMN placed_by UUID REFERENCES employees(id) ON DELETE RESTRICT;
+
+CREATE INDEX idx_investments_placed_by ON investments(placed_by) WHERE placed_by IS NOT NULL;
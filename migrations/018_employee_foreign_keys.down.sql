@@ -0,0 +1,3 @@
+ALTER TABLE loan_closures DROP CONSTRAINT IF EXISTS fk_loan_closures_employee;
+ALTER TABLE disbursements DROP CONSTRAINT IF EXISTS fk_disbursements_employee;
+ALTER TABLE loan_approvals DROP CONSTRAINT IF EXISTS fk_loan_approvals_employee;
@@ -0,0 +1,9 @@
+-- Approvals, disbursements and closures record the employee who made them
+ALTER TABLE loan_approvals
+    ADD CONSTRAINT fk_loan_approvals_employee FOREIGN KEY (employee_id) REFERENCES employees(id) ON DELETE RESTRICT;
+
+ALTER TABLE disbursements
+    ADD CONSTRAINT fk_disbursements_employee FOREIGN KEY (employee_id) REFERENCES employees(id) ON DELETE RESTRICT;
+
+ALTER TABLE loan_closures
+    ADD CONSTRAINT fk_loan_closures_employee FOREIGN KEY (employee_id) REFERENCES employees(id) ON DELETE RESTRICT;
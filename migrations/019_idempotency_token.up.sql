@@ -0,0 +1,4 @@
+-- The reservation a key is held under. Only the request holding the token may
+-- renew, complete or release the key, so one whose lease ran out cannot touch
+-- the reservation of the request that took the key over.
+ALTER TABLE idempotency_keys ADD COLUMN token VARCHAR(36) NOT NULL DEFAULT '';